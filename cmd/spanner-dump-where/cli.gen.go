@@ -9,6 +9,11 @@ import (
 
 type CLIHandler interface {
 	Run(input Input) error
+	Run_Convert(input Input_Convert) error
+	Run_Delta(input Input_Delta) error
+	Run_Diff(input Input_Diff) error
+	Run_LoadEmulator(input Input_LoadEmulator) error
+	Run_LoadFixtures(input Input_LoadFixtures) error
 }
 
 func Run(handler CLIHandler, args []string) error {
@@ -18,44 +23,116 @@ func Run(handler CLIHandler, args []string) error {
 		var input Input
 		input.resolveInput(subcommandPath, options, arguments)
 		return handler.Run(input)
+	case "convert":
+		var input Input_Convert
+		input.resolveInput(subcommandPath, options, arguments)
+		return handler.Run_Convert(input)
+	case "delta":
+		var input Input_Delta
+		input.resolveInput(subcommandPath, options, arguments)
+		return handler.Run_Delta(input)
+	case "diff":
+		var input Input_Diff
+		input.resolveInput(subcommandPath, options, arguments)
+		return handler.Run_Diff(input)
+	case "load-emulator":
+		var input Input_LoadEmulator
+		input.resolveInput(subcommandPath, options, arguments)
+		return handler.Run_LoadEmulator(input)
+	case "load-fixtures":
+		var input Input_LoadFixtures
+		input.resolveInput(subcommandPath, options, arguments)
+		return handler.Run_LoadFixtures(input)
 	}
 	return nil
 }
 
 type Input struct {
-	Opt_BulkSize  int64
-	Opt_Database  string
-	Opt_From      []string
-	Opt_Instance  string
-	Opt_NoData    bool
-	Opt_NoDdl     bool
-	Opt_Project   string
-	Opt_Sort      bool
-	Opt_Timestamp string
-	Opt_Upsert    bool
-	Opt_Where     []string
-	Subcommand    []string
-	Options       []string
-	Arguments     []string
+	Opt_BulkSize            int64
+	Opt_CheckSchema         bool
+	Opt_Checkpoint          string
+	Opt_DataBoost           bool
+	Opt_Database            string
+	Opt_ExactStaleness      string
+	Opt_Format              string
+	Opt_From                []string
+	Opt_GoFunc              string
+	Opt_GoPackage           string
+	Opt_Instance            string
+	Opt_Mask                []string
+	Opt_MaskFile            string
+	Opt_MaskKey             string
+	Opt_MaxMutations        int64
+	Opt_MaxStaleness        string
+	Opt_MaxStatementBytes   int64
+	Opt_MinReadTimestamp    string
+	Opt_NoData              bool
+	Opt_NoDdl               bool
+	Opt_NoHeader            bool
+	Opt_Output              string
+	Opt_PageSize            int64
+	Opt_Parallelism         int64
+	Opt_Partitioned         bool
+	Opt_Priority            string
+	Opt_ProgressInterval    string
+	Opt_Project             string
+	Opt_Report              string
+	Opt_RequestTag          string
+	Opt_SchemaChangeRetries int64
+	Opt_SnapshotDdl         bool
+	Opt_Sort                bool
+	Opt_Timestamp           string
+	Opt_TransformCmd        string
+	Opt_Upsert              bool
+	Opt_Where               []string
+	Subcommand              []string
+	Options                 []string
+	Arguments               []string
 
 	ErrorMessage string
 }
 
 func (input *Input) resolveInput(subcommand, options, arguments []string) {
 	*input = Input{Opt_BulkSize: 100,
-		Opt_Database:  "",
-		Opt_From:      []string{},
-		Opt_Instance:  "",
-		Opt_NoData:    false,
-		Opt_NoDdl:     false,
-		Opt_Project:   "",
-		Opt_Sort:      false,
-		Opt_Timestamp: "",
-		Opt_Upsert:    false,
-		Opt_Where:     []string{},
-		Subcommand:    subcommand,
-		Options:       options,
-		Arguments:     arguments,
+		Opt_CheckSchema:         false,
+		Opt_Checkpoint:          "",
+		Opt_DataBoost:           false,
+		Opt_Database:            "",
+		Opt_ExactStaleness:      "",
+		Opt_Format:              "sql",
+		Opt_From:                []string{},
+		Opt_GoFunc:              "Mutations",
+		Opt_GoPackage:           "fixtures",
+		Opt_Instance:            "",
+		Opt_Mask:                []string{},
+		Opt_MaskFile:            "",
+		Opt_MaskKey:             "",
+		Opt_MaxMutations:        0,
+		Opt_MaxStaleness:        "",
+		Opt_MaxStatementBytes:   0,
+		Opt_MinReadTimestamp:    "",
+		Opt_NoData:              false,
+		Opt_NoDdl:               false,
+		Opt_NoHeader:            false,
+		Opt_Output:              "",
+		Opt_PageSize:            0,
+		Opt_Parallelism:         1,
+		Opt_Partitioned:         false,
+		Opt_Priority:            "",
+		Opt_ProgressInterval:    "10s",
+		Opt_Project:             "",
+		Opt_Report:              "",
+		Opt_RequestTag:          "",
+		Opt_SchemaChangeRetries: 0,
+		Opt_SnapshotDdl:         false,
+		Opt_Sort:                false,
+		Opt_Timestamp:           "",
+		Opt_TransformCmd:        "",
+		Opt_Upsert:              false,
+		Opt_Where:               []string{},
+		Subcommand:              subcommand,
+		Options:                 options,
+		Arguments:               arguments,
 	}
 
 	for _, arg := range input.Options {
@@ -75,6 +152,40 @@ func (input *Input) resolveInput(subcommand, options, arguments []string) {
 				input.Opt_BulkSize = v.(int64)
 			}
 
+		case "-check-schema":
+			if !cut {
+				lit = "true"
+			}
+			if v, err := parseValue("bool", lit); err != nil {
+				input.ErrorMessage = fmt.Sprintf("value %q is not assignable to option %q", lit, optName)
+				return
+			} else {
+				input.Opt_CheckSchema = v.(bool)
+			}
+
+		case "-checkpoint":
+			if !cut {
+				input.ErrorMessage = fmt.Sprintf("value is not specified to option %q", optName)
+				return
+			}
+			if v, err := parseValue("string", lit); err != nil {
+				input.ErrorMessage = fmt.Sprintf("value %q is not assignable to option %q", lit, optName)
+				return
+			} else {
+				input.Opt_Checkpoint = v.(string)
+			}
+
+		case "-data-boost":
+			if !cut {
+				lit = "true"
+			}
+			if v, err := parseValue("bool", lit); err != nil {
+				input.ErrorMessage = fmt.Sprintf("value %q is not assignable to option %q", lit, optName)
+				return
+			} else {
+				input.Opt_DataBoost = v.(bool)
+			}
+
 		case "-database", "-d":
 			if !cut {
 				input.ErrorMessage = fmt.Sprintf("value is not specified to option %q", optName)
@@ -87,6 +198,30 @@ func (input *Input) resolveInput(subcommand, options, arguments []string) {
 				input.Opt_Database = v.(string)
 			}
 
+		case "-exact-staleness":
+			if !cut {
+				input.ErrorMessage = fmt.Sprintf("value is not specified to option %q", optName)
+				return
+			}
+			if v, err := parseValue("string", lit); err != nil {
+				input.ErrorMessage = fmt.Sprintf("value %q is not assignable to option %q", lit, optName)
+				return
+			} else {
+				input.Opt_ExactStaleness = v.(string)
+			}
+
+		case "-format", "-f":
+			if !cut {
+				input.ErrorMessage = fmt.Sprintf("value is not specified to option %q", optName)
+				return
+			}
+			if v, err := parseValue("string", lit); err != nil {
+				input.ErrorMessage = fmt.Sprintf("value %q is not assignable to option %q", lit, optName)
+				return
+			} else {
+				input.Opt_Format = v.(string)
+			}
+
 		case "-from":
 			if !cut {
 				input.ErrorMessage = fmt.Sprintf("value is not specified to option %q", optName)
@@ -99,6 +234,30 @@ func (input *Input) resolveInput(subcommand, options, arguments []string) {
 				input.Opt_From = append(input.Opt_From, v.([]string)[0])
 			}
 
+		case "-go-func":
+			if !cut {
+				input.ErrorMessage = fmt.Sprintf("value is not specified to option %q", optName)
+				return
+			}
+			if v, err := parseValue("string", lit); err != nil {
+				input.ErrorMessage = fmt.Sprintf("value %q is not assignable to option %q", lit, optName)
+				return
+			} else {
+				input.Opt_GoFunc = v.(string)
+			}
+
+		case "-go-package":
+			if !cut {
+				input.ErrorMessage = fmt.Sprintf("value is not specified to option %q", optName)
+				return
+			}
+			if v, err := parseValue("string", lit); err != nil {
+				input.ErrorMessage = fmt.Sprintf("value %q is not assignable to option %q", lit, optName)
+				return
+			} else {
+				input.Opt_GoPackage = v.(string)
+			}
+
 		case "-instance", "-i":
 			if !cut {
 				input.ErrorMessage = fmt.Sprintf("value is not specified to option %q", optName)
@@ -111,6 +270,90 @@ func (input *Input) resolveInput(subcommand, options, arguments []string) {
 				input.Opt_Instance = v.(string)
 			}
 
+		case "-mask":
+			if !cut {
+				input.ErrorMessage = fmt.Sprintf("value is not specified to option %q", optName)
+				return
+			}
+			if v, err := parseValue("[]string", lit); err != nil {
+				input.ErrorMessage = fmt.Sprintf("value %q is not assignable to option %q", lit, optName)
+				return
+			} else {
+				input.Opt_Mask = append(input.Opt_Mask, v.([]string)[0])
+			}
+
+		case "-mask-file":
+			if !cut {
+				input.ErrorMessage = fmt.Sprintf("value is not specified to option %q", optName)
+				return
+			}
+			if v, err := parseValue("string", lit); err != nil {
+				input.ErrorMessage = fmt.Sprintf("value %q is not assignable to option %q", lit, optName)
+				return
+			} else {
+				input.Opt_MaskFile = v.(string)
+			}
+
+		case "-mask-key":
+			if !cut {
+				input.ErrorMessage = fmt.Sprintf("value is not specified to option %q", optName)
+				return
+			}
+			if v, err := parseValue("string", lit); err != nil {
+				input.ErrorMessage = fmt.Sprintf("value %q is not assignable to option %q", lit, optName)
+				return
+			} else {
+				input.Opt_MaskKey = v.(string)
+			}
+
+		case "-max-mutations":
+			if !cut {
+				input.ErrorMessage = fmt.Sprintf("value is not specified to option %q", optName)
+				return
+			}
+			if v, err := parseValue("int64", lit); err != nil {
+				input.ErrorMessage = fmt.Sprintf("value %q is not assignable to option %q", lit, optName)
+				return
+			} else {
+				input.Opt_MaxMutations = v.(int64)
+			}
+
+		case "-max-staleness":
+			if !cut {
+				input.ErrorMessage = fmt.Sprintf("value is not specified to option %q", optName)
+				return
+			}
+			if v, err := parseValue("string", lit); err != nil {
+				input.ErrorMessage = fmt.Sprintf("value %q is not assignable to option %q", lit, optName)
+				return
+			} else {
+				input.Opt_MaxStaleness = v.(string)
+			}
+
+		case "-max-statement-bytes":
+			if !cut {
+				input.ErrorMessage = fmt.Sprintf("value is not specified to option %q", optName)
+				return
+			}
+			if v, err := parseValue("int64", lit); err != nil {
+				input.ErrorMessage = fmt.Sprintf("value %q is not assignable to option %q", lit, optName)
+				return
+			} else {
+				input.Opt_MaxStatementBytes = v.(int64)
+			}
+
+		case "-min-read-timestamp":
+			if !cut {
+				input.ErrorMessage = fmt.Sprintf("value is not specified to option %q", optName)
+				return
+			}
+			if v, err := parseValue("string", lit); err != nil {
+				input.ErrorMessage = fmt.Sprintf("value %q is not assignable to option %q", lit, optName)
+				return
+			} else {
+				input.Opt_MinReadTimestamp = v.(string)
+			}
+
 		case "-no-data":
 			if !cut {
 				lit = "true"
@@ -133,7 +376,18 @@ func (input *Input) resolveInput(subcommand, options, arguments []string) {
 				input.Opt_NoDdl = v.(bool)
 			}
 
-		case "-project", "-p":
+		case "-no-header":
+			if !cut {
+				lit = "true"
+			}
+			if v, err := parseValue("bool", lit); err != nil {
+				input.ErrorMessage = fmt.Sprintf("value %q is not assignable to option %q", lit, optName)
+				return
+			} else {
+				input.Opt_NoHeader = v.(bool)
+			}
+
+		case "-output", "-o":
 			if !cut {
 				input.ErrorMessage = fmt.Sprintf("value is not specified to option %q", optName)
 				return
@@ -142,10 +396,34 @@ func (input *Input) resolveInput(subcommand, options, arguments []string) {
 				input.ErrorMessage = fmt.Sprintf("value %q is not assignable to option %q", lit, optName)
 				return
 			} else {
-				input.Opt_Project = v.(string)
+				input.Opt_Output = v.(string)
 			}
 
-		case "-sort":
+		case "-page-size":
+			if !cut {
+				input.ErrorMessage = fmt.Sprintf("value is not specified to option %q", optName)
+				return
+			}
+			if v, err := parseValue("int64", lit); err != nil {
+				input.ErrorMessage = fmt.Sprintf("value %q is not assignable to option %q", lit, optName)
+				return
+			} else {
+				input.Opt_PageSize = v.(int64)
+			}
+
+		case "-parallelism":
+			if !cut {
+				input.ErrorMessage = fmt.Sprintf("value is not specified to option %q", optName)
+				return
+			}
+			if v, err := parseValue("int64", lit); err != nil {
+				input.ErrorMessage = fmt.Sprintf("value %q is not assignable to option %q", lit, optName)
+				return
+			} else {
+				input.Opt_Parallelism = v.(int64)
+			}
+
+		case "-partitioned":
 			if !cut {
 				lit = "true"
 			}
@@ -153,10 +431,10 @@ func (input *Input) resolveInput(subcommand, options, arguments []string) {
 				input.ErrorMessage = fmt.Sprintf("value %q is not assignable to option %q", lit, optName)
 				return
 			} else {
-				input.Opt_Sort = v.(bool)
+				input.Opt_Partitioned = v.(bool)
 			}
 
-		case "-timestamp", "-t":
+		case "-priority":
 			if !cut {
 				input.ErrorMessage = fmt.Sprintf("value is not specified to option %q", optName)
 				return
@@ -165,10 +443,70 @@ func (input *Input) resolveInput(subcommand, options, arguments []string) {
 				input.ErrorMessage = fmt.Sprintf("value %q is not assignable to option %q", lit, optName)
 				return
 			} else {
-				input.Opt_Timestamp = v.(string)
+				input.Opt_Priority = v.(string)
 			}
 
-		case "-upsert":
+		case "-progress-interval":
+			if !cut {
+				input.ErrorMessage = fmt.Sprintf("value is not specified to option %q", optName)
+				return
+			}
+			if v, err := parseValue("string", lit); err != nil {
+				input.ErrorMessage = fmt.Sprintf("value %q is not assignable to option %q", lit, optName)
+				return
+			} else {
+				input.Opt_ProgressInterval = v.(string)
+			}
+
+		case "-project", "-p":
+			if !cut {
+				input.ErrorMessage = fmt.Sprintf("value is not specified to option %q", optName)
+				return
+			}
+			if v, err := parseValue("string", lit); err != nil {
+				input.ErrorMessage = fmt.Sprintf("value %q is not assignable to option %q", lit, optName)
+				return
+			} else {
+				input.Opt_Project = v.(string)
+			}
+
+		case "-report":
+			if !cut {
+				input.ErrorMessage = fmt.Sprintf("value is not specified to option %q", optName)
+				return
+			}
+			if v, err := parseValue("string", lit); err != nil {
+				input.ErrorMessage = fmt.Sprintf("value %q is not assignable to option %q", lit, optName)
+				return
+			} else {
+				input.Opt_Report = v.(string)
+			}
+
+		case "-request-tag":
+			if !cut {
+				input.ErrorMessage = fmt.Sprintf("value is not specified to option %q", optName)
+				return
+			}
+			if v, err := parseValue("string", lit); err != nil {
+				input.ErrorMessage = fmt.Sprintf("value %q is not assignable to option %q", lit, optName)
+				return
+			} else {
+				input.Opt_RequestTag = v.(string)
+			}
+
+		case "-schema-change-retries":
+			if !cut {
+				input.ErrorMessage = fmt.Sprintf("value is not specified to option %q", optName)
+				return
+			}
+			if v, err := parseValue("int64", lit); err != nil {
+				input.ErrorMessage = fmt.Sprintf("value %q is not assignable to option %q", lit, optName)
+				return
+			} else {
+				input.Opt_SchemaChangeRetries = v.(int64)
+			}
+
+		case "-snapshot-ddl":
 			if !cut {
 				lit = "true"
 			}
@@ -176,36 +514,811 @@ func (input *Input) resolveInput(subcommand, options, arguments []string) {
 				input.ErrorMessage = fmt.Sprintf("value %q is not assignable to option %q", lit, optName)
 				return
 			} else {
-				input.Opt_Upsert = v.(bool)
+				input.Opt_SnapshotDdl = v.(bool)
 			}
 
-		case "-where":
+		case "-sort":
+			if !cut {
+				lit = "true"
+			}
+			if v, err := parseValue("bool", lit); err != nil {
+				input.ErrorMessage = fmt.Sprintf("value %q is not assignable to option %q", lit, optName)
+				return
+			} else {
+				input.Opt_Sort = v.(bool)
+			}
+
+		case "-timestamp", "-t":
 			if !cut {
 				input.ErrorMessage = fmt.Sprintf("value is not specified to option %q", optName)
 				return
 			}
-			if v, err := parseValue("[]string", lit); err != nil {
+			if v, err := parseValue("string", lit); err != nil {
 				input.ErrorMessage = fmt.Sprintf("value %q is not assignable to option %q", lit, optName)
 				return
 			} else {
-				input.Opt_Where = append(input.Opt_Where, v.([]string)[0])
+				input.Opt_Timestamp = v.(string)
 			}
 
-		default:
-			input.ErrorMessage = fmt.Sprintf("unknown option %q", optName)
-			return
-		}
-	}
-
-	expectedArgs := 0
+		case "-transform-cmd":
+			if !cut {
+				input.ErrorMessage = fmt.Sprintf("value is not specified to option %q", optName)
+				return
+			}
+			if v, err := parseValue("string", lit); err != nil {
+				input.ErrorMessage = fmt.Sprintf("value %q is not assignable to option %q", lit, optName)
+				return
+			} else {
+				input.Opt_TransformCmd = v.(string)
+			}
+
+		case "-upsert":
+			if !cut {
+				lit = "true"
+			}
+			if v, err := parseValue("bool", lit); err != nil {
+				input.ErrorMessage = fmt.Sprintf("value %q is not assignable to option %q", lit, optName)
+				return
+			} else {
+				input.Opt_Upsert = v.(bool)
+			}
+
+		case "-where":
+			if !cut {
+				input.ErrorMessage = fmt.Sprintf("value is not specified to option %q", optName)
+				return
+			}
+			if v, err := parseValue("[]string", lit); err != nil {
+				input.ErrorMessage = fmt.Sprintf("value %q is not assignable to option %q", lit, optName)
+				return
+			} else {
+				input.Opt_Where = append(input.Opt_Where, v.([]string)[0])
+			}
+
+		default:
+			input.ErrorMessage = fmt.Sprintf("unknown option %q", optName)
+			return
+		}
+	}
+
+	expectedArgs := 0
+	func(...any) {}(expectedArgs)
+}
+
+type Input_Convert struct {
+	Opt_BulkSize          int64
+	Opt_Ddl               string
+	Opt_Format            string
+	Opt_GoFunc            string
+	Opt_GoPackage         string
+	Opt_MaxMutations      int64
+	Opt_MaxStatementBytes int64
+	Opt_Output            string
+	Opt_Upsert            bool
+	Arg_Input             string
+	Subcommand            []string
+	Options               []string
+	Arguments             []string
+
+	ErrorMessage string
+}
+
+func (input *Input_Convert) resolveInput(subcommand, options, arguments []string) {
+	*input = Input_Convert{Opt_BulkSize: 100,
+		Opt_Ddl:               "",
+		Opt_Format:            "sql",
+		Opt_GoFunc:            "Mutations",
+		Opt_GoPackage:         "fixtures",
+		Opt_MaxMutations:      0,
+		Opt_MaxStatementBytes: 0,
+		Opt_Output:            "",
+		Opt_Upsert:            false,
+		Subcommand:            subcommand,
+		Options:               options,
+		Arguments:             arguments,
+	}
+
+	for _, arg := range input.Options {
+		optName, lit, cut := strings.Cut(arg, "=")
+		func(...any) {}(optName, lit, cut)
+
+		switch optName {
+		case "-bulk-size":
+			if !cut {
+				input.ErrorMessage = fmt.Sprintf("value is not specified to option %q", optName)
+				return
+			}
+			if v, err := parseValue("int64", lit); err != nil {
+				input.ErrorMessage = fmt.Sprintf("value %q is not assignable to option %q", lit, optName)
+				return
+			} else {
+				input.Opt_BulkSize = v.(int64)
+			}
+
+		case "-ddl":
+			if !cut {
+				input.ErrorMessage = fmt.Sprintf("value is not specified to option %q", optName)
+				return
+			}
+			if v, err := parseValue("string", lit); err != nil {
+				input.ErrorMessage = fmt.Sprintf("value %q is not assignable to option %q", lit, optName)
+				return
+			} else {
+				input.Opt_Ddl = v.(string)
+			}
+
+		case "-format", "-f":
+			if !cut {
+				input.ErrorMessage = fmt.Sprintf("value is not specified to option %q", optName)
+				return
+			}
+			if v, err := parseValue("string", lit); err != nil {
+				input.ErrorMessage = fmt.Sprintf("value %q is not assignable to option %q", lit, optName)
+				return
+			} else {
+				input.Opt_Format = v.(string)
+			}
+
+		case "-go-func":
+			if !cut {
+				input.ErrorMessage = fmt.Sprintf("value is not specified to option %q", optName)
+				return
+			}
+			if v, err := parseValue("string", lit); err != nil {
+				input.ErrorMessage = fmt.Sprintf("value %q is not assignable to option %q", lit, optName)
+				return
+			} else {
+				input.Opt_GoFunc = v.(string)
+			}
+
+		case "-go-package":
+			if !cut {
+				input.ErrorMessage = fmt.Sprintf("value is not specified to option %q", optName)
+				return
+			}
+			if v, err := parseValue("string", lit); err != nil {
+				input.ErrorMessage = fmt.Sprintf("value %q is not assignable to option %q", lit, optName)
+				return
+			} else {
+				input.Opt_GoPackage = v.(string)
+			}
+
+		case "-max-mutations":
+			if !cut {
+				input.ErrorMessage = fmt.Sprintf("value is not specified to option %q", optName)
+				return
+			}
+			if v, err := parseValue("int64", lit); err != nil {
+				input.ErrorMessage = fmt.Sprintf("value %q is not assignable to option %q", lit, optName)
+				return
+			} else {
+				input.Opt_MaxMutations = v.(int64)
+			}
+
+		case "-max-statement-bytes":
+			if !cut {
+				input.ErrorMessage = fmt.Sprintf("value is not specified to option %q", optName)
+				return
+			}
+			if v, err := parseValue("int64", lit); err != nil {
+				input.ErrorMessage = fmt.Sprintf("value %q is not assignable to option %q", lit, optName)
+				return
+			} else {
+				input.Opt_MaxStatementBytes = v.(int64)
+			}
+
+		case "-output", "-o":
+			if !cut {
+				input.ErrorMessage = fmt.Sprintf("value is not specified to option %q", optName)
+				return
+			}
+			if v, err := parseValue("string", lit); err != nil {
+				input.ErrorMessage = fmt.Sprintf("value %q is not assignable to option %q", lit, optName)
+				return
+			} else {
+				input.Opt_Output = v.(string)
+			}
+
+		case "-upsert":
+			if !cut {
+				lit = "true"
+			}
+			if v, err := parseValue("bool", lit); err != nil {
+				input.ErrorMessage = fmt.Sprintf("value %q is not assignable to option %q", lit, optName)
+				return
+			} else {
+				input.Opt_Upsert = v.(bool)
+			}
+
+		default:
+			input.ErrorMessage = fmt.Sprintf("unknown option %q", optName)
+			return
+		}
+	}
+
+	expectedArgs := 1
+	func(...any) {}(expectedArgs)
+	if len(input.Arguments) != expectedArgs {
+		input.ErrorMessage = fmt.Sprintf("wrong number of arguments: required %d, got %d", expectedArgs, len(input.Arguments))
+		return
+	}
+
+	if v, err := parseValue("string", input.Arguments[0]); err != nil {
+		input.ErrorMessage = fmt.Sprintf("value %q is not assignable to argument %q", input.Arguments[0], "<input>")
+		return
+	} else {
+		input.Arg_Input = v.(string)
+	}
+}
+
+type Input_Delta struct {
+	Opt_Database      string
+	Opt_Ddl           string
+	Opt_Dump          string
+	Opt_From          []string
+	Opt_FromTimestamp string
+	Opt_Instance      string
+	Opt_Priority      string
+	Opt_Project       string
+	Opt_ToTimestamp   string
+	Opt_Where         []string
+	Subcommand        []string
+	Options           []string
+	Arguments         []string
+
+	ErrorMessage string
+}
+
+func (input *Input_Delta) resolveInput(subcommand, options, arguments []string) {
+	*input = Input_Delta{Opt_Database: "",
+		Opt_Ddl:           "",
+		Opt_Dump:          "",
+		Opt_From:          []string{},
+		Opt_FromTimestamp: "",
+		Opt_Instance:      "",
+		Opt_Priority:      "",
+		Opt_Project:       "",
+		Opt_ToTimestamp:   "",
+		Opt_Where:         []string{},
+		Subcommand:        subcommand,
+		Options:           options,
+		Arguments:         arguments,
+	}
+
+	for _, arg := range input.Options {
+		optName, lit, cut := strings.Cut(arg, "=")
+		func(...any) {}(optName, lit, cut)
+
+		switch optName {
+		case "-database", "-d":
+			if !cut {
+				input.ErrorMessage = fmt.Sprintf("value is not specified to option %q", optName)
+				return
+			}
+			if v, err := parseValue("string", lit); err != nil {
+				input.ErrorMessage = fmt.Sprintf("value %q is not assignable to option %q", lit, optName)
+				return
+			} else {
+				input.Opt_Database = v.(string)
+			}
+
+		case "-ddl":
+			if !cut {
+				input.ErrorMessage = fmt.Sprintf("value is not specified to option %q", optName)
+				return
+			}
+			if v, err := parseValue("string", lit); err != nil {
+				input.ErrorMessage = fmt.Sprintf("value %q is not assignable to option %q", lit, optName)
+				return
+			} else {
+				input.Opt_Ddl = v.(string)
+			}
+
+		case "-dump":
+			if !cut {
+				input.ErrorMessage = fmt.Sprintf("value is not specified to option %q", optName)
+				return
+			}
+			if v, err := parseValue("string", lit); err != nil {
+				input.ErrorMessage = fmt.Sprintf("value %q is not assignable to option %q", lit, optName)
+				return
+			} else {
+				input.Opt_Dump = v.(string)
+			}
+
+		case "-from":
+			if !cut {
+				input.ErrorMessage = fmt.Sprintf("value is not specified to option %q", optName)
+				return
+			}
+			if v, err := parseValue("[]string", lit); err != nil {
+				input.ErrorMessage = fmt.Sprintf("value %q is not assignable to option %q", lit, optName)
+				return
+			} else {
+				input.Opt_From = append(input.Opt_From, v.([]string)[0])
+			}
+
+		case "-from-timestamp":
+			if !cut {
+				input.ErrorMessage = fmt.Sprintf("value is not specified to option %q", optName)
+				return
+			}
+			if v, err := parseValue("string", lit); err != nil {
+				input.ErrorMessage = fmt.Sprintf("value %q is not assignable to option %q", lit, optName)
+				return
+			} else {
+				input.Opt_FromTimestamp = v.(string)
+			}
+
+		case "-instance", "-i":
+			if !cut {
+				input.ErrorMessage = fmt.Sprintf("value is not specified to option %q", optName)
+				return
+			}
+			if v, err := parseValue("string", lit); err != nil {
+				input.ErrorMessage = fmt.Sprintf("value %q is not assignable to option %q", lit, optName)
+				return
+			} else {
+				input.Opt_Instance = v.(string)
+			}
+
+		case "-priority":
+			if !cut {
+				input.ErrorMessage = fmt.Sprintf("value is not specified to option %q", optName)
+				return
+			}
+			if v, err := parseValue("string", lit); err != nil {
+				input.ErrorMessage = fmt.Sprintf("value %q is not assignable to option %q", lit, optName)
+				return
+			} else {
+				input.Opt_Priority = v.(string)
+			}
+
+		case "-project", "-p":
+			if !cut {
+				input.ErrorMessage = fmt.Sprintf("value is not specified to option %q", optName)
+				return
+			}
+			if v, err := parseValue("string", lit); err != nil {
+				input.ErrorMessage = fmt.Sprintf("value %q is not assignable to option %q", lit, optName)
+				return
+			} else {
+				input.Opt_Project = v.(string)
+			}
+
+		case "-to-timestamp":
+			if !cut {
+				input.ErrorMessage = fmt.Sprintf("value is not specified to option %q", optName)
+				return
+			}
+			if v, err := parseValue("string", lit); err != nil {
+				input.ErrorMessage = fmt.Sprintf("value %q is not assignable to option %q", lit, optName)
+				return
+			} else {
+				input.Opt_ToTimestamp = v.(string)
+			}
+
+		case "-where":
+			if !cut {
+				input.ErrorMessage = fmt.Sprintf("value is not specified to option %q", optName)
+				return
+			}
+			if v, err := parseValue("[]string", lit); err != nil {
+				input.ErrorMessage = fmt.Sprintf("value %q is not assignable to option %q", lit, optName)
+				return
+			} else {
+				input.Opt_Where = append(input.Opt_Where, v.([]string)[0])
+			}
+
+		default:
+			input.ErrorMessage = fmt.Sprintf("unknown option %q", optName)
+			return
+		}
+	}
+
+	expectedArgs := 0
+	func(...any) {}(expectedArgs)
+}
+
+type Input_Diff struct {
+	Opt_Database      string
+	Opt_Format        string
+	Opt_From          []string
+	Opt_FromTimestamp string
+	Opt_Instance      string
+	Opt_Priority      string
+	Opt_Project       string
+	Opt_ToDatabase    string
+	Opt_ToInstance    string
+	Opt_ToProject     string
+	Opt_ToTimestamp   string
+	Opt_Where         []string
+	Subcommand        []string
+	Options           []string
+	Arguments         []string
+
+	ErrorMessage string
+}
+
+func (input *Input_Diff) resolveInput(subcommand, options, arguments []string) {
+	*input = Input_Diff{Opt_Database: "",
+		Opt_Format:        "text",
+		Opt_From:          []string{},
+		Opt_FromTimestamp: "",
+		Opt_Instance:      "",
+		Opt_Priority:      "",
+		Opt_Project:       "",
+		Opt_ToDatabase:    "",
+		Opt_ToInstance:    "",
+		Opt_ToProject:     "",
+		Opt_ToTimestamp:   "",
+		Opt_Where:         []string{},
+		Subcommand:        subcommand,
+		Options:           options,
+		Arguments:         arguments,
+	}
+
+	for _, arg := range input.Options {
+		optName, lit, cut := strings.Cut(arg, "=")
+		func(...any) {}(optName, lit, cut)
+
+		switch optName {
+		case "-database", "-d":
+			if !cut {
+				input.ErrorMessage = fmt.Sprintf("value is not specified to option %q", optName)
+				return
+			}
+			if v, err := parseValue("string", lit); err != nil {
+				input.ErrorMessage = fmt.Sprintf("value %q is not assignable to option %q", lit, optName)
+				return
+			} else {
+				input.Opt_Database = v.(string)
+			}
+
+		case "-format", "-f":
+			if !cut {
+				input.ErrorMessage = fmt.Sprintf("value is not specified to option %q", optName)
+				return
+			}
+			if v, err := parseValue("string", lit); err != nil {
+				input.ErrorMessage = fmt.Sprintf("value %q is not assignable to option %q", lit, optName)
+				return
+			} else {
+				input.Opt_Format = v.(string)
+			}
+
+		case "-from":
+			if !cut {
+				input.ErrorMessage = fmt.Sprintf("value is not specified to option %q", optName)
+				return
+			}
+			if v, err := parseValue("[]string", lit); err != nil {
+				input.ErrorMessage = fmt.Sprintf("value %q is not assignable to option %q", lit, optName)
+				return
+			} else {
+				input.Opt_From = append(input.Opt_From, v.([]string)[0])
+			}
+
+		case "-from-timestamp":
+			if !cut {
+				input.ErrorMessage = fmt.Sprintf("value is not specified to option %q", optName)
+				return
+			}
+			if v, err := parseValue("string", lit); err != nil {
+				input.ErrorMessage = fmt.Sprintf("value %q is not assignable to option %q", lit, optName)
+				return
+			} else {
+				input.Opt_FromTimestamp = v.(string)
+			}
+
+		case "-instance", "-i":
+			if !cut {
+				input.ErrorMessage = fmt.Sprintf("value is not specified to option %q", optName)
+				return
+			}
+			if v, err := parseValue("string", lit); err != nil {
+				input.ErrorMessage = fmt.Sprintf("value %q is not assignable to option %q", lit, optName)
+				return
+			} else {
+				input.Opt_Instance = v.(string)
+			}
+
+		case "-priority":
+			if !cut {
+				input.ErrorMessage = fmt.Sprintf("value is not specified to option %q", optName)
+				return
+			}
+			if v, err := parseValue("string", lit); err != nil {
+				input.ErrorMessage = fmt.Sprintf("value %q is not assignable to option %q", lit, optName)
+				return
+			} else {
+				input.Opt_Priority = v.(string)
+			}
+
+		case "-project", "-p":
+			if !cut {
+				input.ErrorMessage = fmt.Sprintf("value is not specified to option %q", optName)
+				return
+			}
+			if v, err := parseValue("string", lit); err != nil {
+				input.ErrorMessage = fmt.Sprintf("value %q is not assignable to option %q", lit, optName)
+				return
+			} else {
+				input.Opt_Project = v.(string)
+			}
+
+		case "-to-database":
+			if !cut {
+				input.ErrorMessage = fmt.Sprintf("value is not specified to option %q", optName)
+				return
+			}
+			if v, err := parseValue("string", lit); err != nil {
+				input.ErrorMessage = fmt.Sprintf("value %q is not assignable to option %q", lit, optName)
+				return
+			} else {
+				input.Opt_ToDatabase = v.(string)
+			}
+
+		case "-to-instance":
+			if !cut {
+				input.ErrorMessage = fmt.Sprintf("value is not specified to option %q", optName)
+				return
+			}
+			if v, err := parseValue("string", lit); err != nil {
+				input.ErrorMessage = fmt.Sprintf("value %q is not assignable to option %q", lit, optName)
+				return
+			} else {
+				input.Opt_ToInstance = v.(string)
+			}
+
+		case "-to-project":
+			if !cut {
+				input.ErrorMessage = fmt.Sprintf("value is not specified to option %q", optName)
+				return
+			}
+			if v, err := parseValue("string", lit); err != nil {
+				input.ErrorMessage = fmt.Sprintf("value %q is not assignable to option %q", lit, optName)
+				return
+			} else {
+				input.Opt_ToProject = v.(string)
+			}
+
+		case "-to-timestamp":
+			if !cut {
+				input.ErrorMessage = fmt.Sprintf("value is not specified to option %q", optName)
+				return
+			}
+			if v, err := parseValue("string", lit); err != nil {
+				input.ErrorMessage = fmt.Sprintf("value %q is not assignable to option %q", lit, optName)
+				return
+			} else {
+				input.Opt_ToTimestamp = v.(string)
+			}
+
+		case "-where":
+			if !cut {
+				input.ErrorMessage = fmt.Sprintf("value is not specified to option %q", optName)
+				return
+			}
+			if v, err := parseValue("[]string", lit); err != nil {
+				input.ErrorMessage = fmt.Sprintf("value %q is not assignable to option %q", lit, optName)
+				return
+			} else {
+				input.Opt_Where = append(input.Opt_Where, v.([]string)[0])
+			}
+
+		default:
+			input.ErrorMessage = fmt.Sprintf("unknown option %q", optName)
+			return
+		}
+	}
+
+	expectedArgs := 0
+	func(...any) {}(expectedArgs)
+}
+
+type Input_LoadEmulator struct {
+	Opt_Database string
+	Opt_Instance string
+	Opt_Project  string
+	Opt_Recreate bool
+	Arg_Input    string
+	Subcommand   []string
+	Options      []string
+	Arguments    []string
+
+	ErrorMessage string
+}
+
+func (input *Input_LoadEmulator) resolveInput(subcommand, options, arguments []string) {
+	*input = Input_LoadEmulator{Opt_Database: "",
+		Opt_Instance: "",
+		Opt_Project:  "",
+		Opt_Recreate: false,
+		Subcommand:   subcommand,
+		Options:      options,
+		Arguments:    arguments,
+	}
+
+	for _, arg := range input.Options {
+		optName, lit, cut := strings.Cut(arg, "=")
+		func(...any) {}(optName, lit, cut)
+
+		switch optName {
+		case "-database", "-d":
+			if !cut {
+				input.ErrorMessage = fmt.Sprintf("value is not specified to option %q", optName)
+				return
+			}
+			if v, err := parseValue("string", lit); err != nil {
+				input.ErrorMessage = fmt.Sprintf("value %q is not assignable to option %q", lit, optName)
+				return
+			} else {
+				input.Opt_Database = v.(string)
+			}
+
+		case "-instance", "-i":
+			if !cut {
+				input.ErrorMessage = fmt.Sprintf("value is not specified to option %q", optName)
+				return
+			}
+			if v, err := parseValue("string", lit); err != nil {
+				input.ErrorMessage = fmt.Sprintf("value %q is not assignable to option %q", lit, optName)
+				return
+			} else {
+				input.Opt_Instance = v.(string)
+			}
+
+		case "-project", "-p":
+			if !cut {
+				input.ErrorMessage = fmt.Sprintf("value is not specified to option %q", optName)
+				return
+			}
+			if v, err := parseValue("string", lit); err != nil {
+				input.ErrorMessage = fmt.Sprintf("value %q is not assignable to option %q", lit, optName)
+				return
+			} else {
+				input.Opt_Project = v.(string)
+			}
+
+		case "-recreate":
+			if !cut {
+				lit = "true"
+			}
+			if v, err := parseValue("bool", lit); err != nil {
+				input.ErrorMessage = fmt.Sprintf("value %q is not assignable to option %q", lit, optName)
+				return
+			} else {
+				input.Opt_Recreate = v.(bool)
+			}
+
+		default:
+			input.ErrorMessage = fmt.Sprintf("unknown option %q", optName)
+			return
+		}
+	}
+
+	expectedArgs := 1
+	func(...any) {}(expectedArgs)
+	if len(input.Arguments) != expectedArgs {
+		input.ErrorMessage = fmt.Sprintf("wrong number of arguments: required %d, got %d", expectedArgs, len(input.Arguments))
+		return
+	}
+
+	if v, err := parseValue("string", input.Arguments[0]); err != nil {
+		input.ErrorMessage = fmt.Sprintf("value %q is not assignable to argument %q", input.Arguments[0], "<input>")
+		return
+	} else {
+		input.Arg_Input = v.(string)
+	}
+}
+
+type Input_LoadFixtures struct {
+	Opt_Database string
+	Opt_Instance string
+	Opt_Project  string
+	Opt_Upsert   bool
+	Arg_Input    string
+	Subcommand   []string
+	Options      []string
+	Arguments    []string
+
+	ErrorMessage string
+}
+
+func (input *Input_LoadFixtures) resolveInput(subcommand, options, arguments []string) {
+	*input = Input_LoadFixtures{Opt_Database: "",
+		Opt_Instance: "",
+		Opt_Project:  "",
+		Opt_Upsert:   false,
+		Subcommand:   subcommand,
+		Options:      options,
+		Arguments:    arguments,
+	}
+
+	for _, arg := range input.Options {
+		optName, lit, cut := strings.Cut(arg, "=")
+		func(...any) {}(optName, lit, cut)
+
+		switch optName {
+		case "-database", "-d":
+			if !cut {
+				input.ErrorMessage = fmt.Sprintf("value is not specified to option %q", optName)
+				return
+			}
+			if v, err := parseValue("string", lit); err != nil {
+				input.ErrorMessage = fmt.Sprintf("value %q is not assignable to option %q", lit, optName)
+				return
+			} else {
+				input.Opt_Database = v.(string)
+			}
+
+		case "-instance", "-i":
+			if !cut {
+				input.ErrorMessage = fmt.Sprintf("value is not specified to option %q", optName)
+				return
+			}
+			if v, err := parseValue("string", lit); err != nil {
+				input.ErrorMessage = fmt.Sprintf("value %q is not assignable to option %q", lit, optName)
+				return
+			} else {
+				input.Opt_Instance = v.(string)
+			}
+
+		case "-project", "-p":
+			if !cut {
+				input.ErrorMessage = fmt.Sprintf("value is not specified to option %q", optName)
+				return
+			}
+			if v, err := parseValue("string", lit); err != nil {
+				input.ErrorMessage = fmt.Sprintf("value %q is not assignable to option %q", lit, optName)
+				return
+			} else {
+				input.Opt_Project = v.(string)
+			}
+
+		case "-upsert":
+			if !cut {
+				lit = "true"
+			}
+			if v, err := parseValue("bool", lit); err != nil {
+				input.ErrorMessage = fmt.Sprintf("value %q is not assignable to option %q", lit, optName)
+				return
+			} else {
+				input.Opt_Upsert = v.(bool)
+			}
+
+		default:
+			input.ErrorMessage = fmt.Sprintf("unknown option %q", optName)
+			return
+		}
+	}
+
+	expectedArgs := 1
 	func(...any) {}(expectedArgs)
+	if len(input.Arguments) != expectedArgs {
+		input.ErrorMessage = fmt.Sprintf("wrong number of arguments: required %d, got %d", expectedArgs, len(input.Arguments))
+		return
+	}
+
+	if v, err := parseValue("string", input.Arguments[0]); err != nil {
+		input.ErrorMessage = fmt.Sprintf("value %q is not assignable to argument %q", input.Arguments[0], "<input>")
+		return
+	} else {
+		input.Arg_Input = v.(string)
+	}
 }
 func resolveArgs(args []string) (subcommandPath []string, options []string, arguments []string) {
 	if len(args) == 0 {
 		panic("command line arguments are too few")
 	}
 	subcommandSet := map[string]bool{
-		"": true,
+		"":              true,
+		"convert":       true,
+		"delta":         true,
+		"diff":          true,
+		"load-emulator": true,
+		"load-fixtures": true,
 	}
 
 	for _, arg := range args[1:] {
@@ -298,7 +1411,17 @@ func GetProgram() string {
 func GetDoc(subcommands []string) string {
 	switch strings.Join(subcommands, " ") {
 	case "":
-		return "spanner-dump-where \n\n    Description:\n        Dump data from a Google Cloud Spanner database with specified conditions.\n        This command allows you to export data from a Spanner database, applying filters and options to control the output.\n\n    Syntax:\n        $ spanner-dump-where  [<option>]...\n\n    Options:\n        -bulk-size=<integer>  (default=100):\n            Number of rows to dump in a single batch.\n            This option is used to control the size of the data dump.\n\n        -database=<string>, -d=<string>  (default=\"\"):\n            Google Cloud Spanner database ID.\n            This option is required.\n\n        -from=<string>  (default=\"\"):\n            Table name to dump data from.\n            This option can be specified one or more times.\n\n        -instance=<string>, -i=<string>  (default=\"\"):\n            Google Cloud Spanner instance ID.\n            This option is required.\n\n        -no-data[=<boolean>]  (default=false):\n            If true, do not dump data.\n\n        -no-ddl[=<boolean>]  (default=false):\n            If true, do not dump DDL statements.\n\n        -project=<string>, -p=<string>  (default=\"\"):\n            Google Cloud project ID.\n            This option is required.\n\n        -sort[=<boolean>]  (default=false):\n            If true, sort the dump order according to dependency relationships on tables.\n            This option is used to control the order of the dumped data.\n\n        -timestamp=<string>, -t=<string>  (default=\"\"):\n            Timestamp to use for the dump.\n\n        -upsert[=<boolean>]  (default=false):\n            If true, use INSERT OR UPDATE instead of INSERT.\n\n        -where=<string>  (default=\"\"):\n            Condition to filter data.\n            This option is required for each -from option.\n            The format is an SQL boolean expression after WHERE clause.\n\n\n"
+		return "spanner-dump-where \n\n    Description:\n        Dump data from a Google Cloud Spanner database with specified conditions.\n        This command allows you to export data from a Spanner database, applying filters and options to control the output.\n\n    Syntax:\n        $ spanner-dump-where  [<option>]...\n\n    Options:\n        -bulk-size=<integer>  (default=100):\n            Maximum number of rows to dump in a single batch if greater than 0.\n            Batches are also split by -max-mutations and -max-statement-bytes.\n\n        -check-schema[=<boolean>]  (default=false):\n            If true, compare fingerprints of the schema of the dumped tables, i.e. the DDL statements and the column lists, before and after the dump,\n            and fail with the changed schema objects if they differ.\n\n        -checkpoint=<string>  (default=\"\"):\n            File to record the progress of the dump, i.e. the read timestamp, completed tables, and the primary key of the last written record.\n            If the file exists, the dump resumes from the recorded progress at the recorded read timestamp without dumping DDLs,\n            so append the output to the interrupted one (e.g. with >>).\n            The last written record is recorded only if -parallelism is 1 and the table is not read by partitioned queries.\n\n        -data-boost[=<boolean>]  (default=false):\n            If true, execute partitioned queries with Data Boost.\n            This option requires -partitioned.\n\n        -database=<string>, -d=<string>  (default=\"\"):\n            Google Cloud Spanner database ID.\n            This option is required.\n\n        -exact-staleness=<string>  (default=\"\"):\n            Read data at the timestamp exactly this duration before now, e.g. 15s.\n\n        -format=<string>, -f=<string>  (default=\"sql\"):\n            Output format of records, which is one of sql, csv, jsonl, go, and yaml.\n            Formats other than sql write neither the header nor DDL statements.\n            csv and yaml write <table>.csv and <table>.yml for each table in the directory specified by -output.\n            yaml lists records as maps of columns to values, where INT64, NUMERIC, DATE, and TIMESTAMP are quoted strings and BYTES are !!binary,\n            which can be loaded by the load-fixtures subcommand.\n            go writes a Go source file with a function returning []*spanner.Mutation to insert the records,\n            whose values are typed Go literals such as int64(1), civil.Date{...}, and spanner.NullJSON{...},\n            grouped per table in the dump order, e.g. the dependency order with -sort.\n            go format cannot be used with -checkpoint.\n\n        -from=<string>  (default=\"\"):\n            Table name to dump data from.\n            This option can be specified one or more times.\n\n        -go-func=<string>  (default=\"Mutations\"):\n            Name of the function returning mutations in the Go source file written in go format.\n\n        -go-package=<string>  (default=\"fixtures\"):\n            Package name of the Go source file written in go format.\n\n        -instance=<string>, -i=<string>  (default=\"\"):\n            Google Cloud Spanner instance ID.\n            This option is required.\n\n        -mask=<string>  (default=\"\"):\n            Rule to mask values of columns in the format TABLE.COLUMN:STRATEGY[=ARG], e.g. User.Email:hash or *.BirthDate:shift=-30d.\n            TABLE and COLUMN are glob patterns, and the first rule matched by each column is applied.\n            STRATEGY is one of null, constant=VALUE, hash, pseudonym, email, phone, name, shift=DURATION, and truncate=N (or truncate=DURATION for TIMESTAMP).\n            hash, pseudonym, email, phone, and name derive values from keyed HMAC-SHA256 digests of original values with the key specified by -mask-key.\n            pseudonym also applies the same mapping as the column to all columns connected to it by foreign keys and interleaved primary keys,\n            so that the masked dump remains joinable and importable.\n            Primary key and unique index columns can be masked only by hash, pseudonym, and shift, which keep distinct values distinct,\n            and since digests are truncated to the length of the column, hash and pseudonym of them\n            require STRING columns of at least 16 characters and BYTES columns of at least 8 bytes to avoid collisions.\n            Rules are checked against types and NOT NULL constraints of columns before the dump, and NULL values are kept as they are.\n            This option can be specified one or more times.\n\n        -mask-file=<string>  (default=\"\"):\n            File containing rules in the same format as -mask, one per line.\n            Empty lines and lines beginning with # are ignored, and rules in the file are applied after rules specified by -mask.\n\n        -mask-key=<string>  (default=\"\"):\n            Key of HMAC to mask values by hash, pseudonym, email, phone, and name.\n            If not specified, the environment variable SPANNER_DUMP_MASK_KEY is used.\n\n        -max-mutations=<integer>  (default=0):\n            Maximum number of estimated mutations in a single batch, counting inserted columns and columns of affected secondary indexes.\n            If 0, 20000 is used.\n\n        -max-staleness=<string>  (default=\"\"):\n            Read data at a timestamp chosen by Spanner, which is no more stale than this duration, e.g. 15s.\n\n        -max-statement-bytes=<integer>  (default=0):\n            Maximum number of bytes of a single INSERT statement.\n            If 0, 1000000 is used.\n\n        -min-read-timestamp=<string>  (default=\"\"):\n            Read data at a timestamp chosen by Spanner, which is no earlier than this timestamp.\n            The format is the same as -timestamp.\n\n        -no-data[=<boolean>]  (default=false):\n            If true, do not dump data.\n\n        -no-ddl[=<boolean>]  (default=false):\n            If true, do not dump DDL statements.\n\n        -no-header[=<boolean>]  (default=false):\n            If true, do not dump the header comment with the tool version, the database, the read timestamp, the tables, and the filters.\n\n        -output=<string>, -o=<string>  (default=\"\"):\n            Directory to write output files.\n            This option is required for csv and yaml formats.\n\n        -page-size=<integer>  (default=0):\n            Number of records to read by each query if greater than 0.\n            Records of each table are read in pages in the primary key order, continuing from the primary key of the last record of the previous page,\n            and each page is retried independently on transient errors, which avoids long-running queries.\n            Tables without dumped primary key columns and tables read by partitioned queries are not paginated.\n\n        -parallelism=<integer>  (default=1):\n            Number of tables to dump concurrently at the same read timestamp.\n            Records of each table are buffered in memory and written in the dump order.\n\n        -partitioned[=<boolean>]  (default=false):\n            If true, use partitioned queries for tables whose queries are root-partitionable.\n            Partitions are read concurrently up to -parallelism and written in the order of partitions.\n            Tables whose queries are not root-partitionable are dumped by normal queries.\n\n        -priority=<string>  (default=\"\"):\n            Request priority of queries, which is one of LOW, MEDIUM, and HIGH.\n            If not specified, the default priority of Spanner is used.\n\n        -progress-interval=<string>  (default=\"10s\"):\n            Interval to write progress of tables being dumped to stderr, e.g. 10s or 1m.\n            A summary of dumped tables is also written to stderr at the end of the dump.\n            If 0, neither progress nor the summary is written.\n\n        -project=<string>, -p=<string>  (default=\"\"):\n            Google Cloud project ID.\n            This option is required.\n\n        -report=<string>  (default=\"\"):\n            File to write a JSON report of the dump, i.e. the read timestamp and rows, bytes, and query durations of dumped tables.\n\n        -request-tag=<string>  (default=\"\"):\n            Request tag attached to all queries to identify the dump job in Spanner statistics.\n            Note that Spanner does not support transaction tags for read-only transactions.\n\n        -schema-change-retries=<integer>  (default=0):\n            Number of times to retry the whole dump at a fresh snapshot if -check-schema detects a schema change.\n            Retrying discards the output written so far, so stdout must be redirected to a regular file.\n            The dump is not retried if -timestamp is an absolute timestamp, at which a fresh snapshot cannot be read,\n            while relative -timestamp and -min-read-timestamp are resolved again from the time of the retry.\n            This option cannot be used with -checkpoint.\n\n        -snapshot-ddl[=<boolean>]  (default=false):\n            If true, reconstruct DDL statements from INFORMATION_SCHEMA at the same read timestamp as the data,\n            which ensures consistency between the schema and the data even with -timestamp in the past.\n            Tables, columns, defaults, generated columns, primary keys, interleaving, row deletion policies, check constraints,\n            secondary indexes, and foreign keys are reconstructed, while other schema objects such as views, change streams, and sequences are not.\n\n        -sort[=<boolean>]  (default=false):\n            If true, sort the dump order according to dependency relationships on tables.\n            This option is used to control the order of the dumped data.\n\n        -timestamp=<string>, -t=<string>  (default=\"\"):\n            Timestamp to use for the dump in RFC3339 format, or a negative duration relative to now such as -1h.\n\n        -transform-cmd=<string>  (default=\"\"):\n            Command run by sh -c to transform records before they are masked and written.\n            Each record is written to the stdin of the command as a JSON line such as\n            {\"table\":\"User\",\"values\":{\"Id\":\"1\",\"Email\":\"alice@example.com\"},\"types\":{\"Id\":\"INT64\",\"Email\":\"STRING\"}},\n            and the command must write and flush a JSON line such as {\"rows\":[{\"Email\":\"user@example.com\"}]} to its stdout for each record,\n            where rows are records to write instead, which are empty to drop the record or multiple to fan it out,\n            and omitted columns keep their values. The command can also respond {\"error\":\"message\"} to abort the dump.\n            See the README for details of the protocol.\n\n        -upsert[=<boolean>]  (default=false):\n            If true, use INSERT OR UPDATE instead of INSERT.\n\n        -where=<string>  (default=\"\"):\n            Condition to filter data.\n            This option is required for each -from option.\n            The format is an SQL boolean expression after WHERE clause.\n\n\n    Subcommands:\n        convert:\n            Convert a dump file produced by spanner-dump-where into another format without accessing the database.\n\n        delta:\n            Write DML statements to move records of tables selected by -from and -where from the old state to the new state to stdout,\n\n        diff:\n            Compare records of tables selected by -from and -where between two databases, or in one database at two timestamps,\n\n        load-emulator:\n            Load a dump file produced by spanner-dump-where into the Cloud Spanner emulator specified by the environment variable SPANNER_EMULATOR_HOST.\n\n        load-fixtures:\n            Insert records in YAML files written by -format=yaml into a Google Cloud Spanner database.\n\n\n"
+	case "convert":
+		return "spanner-dump-where convert \n\n    Description:\n        Convert a dump file produced by spanner-dump-where into another format without accessing the database.\n        Column types are resolved from CREATE TABLE statements in the dump or in the file specified by -ddl.\n\n    Syntax:\n        $ spanner-dump-where convert [<option>]... [--] <input:string>\n\n    Options:\n        -bulk-size=<integer>  (default=100):\n            Maximum number of rows in a single INSERT statement for sql format if greater than 0.\n\n        -ddl=<string>  (default=\"\"):\n            File containing DDL statements of the dumped tables.\n            This option is required if the dump does not contain CREATE TABLE statements.\n\n        -format=<string>, -f=<string>  (default=\"sql\"):\n            Output format, which is one of sql, csv, jsonl, go, and yaml.\n            csv and yaml write <table>.csv and <table>.yml for each table in the directory specified by -output.\n            go writes a Go source file with a function returning []*spanner.Mutation to insert the records,\n            which can be applied as a batch of mutations by spanner.Client.Apply.\n\n        -go-func=<string>  (default=\"Mutations\"):\n            Name of the function returning mutations in the Go source file written in go format.\n\n        -go-package=<string>  (default=\"fixtures\"):\n            Package name of the Go source file written in go format.\n\n        -max-mutations=<integer>  (default=0):\n            Maximum number of estimated mutations in a single INSERT statement for sql format.\n            If 0, 20000 is used.\n\n        -max-statement-bytes=<integer>  (default=0):\n            Maximum number of bytes of a single INSERT statement for sql format.\n            If 0, 1000000 is used.\n\n        -output=<string>, -o=<string>  (default=\"\"):\n            Directory to write output files.\n            This option is required for csv and yaml formats.\n\n        -upsert[=<boolean>]  (default=false):\n            If true, use INSERT OR UPDATE instead of INSERT for sql format, and spanner.InsertOrUpdate instead of spanner.Insert for go format.\n\n\n    Arguments:\n        1. <input:string>\n            Dump file or directory containing dump files (*.sql).\n            Files in a directory are converted in lexical order.\n\n\n"
+	case "delta":
+		return "spanner-dump-where delta \n\n    Description:\n        Write DML statements to move records of tables selected by -from and -where from the old state to the new state to stdout,\n        i.e. INSERT for inserted records, UPDATE ... WHERE <primary key> for changed columns, and DELETE ... WHERE <primary key> for deleted records.\n        The old state is the database at -from-timestamp or a previous dump file specified by -dump, and the new state is the database at -to-timestamp.\n        INSERT and UPDATE statements are written in the dependency order of tables, i.e. parents of interleaving and tables referenced by foreign keys first,\n        followed by DELETE statements in the reverse order, i.e. children first.\n        The numbers of inserted, deleted, changed, and unchanged records of each table are written to stderr.\n        The command fails before writing any statements if columns of the new state do not exist in the old state or have different types,\n        since INSERT statements must have all columns of the new state.\n\n    Syntax:\n        $ spanner-dump-where delta [<option>]...\n\n    Options:\n        -database=<string>, -d=<string>  (default=\"\"):\n            Google Cloud Spanner database ID.\n            This option is required.\n\n        -ddl=<string>  (default=\"\"):\n            File containing DDL statements of the tables in the file specified by -dump.\n\n        -dump=<string>  (default=\"\"):\n            Dump file or directory containing dump files (*.sql) produced by spanner-dump-where as the old state.\n            Files in a directory are read in lexical order, and records of the compared tables are held in memory.\n            The dump must contain CREATE TABLE statements of the compared tables unless -ddl is specified.\n\n        -from=<string>  (default=\"\"):\n            Table name to compare.\n            This option can be specified one or more times.\n\n        -from-timestamp=<string>  (default=\"\"):\n            Timestamp of the old state in RFC3339 format, or a negative duration relative to now such as -1h.\n            Either this option or -dump is required.\n\n        -instance=<string>, -i=<string>  (default=\"\"):\n            Google Cloud Spanner instance ID.\n            This option is required.\n\n        -priority=<string>  (default=\"\"):\n            Request priority of queries, which is one of LOW, MEDIUM, and HIGH.\n            If not specified, the default priority of Spanner is used.\n\n        -project=<string>, -p=<string>  (default=\"\"):\n            Google Cloud project ID.\n            This option is required.\n\n        -to-timestamp=<string>  (default=\"\"):\n            Timestamp of the new state in the same format as -from-timestamp.\n            If not specified, the new state is read by a strong read.\n\n        -where=<string>  (default=\"\"):\n            Condition to filter records.\n            This option is required for each -from option.\n            The format is an SQL boolean expression after WHERE clause.\n            With -dump, the conditions must be the same as the filters recorded in the header of the dump, or TRUE if the dump has no header,\n            since records in the dump cannot be filtered and records out of the conditions would be deleted.\n\n\n"
+	case "diff":
+		return "spanner-dump-where diff \n\n    Description:\n        Compare records of tables selected by -from and -where between two databases, or in one database at two timestamps,\n        and write inserted, deleted, and changed records aligned by the primary key to stdout.\n        Records of both sides are read by queries ordered by the primary key and compared while they are streamed, so that large tables can be compared.\n        Only columns existing in both sides with the same type are compared, and tables must have the same primary key in both sides.\n\n    Syntax:\n        $ spanner-dump-where diff [<option>]...\n\n    Options:\n        -database=<string>, -d=<string>  (default=\"\"):\n            Google Cloud Spanner database ID of the old side.\n            This option is required.\n\n        -format=<string>, -f=<string>  (default=\"text\"):\n            Output format of differences, which is one of text and json.\n            text writes a line of each difference such as ~ Singers (SingerId=3): Name: \"Carol\" -> \"Caroline\" with values in SQL literals.\n            json writes a JSON object of each difference line by line such as\n            {\"type\":\"changed\",\"table\":\"Singers\",\"key\":{\"SingerId\":\"3\"},\"from\":{\"Name\":\"Carol\"},\"to\":{\"Name\":\"Caroline\"}}.\n            Both formats end with the numbers of inserted, deleted, changed, and unchanged records of each table.\n\n        -from=<string>  (default=\"\"):\n            Table name to compare.\n            This option can be specified one or more times.\n\n        -from-timestamp=<string>  (default=\"\"):\n            Timestamp to read the old side in RFC3339 format, or a negative duration relative to now such as -1h.\n            If not specified, the old side is read by a strong read.\n\n        -instance=<string>, -i=<string>  (default=\"\"):\n            Google Cloud Spanner instance ID of the old side.\n            This option is required.\n\n        -priority=<string>  (default=\"\"):\n            Request priority of queries, which is one of LOW, MEDIUM, and HIGH.\n            If not specified, the default priority of Spanner is used.\n\n        -project=<string>, -p=<string>  (default=\"\"):\n            Google Cloud project ID of the old side.\n            This option is required.\n\n        -to-database=<string>  (default=\"\"):\n            Google Cloud Spanner database ID of the new side.\n            If not specified, -database is used.\n\n        -to-instance=<string>  (default=\"\"):\n            Google Cloud Spanner instance ID of the new side.\n            If not specified, -instance is used.\n\n        -to-project=<string>  (default=\"\"):\n            Google Cloud project ID of the new side.\n            If not specified, -project is used.\n\n        -to-timestamp=<string>  (default=\"\"):\n            Timestamp to read the new side in the same format as -from-timestamp.\n            If not specified, the new side is read by a strong read.\n\n        -where=<string>  (default=\"\"):\n            Condition to filter records of both sides.\n            This option is required for each -from option.\n            The format is an SQL boolean expression after WHERE clause.\n\n\n"
+	case "load-emulator":
+		return "spanner-dump-where load-emulator \n\n    Description:\n        Load a dump file produced by spanner-dump-where into the Cloud Spanner emulator specified by the environment variable SPANNER_EMULATOR_HOST.\n        The instance and the database are created if they do not exist.\n        DDL statements in the dump are applied through the database admin API, and then records are inserted in the order of the dump\n        by commits split to keep the estimated mutations within the limit.\n        The time taken to apply DDL statements and to load each table is printed.\n\n    Syntax:\n        $ spanner-dump-where load-emulator [<option>]... [--] <input:string>\n\n    Options:\n        -database=<string>, -d=<string>  (default=\"\"):\n            Google Cloud Spanner database ID.\n            This option is required.\n\n        -instance=<string>, -i=<string>  (default=\"\"):\n            Google Cloud Spanner instance ID.\n            This option is required.\n\n        -project=<string>, -p=<string>  (default=\"\"):\n            Google Cloud project ID.\n            This option is required.\n\n        -recreate[=<boolean>]  (default=false):\n            If true, drop the database if it exists before creating it.\n\n\n    Arguments:\n        1. <input:string>\n            Dump file or directory containing dump files (*.sql).\n            Files in a directory are loaded in lexical order.\n\n\n"
+	case "load-fixtures":
+		return "spanner-dump-where load-fixtures \n\n    Description:\n        Insert records in YAML files written by -format=yaml into a Google Cloud Spanner database.\n        The table of each file is the file name without the extension, e.g. User.yml for table User.\n        Tables are loaded in the dependency order, i.e. parents of interleaving and tables referenced by foreign keys first.\n        The emulator is used if the environment variable SPANNER_EMULATOR_HOST is set.\n\n    Syntax:\n        $ spanner-dump-where load-fixtures [<option>]... [--] <input:string>\n\n    Options:\n        -database=<string>, -d=<string>  (default=\"\"):\n            Google Cloud Spanner database ID.\n            This option is required.\n\n        -instance=<string>, -i=<string>  (default=\"\"):\n            Google Cloud Spanner instance ID.\n            This option is required.\n\n        -project=<string>, -p=<string>  (default=\"\"):\n            Google Cloud project ID.\n            This option is required.\n\n        -upsert[=<boolean>]  (default=false):\n            If true, insert or update records instead of inserting them.\n\n\n    Arguments:\n        1. <input:string>\n            YAML file or directory containing YAML files (*.yml and *.yaml).\n\n\n"
 	default:
 		panic(fmt.Sprintf(`invalid subcommands: %v`, subcommands))
 	}
@@ -1,11 +1,24 @@
 package main
 
 import (
+	"cloud.google.com/go/spanner"
+	adminapi "cloud.google.com/go/spanner/admin/database/apiv1"
+	adminpb "cloud.google.com/go/spanner/admin/database/apiv1/databasepb"
+	instanceapi "cloud.google.com/go/spanner/admin/instance/apiv1"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/Jumpaku/spanner-dump-whare/spanner-dump"
+	sppb "google.golang.org/genproto/googleapis/spanner/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"io"
 	"log"
 	"os"
+	"path/filepath"
+	"sort"
+	"strings"
 	"time"
 )
 
@@ -35,45 +48,628 @@ func (cli) Run(input Input) error {
 		fmt.Println(GetDoc(input.Subcommand))
 		panicf("Error: Invalid parameters: count of -from and -where must be same\n")
 	}
+	if input.Opt_Parallelism < 1 {
+		fmt.Println(GetDoc(input.Subcommand))
+		panicf("Error: Invalid parameters: -parallelism must be positive\n")
+	}
+	if input.Opt_PageSize < 0 {
+		fmt.Println(GetDoc(input.Subcommand))
+		panicf("Error: Invalid parameters: -page-size must not be negative\n")
+	}
+	if input.Opt_BulkSize < 0 || input.Opt_MaxMutations < 0 || input.Opt_MaxStatementBytes < 0 {
+		fmt.Println(GetDoc(input.Subcommand))
+		panicf("Error: Invalid parameters: -bulk-size, -max-mutations, and -max-statement-bytes must not be negative\n")
+	}
+	progressInterval, err := time.ParseDuration(input.Opt_ProgressInterval)
+	if err != nil || progressInterval < 0 {
+		fmt.Println(GetDoc(input.Subcommand))
+		panicf("Error: Invalid parameters: -progress-interval must be a non-negative duration such as 10s\n")
+	}
 
-	var timestamp *time.Time
-	if input.Opt_Timestamp != "" {
-		t, err := time.Parse(time.RFC3339, input.Opt_Timestamp)
-		panicfIfError(err, "Error: Invalid timestamp format")
-		timestamp = &t
+	if input.Opt_SchemaChangeRetries < 0 {
+		fmt.Println(GetDoc(input.Subcommand))
+		panicf("Error: Invalid parameters: -schema-change-retries must not be negative\n")
+	}
+	if input.Opt_SchemaChangeRetries > 0 {
+		if !input.Opt_CheckSchema {
+			fmt.Println(GetDoc(input.Subcommand))
+			panicf("Error: Invalid parameters: -schema-change-retries requires -check-schema\n")
+		}
+		if input.Opt_Checkpoint != "" {
+			fmt.Println(GetDoc(input.Subcommand))
+			panicf("Error: Invalid parameters: -schema-change-retries cannot be used with -checkpoint\n")
+		}
+		if info, err := os.Stdout.Stat(); err != nil || !info.Mode().IsRegular() {
+			fmt.Println(GetDoc(input.Subcommand))
+			panicf("Error: Invalid parameters: -schema-change-retries requires stdout to be redirected to a regular file\n")
+		}
+	}
+
+	format, err := spanner_dump.ParseFormat(input.Opt_Format)
+	if err != nil {
+		fmt.Println(GetDoc(input.Subcommand))
+		panicf("Error: Invalid parameters: %v\n", err)
+	}
+	if (format == spanner_dump.FormatCSV || format == spanner_dump.FormatYAML) && input.Opt_Output == "" {
+		fmt.Println(GetDoc(input.Subcommand))
+		panicf("Error: Missing parameters: -output is required for %s format\n", format)
+	}
+	if format == spanner_dump.FormatGo && input.Opt_Checkpoint != "" {
+		fmt.Println(GetDoc(input.Subcommand))
+		panicf("Error: Invalid parameters: -checkpoint cannot be used with go format\n")
+	}
+	encoderConfig := spanner_dump.EncoderConfig{
+		OutDir:            input.Opt_Output,
+		BulkSize:          uint(input.Opt_BulkSize),
+		MaxMutations:      uint(input.Opt_MaxMutations),
+		MaxStatementBytes: uint(input.Opt_MaxStatementBytes),
+		Upsert:            input.Opt_Upsert,
+		GoPackage:         input.Opt_GoPackage,
+		GoFunc:            input.Opt_GoFunc,
+	}
+	if _, err := spanner_dump.NewEncoder(format, encoderConfig); err != nil {
+		fmt.Println(GetDoc(input.Subcommand))
+		panicf("Error: Invalid parameters: %v\n", err)
+	}
+
+	readBound := readBoundFromInput(input)
+	mask := maskPolicyFromInput(input)
+
+	queryOptions := spanner.QueryOptions{
+		RequestTag:       input.Opt_RequestTag,
+		DataBoostEnabled: input.Opt_DataBoost,
+		Priority:         priorityFromInput(input.Subcommand, input.Opt_Priority),
+	}
+	if input.Opt_DataBoost && !input.Opt_Partitioned {
+		fmt.Println(GetDoc(input.Subcommand))
+		panicf("Error: Invalid parameters: -data-boost requires -partitioned\n")
 	}
 
-	query := make(map[string]string)
+	var checkpoint *spanner_dump.Checkpoint
+	if input.Opt_Checkpoint != "" {
+		var err error
+		checkpoint, err = spanner_dump.LoadCheckpoint(input.Opt_Checkpoint)
+		panicfIfError(err, "Failed to load checkpoint")
+		if checkpoint.Resumed() {
+			log.Printf("Resuming the dump at %s from checkpoint %s", checkpoint.ReadTimestamp.Format(time.RFC3339Nano), input.Opt_Checkpoint)
+		}
+	}
+
+	opts := []spanner_dump.Option{
+		spanner_dump.WithOutput(os.Stdout),
+		spanner_dump.WithBulkSize(uint(input.Opt_BulkSize)),
+		spanner_dump.WithSort(input.Opt_Sort),
+		spanner_dump.WithUpsert(input.Opt_Upsert),
+		spanner_dump.WithParallelism(uint(input.Opt_Parallelism)),
+		spanner_dump.WithPartitioned(input.Opt_Partitioned),
+		spanner_dump.WithQueryOptions(queryOptions),
+		spanner_dump.WithCheckpoint(checkpoint),
+		spanner_dump.WithPageSize(uint(input.Opt_PageSize)),
+		spanner_dump.WithMaxMutations(uint(input.Opt_MaxMutations)),
+		spanner_dump.WithMaxStatementBytes(uint(input.Opt_MaxStatementBytes)),
+		spanner_dump.WithProgress(os.Stderr, progressInterval),
+		spanner_dump.WithSchemaCheck(input.Opt_CheckSchema),
+		spanner_dump.WithMask(mask),
+	}
 	for index, from := range input.Opt_From {
-		query[from] = input.Opt_Where[index]
+		opts = append(opts, spanner_dump.WithTable(from, input.Opt_Where[index]))
 	}
 
+	// A fresh snapshot cannot be read at an absolute -timestamp, while relative bounds are resolved again for each attempt.
+	fixedTimestamp := input.Opt_Timestamp != "" && !strings.HasPrefix(input.Opt_Timestamp, "-")
 	ctx := context.Background()
-	dumper, err := spanner_dump.NewDumper(ctx,
-		input.Opt_Project, input.Opt_Instance, input.Opt_Database,
-		os.Stdout,
-		timestamp,
-		uint(input.Opt_BulkSize),
-		query,
-		input.Opt_Sort,
-		input.Opt_Upsert,
-	)
-	panicfIfError(err, "Failed to create dumper")
+	for attempt := int64(0); ; attempt++ {
+		err := dump(ctx, input, format, encoderConfig, checkpoint, progressInterval, append(opts, spanner_dump.WithReadBound(readBound)))
+		var changed *spanner_dump.SchemaChangedError
+		if errors.As(err, &changed) && attempt < input.Opt_SchemaChangeRetries && !fixedTimestamp {
+			log.Printf("Retrying the dump at a fresh snapshot: %v", err)
+			err := discardStdout()
+			panicfIfError(err, "Failed to discard the output to retry")
+			readBound = readBoundFromInput(input)
+			continue
+		}
+		panicfIfError(err, "Failed to dump")
+		break
+	}
+
+	return nil
+}
+
+// dump dumps the header, DDLs, and data specified by input to stdout.
+// The header and DDLs are dumped only in sql format.
+func dump(ctx context.Context, input Input, format spanner_dump.Format, encoderConfig spanner_dump.EncoderConfig, checkpoint *spanner_dump.Checkpoint, progressInterval time.Duration, opts []spanner_dump.Option) error {
+	// The encoder is created for each attempt not to continue files of the discarded attempt.
+	encoder, err := spanner_dump.NewEncoder(format, encoderConfig)
+	if err != nil {
+		return fmt.Errorf("failed to create encoder: %w", err)
+	}
+	dbPath := fmt.Sprintf("projects/%s/instances/%s/databases/%s", input.Opt_Project, input.Opt_Instance, input.Opt_Database)
+	dumper, err := spanner_dump.NewDumper(ctx, dbPath, append(opts, spanner_dump.WithEncoder(encoder))...)
+	if err != nil {
+		return fmt.Errorf("failed to create dumper: %w", err)
+	}
 	defer dumper.Cleanup()
 
-	if !input.Opt_NoDdl {
-		err := dumper.DumpDDLs(ctx)
-		panicfIfError(err, "Failed to dump DDLs")
+	// The header and DDLs have been dumped before the data if the dump is resumed.
+	resumed := checkpoint != nil && checkpoint.Resumed()
+	sql := format == spanner_dump.FormatSQL
+	if !input.Opt_NoHeader && !resumed && sql {
+		if err := dumper.DumpHeader(ctx); err != nil {
+			return fmt.Errorf("failed to dump header: %w", err)
+		}
+	}
+
+	if !input.Opt_NoDdl && !resumed && sql {
+		dumpDDLs := dumper.DumpDDLs
+		if input.Opt_SnapshotDdl {
+			dumpDDLs = dumper.DumpSnapshotDDLs
+		}
+		if err := dumpDDLs(ctx); err != nil {
+			return fmt.Errorf("failed to dump DDLs: %w", err)
+		}
 	}
 
 	if !input.Opt_NoData {
-		err := dumper.DumpTables(ctx)
-		panicfIfError(err, "Failed to dump tables")
+		var transformer *spanner_dump.CommandTransformer
+		if input.Opt_TransformCmd != "" {
+			transformer, err = spanner_dump.StartCommandTransformer(ctx, input.Opt_TransformCmd, os.Stderr)
+			if err != nil {
+				return err
+			}
+			defer transformer.Close()
+			dumper.AddRowTransformer(transformer)
+		}
+		if err := dumper.DumpTables(ctx); err != nil {
+			return fmt.Errorf("failed to dump tables: %w", err)
+		}
+		if transformer != nil {
+			if err := transformer.Close(); err != nil {
+				return err
+			}
+		}
+
+		report := dumper.Report()
+		if progressInterval > 0 {
+			if err := report.WriteSummary(os.Stderr); err != nil {
+				return fmt.Errorf("failed to write summary: %w", err)
+			}
+		}
+		if input.Opt_Report != "" {
+			b, err := json.MarshalIndent(report, "", "  ")
+			if err != nil {
+				return fmt.Errorf("failed to encode report: %w", err)
+			}
+			if err := os.WriteFile(input.Opt_Report, append(b, '\n'), 0o644); err != nil {
+				return fmt.Errorf("failed to write report: %w", err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// discardStdout truncates stdout redirected to a regular file to write the output again from the beginning.
+func discardStdout() error {
+	if err := os.Stdout.Truncate(0); err != nil {
+		return err
+	}
+	_, err := os.Stdout.Seek(0, io.SeekStart)
+	return err
+}
+
+// readBoundFromInput returns the read bound specified by at most one of -timestamp, -exact-staleness, -max-staleness, and -min-read-timestamp.
+func readBoundFromInput(input Input) spanner_dump.ReadBound {
+	now := time.Now()
+	var bounds []spanner_dump.ReadBound
+	if input.Opt_Timestamp != "" {
+		t, err := spanner_dump.ParseTimestamp(input.Opt_Timestamp, now)
+		panicfIfError(err, "Error: Invalid -timestamp")
+		bounds = append(bounds, spanner_dump.ReadTimestamp(t))
+	}
+	if input.Opt_ExactStaleness != "" {
+		d, err := time.ParseDuration(input.Opt_ExactStaleness)
+		panicfIfError(err, "Error: Invalid -exact-staleness")
+		bounds = append(bounds, spanner_dump.ExactStaleness(d))
+	}
+	if input.Opt_MaxStaleness != "" {
+		d, err := time.ParseDuration(input.Opt_MaxStaleness)
+		panicfIfError(err, "Error: Invalid -max-staleness")
+		bounds = append(bounds, spanner_dump.MaxStaleness(d))
+	}
+	if input.Opt_MinReadTimestamp != "" {
+		t, err := spanner_dump.ParseTimestamp(input.Opt_MinReadTimestamp, now)
+		panicfIfError(err, "Error: Invalid -min-read-timestamp")
+		bounds = append(bounds, spanner_dump.MinReadTimestamp(t))
+	}
+	switch len(bounds) {
+	case 0:
+		return spanner_dump.StrongRead()
+	case 1:
+		return bounds[0]
+	default:
+		fmt.Println(GetDoc(input.Subcommand))
+		panicf("Error: Invalid parameters: only one of -timestamp, -exact-staleness, -max-staleness, and -min-read-timestamp can be specified\n")
+		return spanner_dump.ReadBound{}
+	}
+}
+
+// maskPolicyFromInput returns the mask policy specified by -mask, -mask-file, and -mask-key, or nil if no rules are specified.
+func maskPolicyFromInput(input Input) *spanner_dump.MaskPolicy {
+	var rules []spanner_dump.MaskRule
+	for _, s := range input.Opt_Mask {
+		rule, err := spanner_dump.ParseMaskRule(s)
+		if err != nil {
+			fmt.Println(GetDoc(input.Subcommand))
+			panicf("Error: Invalid parameters: -mask: %v\n", err)
+		}
+		rules = append(rules, rule)
+	}
+	if input.Opt_MaskFile != "" {
+		f, err := os.Open(input.Opt_MaskFile)
+		panicfIfError(err, "Failed to open mask file")
+		fileRules, err := spanner_dump.LoadMaskRules(f)
+		f.Close()
+		panicfIfError(err, "Failed to load mask rules")
+		rules = append(rules, fileRules...)
+	}
+	if len(rules) == 0 {
+		return nil
+	}
+	key := input.Opt_MaskKey
+	if key == "" {
+		key = os.Getenv("SPANNER_DUMP_MASK_KEY")
+	}
+	return &spanner_dump.MaskPolicy{Rules: rules, Key: []byte(key)}
+}
+
+func (cli) Run_Convert(input Input_Convert) error {
+	if input.ErrorMessage != "" {
+		fmt.Println(GetDoc(input.Subcommand))
+		panicf("Error: %s\n", input.ErrorMessage)
+	}
+	format, err := spanner_dump.ParseFormat(input.Opt_Format)
+	if err != nil {
+		fmt.Println(GetDoc(input.Subcommand))
+		panicf("Error: Invalid parameters: %v\n", err)
+	}
+	if (format == spanner_dump.FormatCSV || format == spanner_dump.FormatYAML) && input.Opt_Output == "" {
+		fmt.Println(GetDoc(input.Subcommand))
+		panicf("Error: Missing parameters: -output is required for %s format\n", format)
+	}
+	if input.Opt_BulkSize < 0 || input.Opt_MaxMutations < 0 || input.Opt_MaxStatementBytes < 0 {
+		fmt.Println(GetDoc(input.Subcommand))
+		panicf("Error: Invalid parameters: -bulk-size, -max-mutations, and -max-statement-bytes must not be negative\n")
+	}
+
+	files, err := listDumpFiles(input.Arg_Input)
+	panicfIfError(err, "Failed to list dump files")
+
+	converter, err := spanner_dump.NewConverter(format, os.Stdout, spanner_dump.EncoderConfig{
+		OutDir:            input.Opt_Output,
+		BulkSize:          uint(input.Opt_BulkSize),
+		MaxMutations:      uint(input.Opt_MaxMutations),
+		MaxStatementBytes: uint(input.Opt_MaxStatementBytes),
+		Upsert:            input.Opt_Upsert,
+		GoPackage:         input.Opt_GoPackage,
+		GoFunc:            input.Opt_GoFunc,
+	})
+	panicfIfError(err, "Failed to create converter")
+
+	if input.Opt_Ddl != "" {
+		f, err := os.Open(input.Opt_Ddl)
+		panicfIfError(err, "Failed to open DDL file")
+		err = converter.LoadDDLs(f)
+		f.Close()
+		panicfIfError(err, "Failed to load DDLs")
+	}
+
+	for _, file := range files {
+		f, err := os.Open(file)
+		panicfIfError(err, "Failed to open dump file")
+		err = converter.Convert(f)
+		f.Close()
+		panicfIfError(err, "Failed to convert %s", file)
+	}
+
+	err = converter.Close()
+	panicfIfError(err, "Failed to write output")
+
+	return nil
+}
+
+func (cli) Run_LoadFixtures(input Input_LoadFixtures) error {
+	if input.ErrorMessage != "" {
+		fmt.Println(GetDoc(input.Subcommand))
+		panicf("Error: %s\n", input.ErrorMessage)
+	}
+	if input.Opt_Project == "" || input.Opt_Instance == "" || input.Opt_Database == "" {
+		fmt.Println(GetDoc(input.Subcommand))
+		panicf("Error: Missing parameters: -project, -instance, -database are required\n")
+	}
+
+	files, err := listFixtureFiles(input.Arg_Input)
+	panicfIfError(err, "Failed to list fixture files")
+
+	ctx := context.Background()
+	dbPath := fmt.Sprintf("projects/%s/instances/%s/databases/%s", input.Opt_Project, input.Opt_Instance, input.Opt_Database)
+	client, err := spanner.NewClient(ctx, dbPath)
+	panicfIfError(err, "Failed to create spanner client")
+	defer client.Close()
+
+	started := time.Now()
+	err = spanner_dump.LoadFixtures(ctx, client, files, input.Opt_Upsert, os.Stderr)
+	panicfIfError(err, "Failed to load fixtures")
+	log.Printf("Loaded %d files in %v", len(files), time.Since(started).Round(time.Millisecond))
+
+	return nil
+}
+
+func (cli) Run_LoadEmulator(input Input_LoadEmulator) error {
+	if input.ErrorMessage != "" {
+		fmt.Println(GetDoc(input.Subcommand))
+		panicf("Error: %s\n", input.ErrorMessage)
+	}
+	if input.Opt_Project == "" || input.Opt_Instance == "" || input.Opt_Database == "" {
+		fmt.Println(GetDoc(input.Subcommand))
+		panicf("Error: Missing parameters: -project, -instance, -database are required\n")
+	}
+	if os.Getenv("SPANNER_EMULATOR_HOST") == "" {
+		panicf("Error: SPANNER_EMULATOR_HOST is not set\n")
 	}
 
+	files, err := listDumpFiles(input.Arg_Input)
+	panicfIfError(err, "Failed to list dump files")
+
+	ctx := context.Background()
+	dbPath := fmt.Sprintf("projects/%s/instances/%s/databases/%s", input.Opt_Project, input.Opt_Instance, input.Opt_Database)
+	instanceAdminClient, err := instanceapi.NewInstanceAdminClient(ctx)
+	panicfIfError(err, "Failed to create spanner instance admin client")
+	defer instanceAdminClient.Close()
+	adminClient, err := adminapi.NewDatabaseAdminClient(ctx)
+	panicfIfError(err, "Failed to create spanner admin client")
+	defer adminClient.Close()
+
+	started := time.Now()
+	if input.Opt_Recreate {
+		err := adminClient.DropDatabase(ctx, &adminpb.DropDatabaseRequest{Database: dbPath})
+		if status.Code(err) != codes.NotFound {
+			panicfIfError(err, "Failed to drop database")
+		}
+	}
+	err = spanner_dump.CreateDatabaseIfNotExists(ctx, instanceAdminClient, adminClient, dbPath, spanner_dump.EmulatorInstanceConfig, os.Stderr)
+	panicfIfError(err, "Failed to create database")
+
+	client, err := spanner.NewClient(ctx, dbPath)
+	panicfIfError(err, "Failed to create spanner client")
+	defer client.Close()
+
+	err = spanner_dump.LoadDump(ctx, client, adminClient, files, os.Stderr)
+	panicfIfError(err, "Failed to load dump")
+	log.Printf("Loaded %d files in %v", len(files), time.Since(started).Round(time.Millisecond))
+
+	return nil
+}
+
+func (cli) Run_Diff(input Input_Diff) error {
+	if input.ErrorMessage != "" {
+		fmt.Println(GetDoc(input.Subcommand))
+		panicf("Error: %s\n", input.ErrorMessage)
+	}
+	if input.Opt_Project == "" || input.Opt_Instance == "" || input.Opt_Database == "" {
+		fmt.Println(GetDoc(input.Subcommand))
+		panicf("Error: Missing parameters: -project, -instance, -database are required\n")
+	}
+	if len(input.Opt_From) == 0 || len(input.Opt_Where) == 0 {
+		fmt.Println(GetDoc(input.Subcommand))
+		panicf("Error: Missing parameters: -from and -where are required\n")
+	}
+	if len(input.Opt_From) != len(input.Opt_Where) {
+		fmt.Println(GetDoc(input.Subcommand))
+		panicf("Error: Invalid parameters: count of -from and -where must be same\n")
+	}
+	w, err := spanner_dump.NewDiffWriter(os.Stdout, spanner_dump.DiffFormat(strings.ToLower(input.Opt_Format)))
+	if err != nil {
+		fmt.Println(GetDoc(input.Subcommand))
+		panicf("Error: Invalid parameters: %v\n", err)
+	}
+
+	toProject, toInstance, toDatabase := input.Opt_ToProject, input.Opt_ToInstance, input.Opt_ToDatabase
+	if toProject == "" {
+		toProject = input.Opt_Project
+	}
+	if toInstance == "" {
+		toInstance = input.Opt_Instance
+	}
+	if toDatabase == "" {
+		toDatabase = input.Opt_Database
+	}
+
+	now := time.Now()
+	fromBound, toBound := spanner_dump.StrongRead(), spanner_dump.StrongRead()
+	if input.Opt_FromTimestamp != "" {
+		t, err := spanner_dump.ParseTimestamp(input.Opt_FromTimestamp, now)
+		panicfIfError(err, "Error: Invalid -from-timestamp")
+		fromBound = spanner_dump.ReadTimestamp(t)
+	}
+	if input.Opt_ToTimestamp != "" {
+		t, err := spanner_dump.ParseTimestamp(input.Opt_ToTimestamp, now)
+		panicfIfError(err, "Error: Invalid -to-timestamp")
+		toBound = spanner_dump.ReadTimestamp(t)
+	}
+
+	queryOptions := spanner.QueryOptions{Priority: priorityFromInput(input.Subcommand, input.Opt_Priority)}
+	opts := []spanner_dump.Option{spanner_dump.WithQueryOptions(queryOptions)}
+	for index, from := range input.Opt_From {
+		opts = append(opts, spanner_dump.WithTable(from, input.Opt_Where[index]))
+	}
+
+	ctx := context.Background()
+	fromPath := fmt.Sprintf("projects/%s/instances/%s/databases/%s", input.Opt_Project, input.Opt_Instance, input.Opt_Database)
+	fromDumper, err := spanner_dump.NewDumper(ctx, fromPath, append(opts, spanner_dump.WithReadBound(fromBound))...)
+	panicfIfError(err, "Failed to create dumper")
+	defer fromDumper.Cleanup()
+	toPath := fmt.Sprintf("projects/%s/instances/%s/databases/%s", toProject, toInstance, toDatabase)
+	toDumper, err := spanner_dump.NewDumper(ctx, toPath, append(opts, spanner_dump.WithReadBound(toBound))...)
+	panicfIfError(err, "Failed to create dumper")
+	defer toDumper.Cleanup()
+
+	fromMetadata, err := fromDumper.Metadata(ctx)
+	panicfIfError(err, "Failed to read the old side")
+	toMetadata, err := toDumper.Metadata(ctx)
+	panicfIfError(err, "Failed to read the new side")
+	log.Printf("Comparing %s at %s with %s at %s", fromPath, fromMetadata.ReadTimestamp.Format(time.RFC3339Nano), toPath, toMetadata.ReadTimestamp.Format(time.RFC3339Nano))
+
+	stats, err := spanner_dump.Diff(ctx, fromDumper, toDumper, w.WriteDiff)
+	panicfIfError(err, "Failed to compare tables")
+	err = w.WriteStats(stats)
+	panicfIfError(err, "Failed to write statistics")
+
 	return nil
 }
 
+func (cli) Run_Delta(input Input_Delta) error {
+	if input.ErrorMessage != "" {
+		fmt.Println(GetDoc(input.Subcommand))
+		panicf("Error: %s\n", input.ErrorMessage)
+	}
+	if input.Opt_Project == "" || input.Opt_Instance == "" || input.Opt_Database == "" {
+		fmt.Println(GetDoc(input.Subcommand))
+		panicf("Error: Missing parameters: -project, -instance, -database are required\n")
+	}
+	if len(input.Opt_From) == 0 || len(input.Opt_Where) == 0 {
+		fmt.Println(GetDoc(input.Subcommand))
+		panicf("Error: Missing parameters: -from and -where are required\n")
+	}
+	if len(input.Opt_From) != len(input.Opt_Where) {
+		fmt.Println(GetDoc(input.Subcommand))
+		panicf("Error: Invalid parameters: count of -from and -where must be same\n")
+	}
+	if (input.Opt_FromTimestamp == "") == (input.Opt_Dump == "") {
+		fmt.Println(GetDoc(input.Subcommand))
+		panicf("Error: Invalid parameters: exactly one of -from-timestamp and -dump is required\n")
+	}
+	if input.Opt_Ddl != "" && input.Opt_Dump == "" {
+		fmt.Println(GetDoc(input.Subcommand))
+		panicf("Error: Invalid parameters: -ddl requires -dump\n")
+	}
+
+	now := time.Now()
+	toBound := spanner_dump.StrongRead()
+	if input.Opt_ToTimestamp != "" {
+		t, err := spanner_dump.ParseTimestamp(input.Opt_ToTimestamp, now)
+		panicfIfError(err, "Error: Invalid -to-timestamp")
+		toBound = spanner_dump.ReadTimestamp(t)
+	}
+
+	queryOptions := spanner.QueryOptions{Priority: priorityFromInput(input.Subcommand, input.Opt_Priority)}
+	opts := []spanner_dump.Option{spanner_dump.WithQueryOptions(queryOptions), spanner_dump.WithSort(true)}
+	for index, from := range input.Opt_From {
+		opts = append(opts, spanner_dump.WithTable(from, input.Opt_Where[index]))
+	}
+
+	ctx := context.Background()
+	dbPath := fmt.Sprintf("projects/%s/instances/%s/databases/%s", input.Opt_Project, input.Opt_Instance, input.Opt_Database)
+	toDumper, err := spanner_dump.NewDumper(ctx, dbPath, append(opts, spanner_dump.WithReadBound(toBound))...)
+	panicfIfError(err, "Failed to create dumper")
+	defer toDumper.Cleanup()
+	toMetadata, err := toDumper.Metadata(ctx)
+	panicfIfError(err, "Failed to read the new state")
+
+	w := spanner_dump.NewDeltaWriter(os.Stdout)
+	var stats []spanner_dump.TableDiffStats
+	if input.Opt_Dump != "" {
+		diffOpts := []spanner_dump.DiffOption{spanner_dump.WithStrictColumns()}
+		if input.Opt_Ddl != "" {
+			f, err := os.Open(input.Opt_Ddl)
+			panicfIfError(err, "Failed to open DDL file")
+			defer f.Close()
+			diffOpts = append(diffOpts, spanner_dump.WithDumpDDL(f))
+		}
+		files, err := listDumpFiles(input.Opt_Dump)
+		panicfIfError(err, "Failed to list dump files")
+		var readers []io.Reader
+		for _, file := range files {
+			f, err := os.Open(file)
+			panicfIfError(err, "Failed to open dump file")
+			defer f.Close()
+			// Statements of each file are terminated even without a trailing semicolon.
+			readers = append(readers, f, strings.NewReader("\n;\n"))
+		}
+		log.Printf("Comparing %s with %s at %s", input.Opt_Dump, dbPath, toMetadata.ReadTimestamp.Format(time.RFC3339Nano))
+		stats, err = spanner_dump.DiffDump(ctx, io.MultiReader(readers...), toDumper, w.WriteDiff, diffOpts...)
+		panicfIfError(err, "Failed to compare tables")
+	} else {
+		t, err := spanner_dump.ParseTimestamp(input.Opt_FromTimestamp, now)
+		panicfIfError(err, "Error: Invalid -from-timestamp")
+		fromDumper, err := spanner_dump.NewDumper(ctx, dbPath, append(opts, spanner_dump.WithReadBound(spanner_dump.ReadTimestamp(t)))...)
+		panicfIfError(err, "Failed to create dumper")
+		defer fromDumper.Cleanup()
+		fromMetadata, err := fromDumper.Metadata(ctx)
+		panicfIfError(err, "Failed to read the old state")
+		log.Printf("Comparing %s at %s with %s", dbPath, fromMetadata.ReadTimestamp.Format(time.RFC3339Nano), toMetadata.ReadTimestamp.Format(time.RFC3339Nano))
+		stats, err = spanner_dump.Diff(ctx, fromDumper, toDumper, w.WriteDiff, spanner_dump.WithStrictColumns())
+		panicfIfError(err, "Failed to compare tables")
+	}
+	err = w.Close()
+	panicfIfError(err, "Failed to write DELETE statements")
+	for _, s := range stats {
+		log.Printf("Table %s: %d inserted, %d deleted, %d changed, %d unchanged", s.Table, s.Inserted, s.Deleted, s.Changed, s.Unchanged)
+	}
+
+	return nil
+}
+
+// priorityFromInput returns the request priority specified by -priority, which is unspecified if empty.
+func priorityFromInput(subcommand []string, priority string) sppb.RequestOptions_Priority {
+	if priority == "" {
+		return sppb.RequestOptions_PRIORITY_UNSPECIFIED
+	}
+	value, ok := sppb.RequestOptions_Priority_value["PRIORITY_"+strings.ToUpper(priority)]
+	if !ok {
+		fmt.Println(GetDoc(subcommand))
+		panicf("Error: Invalid parameters: -priority must be one of LOW, MEDIUM, and HIGH\n")
+	}
+	return sppb.RequestOptions_Priority(value)
+}
+
+// listFixtureFiles returns path itself if it is a file, or *.yml and *.yaml files in lexical order if it is a directory.
+func listFixtureFiles(path string) ([]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+	var files []string
+	for _, pattern := range []string{"*.yml", "*.yaml"} {
+		matches, err := filepath.Glob(filepath.Join(path, pattern))
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, matches...)
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// listDumpFiles returns path itself if it is a file, or *.sql files in lexical order if it is a directory.
+func listDumpFiles(path string) ([]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+	files, err := filepath.Glob(filepath.Join(path, "*.sql"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
 func panicfIfError(err error, format string, a ...interface{}) {
 	if err != nil {
 		log.Panicf(fmt.Sprintf(format, a...)+": %+v", err)
@@ -0,0 +1,91 @@
+package spanner_dump
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"time"
+)
+
+// Checkpoint records the progress of DumpTables to resume an interrupted dump at the same snapshot.
+//
+// NOTE: Checkpoint is not goroutine-safe.
+type Checkpoint struct {
+	// ReadTimestamp is the timestamp of the snapshot which the dump reads.
+	ReadTimestamp time.Time `json:"readTimestamp"`
+	// CompletedTables are names of tables whose records are all written.
+	CompletedTables []string `json:"completedTables"`
+	// LastKeys maps names of partially written tables to
+	// literals of primary key values of their last written records.
+	LastKeys map[string][]string `json:"lastKeys"`
+
+	path string
+}
+
+// LoadCheckpoint loads a checkpoint from the file, which is updated as the dump progresses.
+// If the file does not exist, it returns an empty checkpoint.
+func LoadCheckpoint(path string) (*Checkpoint, error) {
+	c := &Checkpoint{path: path, LastKeys: map[string][]string{}}
+	b, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(b, c); err != nil {
+		return nil, fmt.Errorf("invalid checkpoint file %s: %v", path, err)
+	}
+	if c.LastKeys == nil {
+		c.LastKeys = map[string][]string{}
+	}
+	return c, nil
+}
+
+// Resumed reports whether the checkpoint has recorded a started dump.
+func (c *Checkpoint) Resumed() bool {
+	return !c.ReadTimestamp.IsZero()
+}
+
+func (c *Checkpoint) completed(table string) bool {
+	return containsString(c.CompletedTables, table)
+}
+
+func (c *Checkpoint) start(readTimestamp time.Time) error {
+	c.ReadTimestamp = readTimestamp
+	return c.save()
+}
+
+func (c *Checkpoint) complete(table string) error {
+	c.CompletedTables = append(c.CompletedTables, table)
+	delete(c.LastKeys, table)
+	return c.save()
+}
+
+// recordLastRow records the primary key of the last written record of the table.
+func (c *Checkpoint) recordLastRow(table *Table, row []string) error {
+	key, ok := primaryKeyValues(table, row)
+	if !ok {
+		return nil
+	}
+	c.LastKeys[table.Name] = key
+	return c.save()
+}
+
+// save writes the checkpoint to a temporary file and renames it so that the file is never left broken.
+func (c *Checkpoint) save() error {
+	b, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := c.path + ".tmp"
+	if err := os.WriteFile(tmp, b, 0o644); err != nil {
+		return fmt.Errorf("failed to write checkpoint: %v", err)
+	}
+	if err := os.Rename(tmp, c.path); err != nil {
+		return fmt.Errorf("failed to write checkpoint: %v", err)
+	}
+	return nil
+}
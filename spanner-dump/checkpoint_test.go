@@ -0,0 +1,47 @@
+package spanner_dump
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCheckpoint(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "checkpoint.json")
+	c, err := LoadCheckpoint(path)
+	if err != nil {
+		t.Fatalf("LoadCheckpoint() failed: %v", err)
+	}
+	if c.Resumed() {
+		t.Errorf("Resumed() of a new checkpoint: got = true, want = false")
+	}
+
+	ts := time.Date(2020, 1, 23, 3, 0, 0, 123, time.UTC)
+	table := &Table{Name: "t2", Columns: []string{"Id"}, PrimaryKey: []KeyColumn{{Name: "Id"}}}
+	if err := c.start(ts); err != nil {
+		t.Fatalf("start() failed: %v", err)
+	}
+	if err := c.recordLastRow(&Table{Name: "t1", Columns: []string{"Id"}, PrimaryKey: []KeyColumn{{Name: "Id"}}}, []string{"1"}); err != nil {
+		t.Fatalf("recordLastRow() failed: %v", err)
+	}
+	if err := c.complete("t1"); err != nil {
+		t.Fatalf("complete() failed: %v", err)
+	}
+	if err := c.recordLastRow(table, []string{"10"}); err != nil {
+		t.Fatalf("recordLastRow() failed: %v", err)
+	}
+
+	got, err := LoadCheckpoint(path)
+	if err != nil {
+		t.Fatalf("LoadCheckpoint() failed: %v", err)
+	}
+	if !got.Resumed() || !got.ReadTimestamp.Equal(ts) {
+		t.Errorf("ReadTimestamp: got = %v, want = %v", got.ReadTimestamp, ts)
+	}
+	if !got.completed("t1") || got.completed("t2") {
+		t.Errorf("CompletedTables: got = %v, want = [t1]", got.CompletedTables)
+	}
+	if len(got.LastKeys) != 1 || !equalStringSlice(got.LastKeys["t2"], []string{"10"}) {
+		t.Errorf("LastKeys: got = %v, want = map[t2:[10]]", got.LastKeys)
+	}
+}
@@ -0,0 +1,102 @@
+package spanner_dump
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/base64"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	pb "google.golang.org/genproto/googleapis/spanner/v1"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// CompareValues compares values of the type in the encoding of Cloud Spanner API in the order of Cloud Spanner,
+// where NULL is the smallest and NaN is smaller than any other FLOAT64 value.
+// It returns a negative number if a < b, a positive number if a > b, and 0 otherwise.
+func CompareValues(typ *pb.Type, a, b *structpb.Value) int {
+	aNull, bNull := isNull(a), isNull(b)
+	switch {
+	case aNull && bNull:
+		return 0
+	case aNull:
+		return -1
+	case bNull:
+		return 1
+	}
+
+	switch typ.GetCode() {
+	case pb.TypeCode_BOOL:
+		return cmp.Compare(boolRank(a.GetBoolValue()), boolRank(b.GetBoolValue()))
+	case pb.TypeCode_INT64:
+		x, errX := strconv.ParseInt(a.GetStringValue(), 10, 64)
+		y, errY := strconv.ParseInt(b.GetStringValue(), 10, 64)
+		if errX == nil && errY == nil {
+			return cmp.Compare(x, y)
+		}
+		// A value may be compared with a FLOAT64 literal.
+		fallthrough
+	case pb.TypeCode_FLOAT64:
+		x, errX := floatValue(a)
+		y, errY := floatValue(b)
+		if errX == nil && errY == nil {
+			// cmp.Compare regards NaN as less than any other value as Cloud Spanner does.
+			return cmp.Compare(x, y)
+		}
+	case pb.TypeCode_NUMERIC:
+		x, okX := new(big.Rat).SetString(a.GetStringValue())
+		y, okY := new(big.Rat).SetString(b.GetStringValue())
+		if okX && okY {
+			return x.Cmp(y)
+		}
+	case pb.TypeCode_TIMESTAMP:
+		x, errX := time.Parse(time.RFC3339Nano, a.GetStringValue())
+		y, errY := time.Parse(time.RFC3339Nano, b.GetStringValue())
+		if errX == nil && errY == nil {
+			return x.Compare(y)
+		}
+	case pb.TypeCode_BYTES:
+		x, errX := base64.StdEncoding.DecodeString(a.GetStringValue())
+		y, errY := base64.StdEncoding.DecodeString(b.GetStringValue())
+		if errX == nil && errY == nil {
+			return bytes.Compare(x, y)
+		}
+	case pb.TypeCode_ARRAY:
+		x, y := a.GetListValue().GetValues(), b.GetListValue().GetValues()
+		for i := 0; i < len(x) && i < len(y); i++ {
+			if c := CompareValues(typ.ArrayElementType, x[i], y[i]); c != 0 {
+				return c
+			}
+		}
+		return cmp.Compare(len(x), len(y))
+	}
+	return strings.Compare(a.GetStringValue(), b.GetStringValue())
+}
+
+func isNull(v *structpb.Value) bool {
+	_, ok := v.GetKind().(*structpb.Value_NullValue)
+	return v == nil || ok
+}
+
+func boolRank(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// floatValue returns the value of FLOAT64, which is a number or a string such as "NaN", "Infinity" and "-Infinity".
+func floatValue(v *structpb.Value) (float64, error) {
+	switch k := v.GetKind().(type) {
+	case *structpb.Value_NumberValue:
+		return k.NumberValue, nil
+	case *structpb.Value_StringValue:
+		return strconv.ParseFloat(k.StringValue, 64)
+	default:
+		return math.NaN(), fmt.Errorf("invalid FLOAT64 value: %v", v)
+	}
+}
@@ -0,0 +1,46 @@
+package spanner_dump
+
+import (
+	"testing"
+
+	pb "google.golang.org/genproto/googleapis/spanner/v1"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestCompareValues(t *testing.T) {
+	null := structpb.NewNullValue()
+	str := structpb.NewStringValue
+	num := structpb.NewNumberValue
+	for _, tt := range []struct {
+		desc string
+		typ  *pb.Type
+		a, b *structpb.Value
+		want int
+	}{
+		{desc: "NULL first", typ: &pb.Type{Code: pb.TypeCode_INT64}, a: null, b: str("-1"), want: -1},
+		{desc: "NULLs", typ: &pb.Type{Code: pb.TypeCode_STRING}, a: null, b: null, want: 0},
+		{desc: "INT64 numerically", typ: &pb.Type{Code: pb.TypeCode_INT64}, a: str("10"), b: str("9"), want: 1},
+		{desc: "NaN first", typ: &pb.Type{Code: pb.TypeCode_FLOAT64}, a: str("NaN"), b: str("-Infinity"), want: -1},
+		{desc: "FLOAT64", typ: &pb.Type{Code: pb.TypeCode_FLOAT64}, a: num(1.5), b: num(1.5), want: 0},
+		{desc: "NUMERIC", typ: &pb.Type{Code: pb.TypeCode_NUMERIC}, a: str("2.50"), b: str("10"), want: -1},
+		{desc: "TIMESTAMP", typ: &pb.Type{Code: pb.TypeCode_TIMESTAMP}, a: str("2020-01-01T09:00:00+09:00"), b: str("2020-01-01T00:00:00Z"), want: 0},
+		{desc: "BYTES", typ: &pb.Type{Code: pb.TypeCode_BYTES}, a: str("/w=="), b: str("AA=="), want: 1},
+		{desc: "STRING", typ: &pb.Type{Code: pb.TypeCode_STRING}, a: str("B"), b: str("a"), want: -1},
+		{
+			desc: "ARRAY",
+			typ:  &pb.Type{Code: pb.TypeCode_ARRAY, ArrayElementType: &pb.Type{Code: pb.TypeCode_INT64}},
+			a:    structpb.NewListValue(&structpb.ListValue{Values: []*structpb.Value{str("1")}}),
+			b:    structpb.NewListValue(&structpb.ListValue{Values: []*structpb.Value{str("1"), null}}),
+			want: -1,
+		},
+	} {
+		t.Run(tt.desc, func(t *testing.T) {
+			if got := CompareValues(tt.typ, tt.a, tt.b); got != tt.want {
+				t.Errorf("CompareValues(): got = %d, want = %d", got, tt.want)
+			}
+			if got := CompareValues(tt.typ, tt.b, tt.a); got != -tt.want {
+				t.Errorf("CompareValues() swapped: got = %d, want = %d", got, -tt.want)
+			}
+		})
+	}
+}
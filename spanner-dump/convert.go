@@ -0,0 +1,357 @@
+package spanner_dump
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"cloud.google.com/go/spanner"
+	pb "google.golang.org/genproto/googleapis/spanner/v1"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// Converter converts dump files produced by Dumper into another format.
+type Converter struct {
+	format  Format
+	out     io.Writer
+	encoder Encoder
+	began   bool
+
+	columnTypes map[string]map[string]*pb.Type
+	indexes     map[string][]Index
+	// encoders are encoders of tables which have begun and not yet ended.
+	encoders map[string]TableEncoder
+	tables   map[string]*Table
+	current  string
+}
+
+// NewConverter creates Converter writing records to out by the encoder of the format created by NewEncoder with config,
+// e.g. FormatCSV writes a file named <table>.csv in config.OutDir for each table,
+// and FormatGo writes a function returning the records as mutations, which can be applied in a batch by spanner.Client.Apply.
+func NewConverter(format Format, out io.Writer, config EncoderConfig) (*Converter, error) {
+	encoder, err := NewEncoder(format, config)
+	if err != nil {
+		return nil, err
+	}
+	return &Converter{
+		format:      format,
+		out:         out,
+		encoder:     encoder,
+		columnTypes: map[string]map[string]*pb.Type{},
+		indexes:     map[string][]Index{},
+		encoders:    map[string]TableEncoder{},
+		tables:      map[string]*Table{},
+	}, nil
+}
+
+// LoadDDLs loads table schemas from CREATE TABLE statements without converting anything.
+func (c *Converter) LoadDDLs(r io.Reader) error {
+	scanner := newStatementScanner(r)
+	for {
+		stmt, err := scanner.next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if _, err := c.loadDDL(stmt); err != nil {
+			return err
+		}
+	}
+}
+
+func (c *Converter) loadDDL(stmt string) (bool, error) {
+	table, index, ok, err := parseCreateIndex(stmt)
+	if err != nil {
+		return true, fmt.Errorf("failed to parse DDL: %v", err)
+	}
+	if ok {
+		c.indexes[table] = append(c.indexes[table], index)
+		return true, nil
+	}
+
+	name, columns, ok, err := parseCreateTable(stmt)
+	if err != nil {
+		return true, fmt.Errorf("failed to parse DDL: %v", err)
+	}
+	if !ok {
+		return ddlRegexp.MatchString(stmt), nil
+	}
+	types := map[string]*pb.Type{}
+	for _, column := range columns {
+		types[column.name] = column.typ
+	}
+	c.columnTypes[name] = types
+	return true, nil
+}
+
+var ddlRegexp = regexp.MustCompile("(?i)^\\s*(?:CREATE|ALTER|DROP)\\s")
+
+// Convert converts statements in a dump file.
+// DDL statements in the dump are also loaded as table schemas, and they are copied to the output for FormatSQL.
+func (c *Converter) Convert(r io.Reader) error {
+	scanner := newStatementScanner(r)
+	for {
+		stmt, err := scanner.next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		isDDL, err := c.loadDDL(stmt)
+		if err != nil {
+			return err
+		}
+		if isDDL {
+			if c.format == FormatSQL {
+				if err := c.switchTable(""); err != nil {
+					return err
+				}
+				fmt.Fprintf(c.out, "%s;\n", stmt)
+			}
+			continue
+		}
+
+		insert, ok, err := parseInsert(stmt, c.lookupColumnTypes)
+		if err != nil {
+			return fmt.Errorf("failed to parse statement: %v", err)
+		}
+		if !ok {
+			return fmt.Errorf("unsupported statement: %.100s", stmt)
+		}
+		if err := c.convertInsert(insert); err != nil {
+			return fmt.Errorf("failed to convert records of table %s: %v", insert.table, err)
+		}
+	}
+}
+
+func (c *Converter) lookupColumnTypes(table string) (map[string]*pb.Type, error) {
+	types, ok := c.columnTypes[table]
+	if !ok {
+		return nil, fmt.Errorf("schema of table %s is not found", table)
+	}
+	return types, nil
+}
+
+func (c *Converter) convertInsert(insert *insertStatement) error {
+	if err := c.switchTable(insert.table); err != nil {
+		return err
+	}
+	encoder, err := c.tableEncoder(insert.table, insert.columns)
+	if err != nil {
+		return err
+	}
+
+	types := c.columnTypes[insert.table]
+	for _, row := range insert.rows {
+		values := make([]spanner.GenericColumnValue, len(row))
+		for i, v := range row {
+			values[i] = spanner.GenericColumnValue{Type: types[insert.columns[i]], Value: v}
+		}
+		if err := encoder.WriteRow(values); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// switchTable ends the previous table to keep the order of tables in a single output stream.
+func (c *Converter) switchTable(table string) error {
+	if c.current == table {
+		return nil
+	}
+	if encoder, ok := c.encoders[c.current]; ok {
+		delete(c.encoders, c.current)
+		if err := encoder.EndTable(); err != nil {
+			return err
+		}
+	}
+	c.current = table
+	return nil
+}
+
+func (c *Converter) tableEncoder(tableName string, columns []string) (TableEncoder, error) {
+	table, ok := c.tables[tableName]
+	if ok && !equalColumns(table.Columns, columns) {
+		return nil, fmt.Errorf("columns %v differ from previous columns %v", columns, table.Columns)
+	}
+	if encoder, ok := c.encoders[tableName]; ok {
+		return encoder, nil
+	}
+	if !ok {
+		table = &Table{Name: tableName, Columns: columns, Indexes: c.indexes[tableName]}
+		c.tables[tableName] = table
+	}
+
+	if !c.began {
+		if err := c.encoder.BeginDump(c.out, &DumpInfo{}); err != nil {
+			return nil, err
+		}
+		c.began = true
+	}
+	encoder, err := c.encoder.BeginTable(c.out, table)
+	if err != nil {
+		return nil, err
+	}
+	c.encoders[tableName] = encoder
+	return encoder, nil
+}
+
+func equalColumns(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Close ends all tables and the output.
+func (c *Converter) Close() error {
+	var firstErr error
+	for _, encoder := range c.encoders {
+		if err := encoder.EndTable(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	c.encoders = map[string]TableEncoder{}
+	if c.began {
+		if err := c.encoder.EndDump(c.out); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// ReadDump reads table schemas and records from a dump file produced by Dumper without accessing the database.
+// onTable is called for each CREATE TABLE statement with the table and the types of all of its columns.
+// onRow is called for each record of INSERT statements, where the table has the columns of the statement.
+// Other statements such as CREATE INDEX are skipped.
+func ReadDump(r io.Reader, onTable func(table *Table, columnTypes []*pb.Type) error, onRow func(table *Table, row Row) error) error {
+	columnTypes := map[string]map[string]*pb.Type{}
+	primaryKeys := map[string][]KeyColumn{}
+	lookupColumnTypes := func(table string) (map[string]*pb.Type, error) {
+		types, ok := columnTypes[table]
+		if !ok {
+			return nil, fmt.Errorf("schema of table %s is not found", table)
+		}
+		return types, nil
+	}
+
+	scanner := newStatementScanner(r)
+	for {
+		stmt, err := scanner.next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		name, columns, ok, err := parseCreateTable(stmt)
+		if err != nil {
+			return fmt.Errorf("failed to parse DDL: %v", err)
+		}
+		if ok {
+			primaryKey, err := parsePrimaryKey(stmt)
+			if err != nil {
+				return fmt.Errorf("failed to parse primary key of %s: %v", name, err)
+			}
+			table := &Table{Name: name, PrimaryKey: primaryKey}
+			types := map[string]*pb.Type{}
+			var typeList []*pb.Type
+			for _, column := range columns {
+				table.Columns = append(table.Columns, column.name)
+				table.NotNull = append(table.NotNull, column.notNull)
+				types[column.name] = column.typ
+				typeList = append(typeList, column.typ)
+			}
+			columnTypes[name] = types
+			primaryKeys[name] = primaryKey
+			if err := onTable(table, typeList); err != nil {
+				return err
+			}
+			continue
+		}
+		if ddlRegexp.MatchString(stmt) {
+			continue
+		}
+
+		insert, ok, err := parseInsert(stmt, lookupColumnTypes)
+		if err != nil {
+			return fmt.Errorf("failed to parse statement: %v", err)
+		}
+		if !ok {
+			return fmt.Errorf("unsupported statement: %.100s", stmt)
+		}
+		table := &Table{Name: insert.table, Columns: insert.columns, PrimaryKey: primaryKeys[insert.table]}
+		types := columnTypes[insert.table]
+		for _, values := range insert.rows {
+			row := Row{table: table, values: make([]spanner.GenericColumnValue, len(values))}
+			for i, v := range values {
+				row.values[i] = spanner.GenericColumnValue{Type: types[insert.columns[i]], Value: v}
+			}
+			if err := onRow(table, row); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// formatValueText formats a value as a plain text, where NULL is an empty string.
+func formatValueText(v *structpb.Value) string {
+	switch k := v.GetKind().(type) {
+	case *structpb.Value_NullValue:
+		return ""
+	case *structpb.Value_BoolValue:
+		return strconv.FormatBool(k.BoolValue)
+	case *structpb.Value_NumberValue:
+		return strconv.FormatFloat(k.NumberValue, 'g', -1, 64)
+	case *structpb.Value_StringValue:
+		return k.StringValue
+	default:
+		sb := &strings.Builder{}
+		writeValueJSON(sb, v)
+		return sb.String()
+	}
+}
+
+// writeValueJSON writes a value in JSON with a stable format, unlike protojson.
+func writeValueJSON(sb *strings.Builder, v *structpb.Value) {
+	switch k := v.GetKind().(type) {
+	case *structpb.Value_BoolValue:
+		sb.WriteString(strconv.FormatBool(k.BoolValue))
+	case *structpb.Value_NumberValue:
+		sb.WriteString(strconv.FormatFloat(k.NumberValue, 'g', -1, 64))
+	case *structpb.Value_StringValue:
+		writeJSONString(sb, k.StringValue)
+	case *structpb.Value_ListValue:
+		sb.WriteString("[")
+		for i, e := range k.ListValue.GetValues() {
+			if i > 0 {
+				sb.WriteString(",")
+			}
+			writeValueJSON(sb, e)
+		}
+		sb.WriteString("]")
+	default:
+		sb.WriteString("null")
+	}
+}
+
+func writeJSONString(sb *strings.Builder, s string) {
+	b := &strings.Builder{}
+	e := json.NewEncoder(b)
+	e.SetEscapeHTML(false)
+	_ = e.Encode(s)
+	sb.WriteString(strings.TrimSuffix(b.String(), "\n"))
+}
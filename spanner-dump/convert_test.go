@@ -0,0 +1,148 @@
+package spanner_dump
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	pb "google.golang.org/genproto/googleapis/spanner/v1"
+)
+
+const testDump = "CREATE TABLE t1 (\n" +
+	"  Id INT64 NOT NULL,\n" +
+	"  Name STRING(MAX),\n" +
+	"  Data BYTES(MAX),\n" +
+	"  Tags ARRAY<STRING(MAX)>,\n" +
+	") PRIMARY KEY(Id);\n" +
+	"INSERT INTO `t1` (`Id`, `Name`, `Data`, `Tags`) VALUES (1, \"foo\", b\"\\x61\\x62\", [\"a\", NULL]), (2, NULL, NULL, NULL);\n" +
+	"INSERT INTO `t1` (`Id`, `Name`, `Data`, `Tags`) VALUES (3, \"a,\\\"b\\\"\", b\"\", []);\n"
+
+func TestConverter_Convert(t *testing.T) {
+	for _, tt := range []struct {
+		desc   string
+		format Format
+		want   string
+	}{
+		{
+			desc:   "sql",
+			format: FormatSQL,
+			want: "CREATE TABLE t1 (\n" +
+				"  Id INT64 NOT NULL,\n" +
+				"  Name STRING(MAX),\n" +
+				"  Data BYTES(MAX),\n" +
+				"  Tags ARRAY<STRING(MAX)>,\n" +
+				") PRIMARY KEY(Id);\n" +
+				"INSERT OR UPDATE INTO `t1` (`Id`, `Name`, `Data`, `Tags`) VALUES (1, \"foo\", b\"\\x61\\x62\", [\"a\", NULL]), (2, NULL, NULL, NULL);\n" +
+				"INSERT OR UPDATE INTO `t1` (`Id`, `Name`, `Data`, `Tags`) VALUES (3, \"a,\\\"b\\\"\", b\"\", []);\n",
+		},
+		{
+			desc:   "jsonl",
+			format: FormatJSONL,
+			want: `{"table":"t1","values":{"Id":"1","Name":"foo","Data":"YWI=","Tags":["a",null]}}` + "\n" +
+				`{"table":"t1","values":{"Id":"2","Name":null,"Data":null,"Tags":null}}` + "\n" +
+				`{"table":"t1","values":{"Id":"3","Name":"a,\"b\"","Data":"","Tags":[]}}` + "\n",
+		},
+	} {
+		t.Run(tt.desc, func(t *testing.T) {
+			out := &bytes.Buffer{}
+			c, err := NewConverter(tt.format, out, EncoderConfig{BulkSize: 2, Upsert: true})
+			if err != nil {
+				t.Fatalf("NewConverter() failed: %v", err)
+			}
+			if err := c.Convert(strings.NewReader(testDump)); err != nil {
+				t.Fatalf("Convert() failed: %v", err)
+			}
+			if err := c.Close(); err != nil {
+				t.Fatalf("Close() failed: %v", err)
+			}
+			if got := out.String(); got != tt.want {
+				t.Errorf("Convert(): got = %q, want = %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConverter_Convert_csv(t *testing.T) {
+	dir := t.TempDir()
+	c, err := NewConverter(FormatCSV, nil, EncoderConfig{OutDir: dir})
+	if err != nil {
+		t.Fatalf("NewConverter() failed: %v", err)
+	}
+	if err := c.Convert(strings.NewReader(testDump)); err != nil {
+		t.Fatalf("Convert() failed: %v", err)
+	}
+	if err := c.Close(); err != nil {
+		t.Fatalf("Close() failed: %v", err)
+	}
+
+	got, err := os.ReadFile(filepath.Join(dir, "t1.csv"))
+	if err != nil {
+		t.Fatalf("failed to read output: %v", err)
+	}
+	want := "Id,Name,Data,Tags\n" +
+		"1,foo,YWI=,\"[\"\"a\"\",null]\"\n" +
+		"2,,,\n" +
+		"3,\"a,\"\"b\"\"\",,[]\n"
+	if string(got) != want {
+		t.Errorf("Convert(): got = %q, want = %q", got, want)
+	}
+}
+
+func TestConverter_LoadDDLs(t *testing.T) {
+	ddl, data, _ := strings.Cut(testDump, "INSERT")
+	data = "INSERT" + data
+
+	c, err := NewConverter(FormatJSONL, &bytes.Buffer{}, EncoderConfig{})
+	if err != nil {
+		t.Fatalf("NewConverter() failed: %v", err)
+	}
+	if err := c.Convert(strings.NewReader(data)); err == nil {
+		t.Errorf("Convert() without schema: expected error but got nil")
+	}
+	if err := c.LoadDDLs(strings.NewReader(ddl)); err != nil {
+		t.Fatalf("LoadDDLs() failed: %v", err)
+	}
+	if err := c.Convert(strings.NewReader(data)); err != nil {
+		t.Errorf("Convert() failed: %v", err)
+	}
+}
+
+func TestReadDump(t *testing.T) {
+	var tables []string
+	var rows []string
+	err := ReadDump(strings.NewReader(testDump+"CREATE INDEX idx ON t1 (Name);\n"),
+		func(table *Table, columnTypes []*pb.Type) error {
+			tables = append(tables, fmt.Sprintf("%s%v%v%d", table.Name, table.Columns, table.PrimaryKey, len(columnTypes)))
+			return nil
+		},
+		func(table *Table, row Row) error {
+			values, err := decodeValues(row.values)
+			if err != nil {
+				return err
+			}
+			rows = append(rows, fmt.Sprintf("%s%v", table.Name, values))
+			return nil
+		})
+	if err != nil {
+		t.Fatalf("ReadDump() failed: %v", err)
+	}
+	wantTables := []string{"t1[Id Name Data Tags][{Id false}]4"}
+	if !equalColumns(tables, wantTables) {
+		t.Errorf("ReadDump(): tables = %q, want = %q", tables, wantTables)
+	}
+	wantRows := []string{
+		`t1[1 "foo" b"\x61\x62" ["a", NULL]]`,
+		`t1[2 NULL NULL NULL]`,
+		`t1[3 "a,\"b\"" b"" []]`,
+	}
+	if !equalColumns(rows, wantRows) {
+		t.Errorf("ReadDump(): rows = %q, want = %q", rows, wantRows)
+	}
+
+	if err := ReadDump(strings.NewReader("INSERT INTO t2 (Id) VALUES (1);"), nil, nil); err == nil {
+		t.Errorf("ReadDump() without schema: expected error but got nil")
+	}
+}
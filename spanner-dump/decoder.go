@@ -34,13 +34,29 @@ type jsonMessage struct {
 
 // DecodeRow decodes column values in spanner.Row into strings.
 func DecodeRow(row *spanner.Row) ([]string, error) {
-	columns := make([]string, row.Size())
-	for i := 0; i < row.Size(); i++ {
-		var column spanner.GenericColumnValue
-		if err := row.Column(i, &column); err != nil {
+	values, err := rowValues(row)
+	if err != nil {
+		return nil, err
+	}
+	return decodeValues(values)
+}
+
+// rowValues returns typed column values in spanner.Row.
+func rowValues(row *spanner.Row) ([]spanner.GenericColumnValue, error) {
+	values := make([]spanner.GenericColumnValue, row.Size())
+	for i := range values {
+		if err := row.Column(i, &values[i]); err != nil {
 			return nil, err
 		}
-		decoded, err := DecodeColumn(column)
+	}
+	return values, nil
+}
+
+// decodeValues decodes typed column values into strings.
+func decodeValues(values []spanner.GenericColumnValue) ([]string, error) {
+	columns := make([]string, len(values))
+	for i, v := range values {
+		decoded, err := DecodeColumn(v)
 		if err != nil {
 			return nil, err
 		}
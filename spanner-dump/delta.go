@@ -0,0 +1,100 @@
+package spanner_dump
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// DeltaWriter writes DML statements to move a database from the old state to the new state of differences passed by WriteDiff,
+// i.e. INSERT for inserted records, UPDATE of the changed columns for changed records, and DELETE for deleted records,
+// where UPDATE and DELETE statements specify records by the primary key.
+// Differences must be passed in the dependency order of tables, i.e. parents of interleaving and tables referenced by foreign keys first,
+// as Diff does for dumpers with WithSort, and should be compared with WithStrictColumns so that INSERT statements have all columns of the new state.
+// INSERT and UPDATE statements are written as differences are passed, so that parents are inserted first and updated foreign keys reference inserted records,
+// while DELETE statements are held until Close and written in the reverse order of tables, so that children are deleted first.
+type DeltaWriter struct {
+	out io.Writer
+	// deletes are DELETE statements of each table in the order of tables.
+	deletes [][]string
+	tables  []string
+}
+
+// NewDeltaWriter creates DeltaWriter writing DML statements to out.
+func NewDeltaWriter(out io.Writer) *DeltaWriter {
+	return &DeltaWriter{out: out}
+}
+
+// WriteDiff writes a DML statement of a difference of a record, which can be passed to Diff as fn.
+func (w *DeltaWriter) WriteDiff(diff *RowDiff) error {
+	stmt, err := deltaStatement(diff)
+	if err != nil {
+		return fmt.Errorf("failed to build DML of table %s: %v", diff.Table.Name, err)
+	}
+	if diff.Kind == DiffDeleted {
+		if n := len(w.tables); n == 0 || w.tables[n-1] != diff.Table.Name {
+			w.tables = append(w.tables, diff.Table.Name)
+			w.deletes = append(w.deletes, nil)
+		}
+		w.deletes[len(w.deletes)-1] = append(w.deletes[len(w.deletes)-1], stmt)
+		return nil
+	}
+	_, err = io.WriteString(w.out, stmt+"\n")
+	return err
+}
+
+// Close writes the held DELETE statements children first.
+func (w *DeltaWriter) Close() error {
+	for i := len(w.deletes) - 1; i >= 0; i-- {
+		if _, err := io.WriteString(w.out, strings.Join(w.deletes[i], "\n")+"\n"); err != nil {
+			return err
+		}
+	}
+	w.deletes, w.tables = nil, nil
+	return nil
+}
+
+func deltaStatement(diff *RowDiff) (string, error) {
+	table := diff.Table
+	switch diff.Kind {
+	case DiffInserted:
+		values, err := decodeValues(diff.To)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("INSERT INTO `%s` (%s) VALUES (%s);", table.Name, table.quotedColumnList(), strings.Join(values, ", ")), nil
+	case DiffChanged:
+		var assignments []string
+		for _, i := range diff.Changed {
+			value, err := DecodeColumn(diff.To[i])
+			if err != nil {
+				return "", err
+			}
+			assignments = append(assignments, fmt.Sprintf("`%s` = %s", table.Columns[i], value))
+		}
+		where, err := keyCondition(diff)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("UPDATE `%s` SET %s WHERE %s;", table.Name, strings.Join(assignments, ", "), where), nil
+	default:
+		where, err := keyCondition(diff)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("DELETE FROM `%s` WHERE %s;", table.Name, where), nil
+	}
+}
+
+// keyCondition builds an SQL boolean expression which holds for the record of the difference.
+func keyCondition(diff *RowDiff) (string, error) {
+	var conjuncts []string
+	for i, v := range diff.Key() {
+		value, err := DecodeColumn(v)
+		if err != nil {
+			return "", err
+		}
+		conjuncts = append(conjuncts, keyEqualCondition(diff.Table.PrimaryKey[i].Name, value))
+	}
+	return strings.Join(conjuncts, " AND "), nil
+}
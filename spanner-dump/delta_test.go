@@ -0,0 +1,46 @@
+package spanner_dump
+
+import (
+	"strings"
+	"testing"
+
+	"cloud.google.com/go/spanner"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestDeltaWriter(t *testing.T) {
+	singers := &Table{Name: "Singers", Columns: []string{"Id", "Name"}, PrimaryKey: []KeyColumn{{Name: "Id"}}}
+	albums := &Table{Name: "Albums", Columns: []string{"Id", "Title"}, PrimaryKey: []KeyColumn{{Name: "Id"}}}
+	nullKey := []spanner.GenericColumnValue{
+		{Type: int64Type, Value: structpb.NewNullValue()},
+		{Type: stringType, Value: structpb.NewStringValue("x")},
+	}
+
+	sb := &strings.Builder{}
+	w := NewDeltaWriter(sb)
+	for _, diff := range []*RowDiff{
+		{Kind: DiffDeleted, Table: singers, From: diffTestRow("1", "a")},
+		{Kind: DiffInserted, Table: singers, To: diffTestRow("2", "b")},
+		{Kind: DiffChanged, Table: singers, From: diffTestRow("3", "c"), To: diffTestRow("3", nil), Changed: []int{1}},
+		{Kind: DiffDeleted, Table: albums, From: diffTestRow("1", "A")},
+		{Kind: DiffDeleted, Table: albums, From: nullKey},
+		{Kind: DiffInserted, Table: albums, To: diffTestRow("2", `B"`)},
+	} {
+		if err := w.WriteDiff(diff); err != nil {
+			t.Fatalf("WriteDiff() failed: %v", err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close() failed: %v", err)
+	}
+
+	want := "INSERT INTO `Singers` (`Id`, `Name`) VALUES (2, \"b\");\n" +
+		"UPDATE `Singers` SET `Name` = NULL WHERE `Id` = 3;\n" +
+		"INSERT INTO `Albums` (`Id`, `Title`) VALUES (2, \"B\\\"\");\n" +
+		"DELETE FROM `Albums` WHERE `Id` = 1;\n" +
+		"DELETE FROM `Albums` WHERE `Id` IS NULL;\n" +
+		"DELETE FROM `Singers` WHERE `Id` = 1;\n"
+	if got := sb.String(); got != want {
+		t.Errorf("DeltaWriter: got = %s, want = %s", got, want)
+	}
+}
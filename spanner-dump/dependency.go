@@ -0,0 +1,87 @@
+package spanner_dump
+
+import (
+	"context"
+	"fmt"
+	"sort"
+)
+
+// sortTables sorts the tables to dump in the dependency order of the database.
+func (d *Dumper) sortTables(ctx context.Context) ([]string, error) {
+	txn := d.client.ReadOnlyTransaction()
+	defer txn.Close()
+	refs, err := fetchColumnReferences(ctx, txn, d.dataQueryOptions())
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch dependencies of tables: %v", err)
+	}
+	return sortTablesByDependency(d.tables, refs)
+}
+
+// sortTablesByDependency sorts tables so that each table follows tables it references by interleaving and foreign keys,
+// keeping the order of tables without dependencies on each other. References to the table itself are ignored.
+func sortTablesByDependency(tables []string, refs []columnReference) ([]string, error) {
+	dependencies := map[string]map[string]bool{}
+	for _, ref := range refs {
+		if ref.from.table == ref.to.table {
+			continue
+		}
+		if dependencies[ref.from.table] == nil {
+			dependencies[ref.from.table] = map[string]bool{}
+		}
+		dependencies[ref.from.table][ref.to.table] = true
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := map[string]int{}
+	included := map[string]bool{}
+	for _, table := range tables {
+		included[table] = true
+	}
+	var sorted []string
+	var visit func(table string) error
+	visit = func(table string) error {
+		switch state[table] {
+		case visiting:
+			return fmt.Errorf("cyclic dependency detected in tables: %s", table)
+		case visited:
+			return nil
+		}
+		state[table] = visiting
+		// Tables which are not dumped are traversed to find dependencies through them.
+		for _, dependency := range tables {
+			if dependencies[table][dependency] {
+				if err := visit(dependency); err != nil {
+					return err
+				}
+			}
+		}
+		// Other tables are traversed in the order of names so that the result does not depend on the iteration order of the map.
+		var others []string
+		for dependency := range dependencies[table] {
+			if !included[dependency] {
+				others = append(others, dependency)
+			}
+		}
+		sort.Strings(others)
+		for _, dependency := range others {
+			if err := visit(dependency); err != nil {
+				return err
+			}
+		}
+		state[table] = visited
+		if included[table] {
+			sorted = append(sorted, table)
+		}
+		return nil
+	}
+	for _, table := range tables {
+		if err := visit(table); err != nil {
+			return nil, err
+		}
+	}
+	return sorted, nil
+}
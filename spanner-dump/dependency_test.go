@@ -0,0 +1,82 @@
+package spanner_dump
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSortTablesByDependency(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		tables  []string
+		refs    []columnReference
+		want    []string
+		wantErr bool
+	}{
+		{
+			name:   "no dependencies",
+			tables: []string{"B", "A", "C"},
+			want:   []string{"B", "A", "C"},
+		},
+		{
+			name:   "interleaving and foreign keys",
+			tables: []string{"Order", "Item", "User"},
+			refs:   pseudonymTestRefs,
+			want:   []string{"User", "Item", "Order"},
+		},
+		{
+			name:   "through tables not dumped",
+			tables: []string{"C", "A"},
+			refs: []columnReference{
+				{from: tableColumn{"C", "BId"}, to: tableColumn{"B", "Id"}},
+				{from: tableColumn{"B", "AId"}, to: tableColumn{"A", "Id"}},
+			},
+			want: []string{"A", "C"},
+		},
+		{
+			name:   "through multiple tables not dumped",
+			tables: []string{"C", "A", "B"},
+			refs: []columnReference{
+				{from: tableColumn{"C", "YId"}, to: tableColumn{"Y", "Id"}},
+				{from: tableColumn{"C", "XId"}, to: tableColumn{"X", "Id"}},
+				{from: tableColumn{"X", "BId"}, to: tableColumn{"B", "Id"}},
+				{from: tableColumn{"Y", "AId"}, to: tableColumn{"A", "Id"}},
+			},
+			want: []string{"B", "A", "C"},
+		},
+		{
+			name:   "self reference",
+			tables: []string{"Node", "Tree"},
+			refs: []columnReference{
+				{from: tableColumn{"Node", "ParentId"}, to: tableColumn{"Node", "Id"}},
+				{from: tableColumn{"Node", "TreeId"}, to: tableColumn{"Tree", "Id"}},
+			},
+			want: []string{"Tree", "Node"},
+		},
+		{
+			name:   "cycle",
+			tables: []string{"A", "B"},
+			refs: []columnReference{
+				{from: tableColumn{"A", "BId"}, to: tableColumn{"B", "Id"}},
+				{from: tableColumn{"B", "AId"}, to: tableColumn{"A", "Id"}},
+			},
+			wantErr: true,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := sortTablesByDependency(tt.tables, tt.refs)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("sortTablesByDependency() = %v, want error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("sortTablesByDependency() failed: %v", err)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("sortTablesByDependency() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,685 @@
+package spanner_dump
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"sort"
+	"strings"
+
+	"cloud.google.com/go/spanner"
+	"google.golang.org/api/iterator"
+	pb "google.golang.org/genproto/googleapis/spanner/v1"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// DiffKind is a kind of a difference of a record between two snapshots.
+type DiffKind string
+
+const (
+	// DiffInserted is a record which exists only in the new snapshot.
+	DiffInserted DiffKind = "inserted"
+	// DiffDeleted is a record which exists only in the old snapshot.
+	DiffDeleted DiffKind = "deleted"
+	// DiffChanged is a record which exists in both snapshots with different values.
+	DiffChanged DiffKind = "changed"
+)
+
+// RowDiff is a difference of a record between two snapshots, which are aligned by the primary key.
+type RowDiff struct {
+	Kind DiffKind
+	// Table has the columns compared in both snapshots.
+	Table *Table
+	// From and To are values of Table.Columns in the old and new snapshots,
+	// where From is nil for DiffInserted and To is nil for DiffDeleted.
+	From, To []spanner.GenericColumnValue
+	// Changed are indexes of Table.Columns whose values differ, which are set only for DiffChanged.
+	Changed []int
+}
+
+// Key returns values of the primary key columns of the record.
+func (d *RowDiff) Key() []spanner.GenericColumnValue {
+	values := d.To
+	if values == nil {
+		values = d.From
+	}
+	var key []spanner.GenericColumnValue
+	for _, k := range d.Table.PrimaryKey {
+		key = append(key, values[indexOfString(d.Table.Columns, k.Name)])
+	}
+	return key
+}
+
+// TableDiffStats counts records of a table by kinds of differences.
+type TableDiffStats struct {
+	Table     string `json:"table"`
+	Inserted  int    `json:"inserted"`
+	Deleted   int    `json:"deleted"`
+	Changed   int    `json:"changed"`
+	Unchanged int    `json:"unchanged"`
+}
+
+// DiffOption configures Diff and DiffDump.
+type DiffOption func(*diffConfig)
+
+type diffConfig struct {
+	ddl           io.Reader
+	strictColumns bool
+}
+
+// WithStrictColumns makes Diff and DiffDump fail before comparing any records if some columns of the new state are not compared,
+// e.g. columns added or whose types are changed after the old state, which DeltaWriter requires to write INSERT statements of all columns.
+func WithStrictColumns() DiffOption {
+	return func(c *diffConfig) {
+		c.strictColumns = true
+	}
+}
+
+// WithDumpDDL specifies DDL statements of the tables in the dump compared by DiffDump,
+// which is required if the dump does not contain CREATE TABLE statements.
+func WithDumpDDL(ddl io.Reader) DiffOption {
+	return func(c *diffConfig) {
+		c.ddl = ddl
+	}
+}
+
+// Diff compares records of the tables to dump by from with records of the tables of the same names by to,
+// e.g. the same database at two read bounds or two databases, and calls fn with each difference in the primary key order of each table.
+// Tables are compared in the order of the tables of to, e.g. the dependency order with WithSort.
+// Records are filtered by the conditions of each dumper, and only columns existing in both tables with the same type are compared.
+// Records of both tables are read by queries ordered by the primary key and merged while they are streamed,
+// so that large tables are compared without holding records in memory.
+// Diff stops and returns the error if fn returns an error, otherwise it returns the statistics of the compared tables.
+func Diff(ctx context.Context, from, to *Dumper, fn func(diff *RowDiff) error, opts ...DiffOption) ([]TableDiffStats, error) {
+	if err := from.beginSnapshot(ctx); err != nil {
+		return nil, err
+	}
+	if err := to.beginSnapshot(ctx); err != nil {
+		return nil, err
+	}
+	return diffTables(ctx, snapshotSource{from}, snapshotSource{to}, newDiffConfig(opts), fn)
+}
+
+// DiffDump compares records in a dump produced by Dumper, which is the old state, with records of the tables to dump by to,
+// and calls fn with each difference as Diff does. The dump must contain CREATE TABLE statements of the compared tables unless WithDumpDDL is specified.
+// Records of the compared tables in the dump are held in memory and sorted in the primary key order, while records of to are streamed.
+// Since records in the dump cannot be filtered by the conditions of to, the conditions must be the same as the filters recorded in the header of the dump,
+// and must be TRUE if the dump has no header, otherwise records out of the conditions would be regarded as deleted.
+func DiffDump(ctx context.Context, dump io.Reader, to *Dumper, fn func(diff *RowDiff) error, opts ...DiffOption) ([]TableDiffStats, error) {
+	config := newDiffConfig(opts)
+	if err := to.beginSnapshot(ctx); err != nil {
+		return nil, err
+	}
+	filters, dump, err := readHeaderFilters(dump)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read dump: %v", err)
+	}
+	var names []string
+	for _, table := range to.snapshotTables {
+		if err := checkDumpFilter(filters, table.Name, to.query[table.Name]); err != nil {
+			return nil, err
+		}
+		names = append(names, table.Name)
+	}
+	if config.ddl != nil {
+		// The separator terminates the last statement of the DDL even without a trailing semicolon.
+		dump = io.MultiReader(config.ddl, strings.NewReader("\n;\n"), dump)
+	}
+	from, err := readDumpSource(dump, names)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read dump: %v", err)
+	}
+	return diffTables(ctx, from, snapshotSource{to}, config, fn)
+}
+
+func newDiffConfig(opts []DiffOption) *diffConfig {
+	c := &diffConfig{}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// checkDumpFilter checks that records of the table in the dump are selected by the condition,
+// where filters are ones in the header of the dump or nil if the dump has no header.
+func checkDumpFilter(filters map[string]string, table, condition string) error {
+	condition = strings.Join(strings.Fields(condition), " ")
+	if condition == "" {
+		condition = "TRUE"
+	}
+	if filters == nil {
+		if condition != "TRUE" {
+			return fmt.Errorf("filter of table %s in the dump is unknown since the dump has no header, so the condition must be TRUE: %s", table, condition)
+		}
+		return nil
+	}
+	filter, ok := filters[table]
+	if !ok {
+		return fmt.Errorf("table %s is not found in the header of the dump", table)
+	}
+	if filter != condition {
+		return fmt.Errorf("filter of table %s in the dump differs from the condition: %s and %s", table, filter, condition)
+	}
+	return nil
+}
+
+// diffSource provides tables and their records to compare.
+type diffSource interface {
+	// tables returns the tables to compare.
+	tables() []*Table
+	// check checks that records of the columns of the table can be read.
+	check(table *Table) error
+	// rows returns records of the columns of the table in the primary key order, and a function to release resources.
+	rows(ctx context.Context, table *Table) (rowSource, func(), error)
+}
+
+// diffTables compares tables of the same names in the order of the tables of to.
+// Columns of all tables are resolved before comparing records, so that incompatible schemas are reported before any differences.
+func diffTables(ctx context.Context, from, to diffSource, config *diffConfig, fn func(diff *RowDiff) error) ([]TableDiffStats, error) {
+	fromTables := map[string]*Table{}
+	for _, table := range from.tables() {
+		fromTables[table.Name] = table
+	}
+
+	var tables []*Table
+	for _, toTable := range to.tables() {
+		fromTable, ok := fromTables[toTable.Name]
+		if !ok {
+			return nil, fmt.Errorf("table %s is not found in the old state", toTable.Name)
+		}
+		table, err := commonTable(fromTable, toTable)
+		if err != nil {
+			return nil, err
+		}
+		if config.strictColumns && len(table.Columns) < len(toTable.Columns) {
+			var columns []string
+			for _, column := range toTable.Columns {
+				if indexOfString(table.Columns, column) < 0 {
+					columns = append(columns, column)
+				}
+			}
+			return nil, fmt.Errorf("columns of table %s in the new state cannot be compared with the old state: %s", table.Name, strings.Join(columns, ", "))
+		}
+		if err := from.check(table); err != nil {
+			return nil, fmt.Errorf("failed to compare table %s: %v", table.Name, err)
+		}
+		if err := to.check(table); err != nil {
+			return nil, fmt.Errorf("failed to compare table %s: %v", table.Name, err)
+		}
+		tables = append(tables, table)
+	}
+
+	var stats []TableDiffStats
+	for _, table := range tables {
+		s := TableDiffStats{Table: table.Name}
+		if err := diffTable(ctx, table, from, to, &s, fn); err != nil {
+			return nil, fmt.Errorf("failed to compare table %s: %v", table.Name, err)
+		}
+		stats = append(stats, s)
+	}
+	return stats, nil
+}
+
+// commonTable returns the table with the columns existing in both tables with the same type, where empty types are regarded as the same.
+// Both tables must have the same primary key, all of whose columns are compared.
+func commonTable(from, to *Table) (*Table, error) {
+	if keyColumnList(from.PrimaryKey) != keyColumnList(to.PrimaryKey) {
+		return nil, fmt.Errorf("primary keys of table %s differ: (%s) and (%s)", from.Name, keyColumnList(from.PrimaryKey), keyColumnList(to.PrimaryKey))
+	}
+	table := &Table{Name: from.Name, PrimaryKey: from.PrimaryKey}
+	for i, column := range from.Columns {
+		j := indexOfString(to.Columns, column)
+		switch {
+		case j < 0:
+			log.Printf("Column %s.%s is not compared since it exists only in the old state", from.Name, column)
+		case columnTypeOf(from, i) != "" && columnTypeOf(to, j) != "" && columnTypeOf(from, i) != columnTypeOf(to, j):
+			log.Printf("Column %s.%s is not compared since its types differ: %s and %s", from.Name, column, columnTypeOf(from, i), columnTypeOf(to, j))
+		default:
+			table.Columns = append(table.Columns, column)
+			typ := columnTypeOf(from, i)
+			if typ == "" {
+				typ = columnTypeOf(to, j)
+			}
+			table.ColumnTypes = append(table.ColumnTypes, typ)
+		}
+	}
+	for _, column := range to.Columns {
+		if indexOfString(from.Columns, column) < 0 {
+			log.Printf("Column %s.%s is not compared since it exists only in the new state", to.Name, column)
+		}
+	}
+	if !table.hasDumpedPrimaryKey() {
+		return nil, fmt.Errorf("table %s cannot be compared since not all of its primary key columns are compared", from.Name)
+	}
+	return table, nil
+}
+
+func columnTypeOf(table *Table, i int) string {
+	if i < len(table.ColumnTypes) {
+		return table.ColumnTypes[i]
+	}
+	return ""
+}
+
+// diffTable reads records of the table in the primary key order from both sources and compares them.
+func diffTable(ctx context.Context, table *Table, from, to diffSource, stats *TableDiffStats, fn func(diff *RowDiff) error) error {
+	fromRows, stopFrom, err := from.rows(ctx, table)
+	if err != nil {
+		return err
+	}
+	defer stopFrom()
+	toRows, stopTo, err := to.rows(ctx, table)
+	if err != nil {
+		return err
+	}
+	defer stopTo()
+	return diffRows(table, fromRows, toRows, stats, fn)
+}
+
+// snapshotSource is a diffSource reading the tables to dump by the dumper at the read timestamp of its snapshot.
+type snapshotSource struct {
+	d *Dumper
+}
+
+func (s snapshotSource) tables() []*Table {
+	return s.d.snapshotTables
+}
+
+func (s snapshotSource) check(table *Table) error {
+	return nil
+}
+
+func (s snapshotSource) rows(ctx context.Context, table *Table) (rowSource, func(), error) {
+	queryCondition := s.d.query[table.Name]
+	if queryCondition == "" {
+		queryCondition = "TRUE"
+	}
+	sql := fmt.Sprintf("SELECT %s FROM `%s` WHERE (%s) %s", table.quotedColumnList(), table.Name, queryCondition, orderByPrimaryKey(table.PrimaryKey))
+	iter := s.d.snapshot.QueryWithOptions(ctx, spanner.NewStatement(sql), s.d.dataQueryOptions())
+	return iteratorRows(iter), iter.Stop, nil
+}
+
+// dumpSource is a diffSource of records in a dump, which are sorted in the primary key order.
+type dumpSource struct {
+	tableList   []*Table
+	columnTypes map[string][]*pb.Type
+	records     map[string][][]spanner.GenericColumnValue
+}
+
+// readDumpSource reads records of the tables of the names in the dump.
+func readDumpSource(dump io.Reader, names []string) (*dumpSource, error) {
+	s := &dumpSource{columnTypes: map[string][]*pb.Type{}, records: map[string][][]spanner.GenericColumnValue{}}
+	tables := map[string]*Table{}
+	err := ReadDump(dump, func(table *Table, columnTypes []*pb.Type) error {
+		if indexOfString(names, table.Name) >= 0 {
+			tables[table.Name], s.columnTypes[table.Name] = table, columnTypes
+		}
+		return nil
+	}, func(table *Table, row Row) error {
+		t, ok := tables[table.Name]
+		if !ok {
+			return nil
+		}
+		// Columns omitted in the INSERT statement are NULL.
+		values := make([]spanner.GenericColumnValue, len(t.Columns))
+		for i, column := range t.Columns {
+			values[i] = spanner.GenericColumnValue{Type: s.columnTypes[t.Name][i], Value: structpb.NewNullValue()}
+			if j := indexOfString(table.Columns, column); j >= 0 {
+				values[i] = row.values[j]
+			}
+		}
+		s.records[t.Name] = append(s.records[t.Name], values)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, name := range names {
+		table, ok := tables[name]
+		if !ok {
+			return nil, fmt.Errorf("CREATE TABLE statement of table %s is not found", name)
+		}
+		if !table.hasDumpedPrimaryKey() {
+			return nil, fmt.Errorf("table %s has no primary key columns in the dump", name)
+		}
+		s.tableList = append(s.tableList, table)
+		records := s.records[name]
+		sort.SliceStable(records, func(i, j int) bool { return compareKeys(table, records[i], records[j]) < 0 })
+		// Records of the same primary key are replaced by the later one as INSERT OR UPDATE does.
+		var deduped [][]spanner.GenericColumnValue
+		for _, record := range records {
+			if n := len(deduped); n > 0 && compareKeys(table, deduped[n-1], record) == 0 {
+				deduped[n-1] = record
+				continue
+			}
+			deduped = append(deduped, record)
+		}
+		s.records[name] = deduped
+	}
+	return s, nil
+}
+
+func (s *dumpSource) tables() []*Table {
+	return s.tableList
+}
+
+func (s *dumpSource) table(name string) *Table {
+	for _, t := range s.tableList {
+		if t.Name == name {
+			return t
+		}
+	}
+	return nil
+}
+
+func (s *dumpSource) check(table *Table) error {
+	dumpTable := s.table(table.Name)
+	for i, column := range table.Columns {
+		typ := columnTypeOf(table, i)
+		if typ == "" {
+			continue
+		}
+		want, err := parseSpannerType(typ)
+		if err == nil && !proto.Equal(want, s.columnTypes[table.Name][indexOfString(dumpTable.Columns, column)]) {
+			return fmt.Errorf("type of column %s differs between the dump and the database: %s", column, typ)
+		}
+	}
+	return nil
+}
+
+func (s *dumpSource) rows(ctx context.Context, table *Table) (rowSource, func(), error) {
+	dumpTable := s.table(table.Name)
+	var indexes []int
+	for _, column := range table.Columns {
+		indexes = append(indexes, indexOfString(dumpTable.Columns, column))
+	}
+
+	records := s.records[table.Name]
+	return func() ([]spanner.GenericColumnValue, error) {
+		if len(records) == 0 {
+			return nil, iterator.Done
+		}
+		values := make([]spanner.GenericColumnValue, len(indexes))
+		for i, j := range indexes {
+			values[i] = records[0][j]
+		}
+		records = records[1:]
+		return values, nil
+	}, func() {}, nil
+}
+
+// rowSource returns records one by one, and iterator.Done after the last record.
+type rowSource func() ([]spanner.GenericColumnValue, error)
+
+func iteratorRows(iter *spanner.RowIterator) rowSource {
+	return func() ([]spanner.GenericColumnValue, error) {
+		row, err := iter.Next()
+		if err != nil {
+			return nil, err
+		}
+		return rowValues(row)
+	}
+}
+
+// diffRows merges records of both sources, which are in the primary key order of the table, and calls fn with differences.
+func diffRows(table *Table, from, to rowSource, stats *TableDiffStats, fn func(diff *RowDiff) error) error {
+	next := func(source rowSource) ([]spanner.GenericColumnValue, error) {
+		values, err := source()
+		if err == iterator.Done {
+			return nil, nil
+		}
+		return values, err
+	}
+	fromValues, err := next(from)
+	if err != nil {
+		return err
+	}
+	toValues, err := next(to)
+	if err != nil {
+		return err
+	}
+	for fromValues != nil || toValues != nil {
+		c := 0
+		switch {
+		case fromValues == nil:
+			c = 1
+		case toValues == nil:
+			c = -1
+		default:
+			c = compareKeys(table, fromValues, toValues)
+		}
+
+		var diff *RowDiff
+		switch {
+		case c < 0:
+			diff = &RowDiff{Kind: DiffDeleted, Table: table, From: fromValues}
+			stats.Deleted++
+		case c > 0:
+			diff = &RowDiff{Kind: DiffInserted, Table: table, To: toValues}
+			stats.Inserted++
+		default:
+			var changed []int
+			for i := range table.Columns {
+				if CompareValues(fromValues[i].Type, fromValues[i].Value, toValues[i].Value) != 0 {
+					changed = append(changed, i)
+				}
+			}
+			if len(changed) > 0 {
+				diff = &RowDiff{Kind: DiffChanged, Table: table, From: fromValues, To: toValues, Changed: changed}
+				stats.Changed++
+			} else {
+				stats.Unchanged++
+			}
+		}
+		if diff != nil {
+			if err := fn(diff); err != nil {
+				return err
+			}
+		}
+
+		if c <= 0 {
+			if fromValues, err = next(from); err != nil {
+				return err
+			}
+		}
+		if c >= 0 {
+			if toValues, err = next(to); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// compareKeys compares primary keys of records of the table in the primary key order.
+func compareKeys(table *Table, a, b []spanner.GenericColumnValue) int {
+	for _, k := range table.PrimaryKey {
+		i := indexOfString(table.Columns, k.Name)
+		c := CompareValues(a[i].Type, a[i].Value, b[i].Value)
+		if k.Desc {
+			c = -c
+		}
+		if c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+// DiffFormat is a format of differences written by DiffWriter.
+type DiffFormat string
+
+const (
+	// DiffFormatText writes a line of each difference with values in SQL literals, such as
+	// "+ Singers (SingerId=1): Name="Alice"", "- Singers (SingerId=2): Name="Bob"", and "~ Singers (SingerId=3): Name: "Carol" -> "Caroline"",
+	// followed by a line of the statistics of each table.
+	DiffFormatText DiffFormat = "text"
+	// DiffFormatJSON writes a JSON object of each difference line by line, such as
+	// {"type":"changed","table":"Singers","key":{"SingerId":"3"},"from":{"Name":"Carol"},"to":{"Name":"Caroline"}},
+	// where from and to have the changed columns of a changed record and all columns of a deleted or an inserted record,
+	// followed by a JSON object of the statistics of each table with "type":"summary".
+	// Values are in the JSON encoding of Cloud Spanner API as JSONLEncoder.
+	DiffFormatJSON DiffFormat = "json"
+)
+
+// DiffWriter writes differences of records in a DiffFormat.
+type DiffWriter struct {
+	out    io.Writer
+	format DiffFormat
+}
+
+// NewDiffWriter creates DiffWriter writing differences to out in the format.
+func NewDiffWriter(out io.Writer, format DiffFormat) (*DiffWriter, error) {
+	switch format {
+	case DiffFormatText, DiffFormatJSON:
+		return &DiffWriter{out: out, format: format}, nil
+	default:
+		return nil, fmt.Errorf("unsupported diff format: %s", format)
+	}
+}
+
+// WriteDiff writes a difference of a record, which can be passed to Diff as fn.
+func (w *DiffWriter) WriteDiff(diff *RowDiff) error {
+	var line string
+	var err error
+	if w.format == DiffFormatJSON {
+		line = diffJSON(diff)
+	} else {
+		line, err = diffText(diff)
+		if err != nil {
+			return fmt.Errorf("failed to format difference of table %s: %v", diff.Table.Name, err)
+		}
+	}
+	_, err = io.WriteString(w.out, line+"\n")
+	return err
+}
+
+// WriteStats writes the statistics of tables returned by Diff.
+func (w *DiffWriter) WriteStats(stats []TableDiffStats) error {
+	sb := &strings.Builder{}
+	for _, s := range stats {
+		if w.format == DiffFormatJSON {
+			sb.WriteString(`{"type":"summary","table":`)
+			writeJSONString(sb, s.Table)
+			fmt.Fprintf(sb, `,"inserted":%d,"deleted":%d,"changed":%d,"unchanged":%d}`+"\n", s.Inserted, s.Deleted, s.Changed, s.Unchanged)
+		} else {
+			fmt.Fprintf(sb, "%s: %d inserted, %d deleted, %d changed, %d unchanged\n", s.Table, s.Inserted, s.Deleted, s.Changed, s.Unchanged)
+		}
+	}
+	_, err := io.WriteString(w.out, sb.String())
+	return err
+}
+
+func diffText(diff *RowDiff) (string, error) {
+	sb := &strings.Builder{}
+	switch diff.Kind {
+	case DiffInserted:
+		sb.WriteString("+ ")
+	case DiffDeleted:
+		sb.WriteString("- ")
+	default:
+		sb.WriteString("~ ")
+	}
+
+	var key []string
+	for i, v := range diff.Key() {
+		s, err := DecodeColumn(v)
+		if err != nil {
+			return "", err
+		}
+		key = append(key, diff.Table.PrimaryKey[i].Name+"="+s)
+	}
+	fmt.Fprintf(sb, "%s (%s):", diff.Table.Name, strings.Join(key, ", "))
+
+	var columns []string
+	switch diff.Kind {
+	case DiffChanged:
+		for _, i := range diff.Changed {
+			from, err := DecodeColumn(diff.From[i])
+			if err != nil {
+				return "", err
+			}
+			to, err := DecodeColumn(diff.To[i])
+			if err != nil {
+				return "", err
+			}
+			columns = append(columns, fmt.Sprintf("%s: %s -> %s", diff.Table.Columns[i], from, to))
+		}
+	default:
+		values := diff.To
+		if values == nil {
+			values = diff.From
+		}
+		for i, column := range diff.Table.Columns {
+			if indexOfKeyColumn(diff.Table.PrimaryKey, column) >= 0 {
+				continue
+			}
+			s, err := DecodeColumn(values[i])
+			if err != nil {
+				return "", err
+			}
+			columns = append(columns, column+"="+s)
+		}
+	}
+	if len(columns) > 0 {
+		sb.WriteString(" " + strings.Join(columns, ", "))
+	}
+	return sb.String(), nil
+}
+
+func diffJSON(diff *RowDiff) string {
+	sb := &strings.Builder{}
+	sb.WriteString(`{"type":`)
+	writeJSONString(sb, string(diff.Kind))
+	sb.WriteString(`,"table":`)
+	writeJSONString(sb, diff.Table.Name)
+	sb.WriteString(`,"key":{`)
+	for i, v := range diff.Key() {
+		if i > 0 {
+			sb.WriteString(",")
+		}
+		writeJSONString(sb, diff.Table.PrimaryKey[i].Name)
+		sb.WriteString(":")
+		writeValueJSON(sb, v.Value)
+	}
+	sb.WriteString("}")
+
+	columns := diff.Changed
+	if diff.Kind != DiffChanged {
+		columns = nil
+		for i := range diff.Table.Columns {
+			columns = append(columns, i)
+		}
+	}
+	writeObject := func(name string, values []spanner.GenericColumnValue) {
+		if values == nil {
+			return
+		}
+		sb.WriteString(`,"` + name + `":{`)
+		for n, i := range columns {
+			if n > 0 {
+				sb.WriteString(",")
+			}
+			writeJSONString(sb, diff.Table.Columns[i])
+			sb.WriteString(":")
+			writeValueJSON(sb, values[i].Value)
+		}
+		sb.WriteString("}")
+	}
+	writeObject("from", diff.From)
+	writeObject("to", diff.To)
+	sb.WriteString("}")
+	return sb.String()
+}
+
+func indexOfKeyColumn(primaryKey []KeyColumn, column string) int {
+	for i, k := range primaryKey {
+		if k.Name == column {
+			return i
+		}
+	}
+	return -1
+}
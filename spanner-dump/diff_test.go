@@ -0,0 +1,309 @@
+package spanner_dump
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"cloud.google.com/go/spanner"
+	"google.golang.org/api/iterator"
+	pb "google.golang.org/genproto/googleapis/spanner/v1"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+var (
+	int64Type  = &pb.Type{Code: pb.TypeCode_INT64}
+	stringType = &pb.Type{Code: pb.TypeCode_STRING}
+)
+
+// diffTestRow builds a record of (INT64, STRING) columns, where nil is NULL.
+func diffTestRow(id string, name any) []spanner.GenericColumnValue {
+	v := structpb.NewNullValue()
+	if s, ok := name.(string); ok {
+		v = structpb.NewStringValue(s)
+	}
+	return []spanner.GenericColumnValue{
+		{Type: int64Type, Value: structpb.NewStringValue(id)},
+		{Type: stringType, Value: v},
+	}
+}
+
+func sliceRows(rows ...[]spanner.GenericColumnValue) rowSource {
+	return func() ([]spanner.GenericColumnValue, error) {
+		if len(rows) == 0 {
+			return nil, iterator.Done
+		}
+		row := rows[0]
+		rows = rows[1:]
+		return row, nil
+	}
+}
+
+func TestDiffRows(t *testing.T) {
+	for _, tt := range []struct {
+		desc      string
+		descKey   bool
+		from, to  [][]spanner.GenericColumnValue
+		want      []string
+		wantStats TableDiffStats
+	}{
+		{
+			desc:      "Empty",
+			wantStats: TableDiffStats{Table: "T"},
+		},
+		{
+			desc:      "Inserted, deleted and changed",
+			from:      [][]spanner.GenericColumnValue{diffTestRow("1", "a"), diffTestRow("2", "b"), diffTestRow("3", "c"), diffTestRow("5", nil)},
+			to:        [][]spanner.GenericColumnValue{diffTestRow("1", "a"), diffTestRow("3", "C"), diffTestRow("4", "d"), diffTestRow("5", "e")},
+			want:      []string{"deleted:2", "changed:3[1]", "inserted:4", "changed:5[1]"},
+			wantStats: TableDiffStats{Table: "T", Inserted: 1, Deleted: 1, Changed: 2, Unchanged: 1},
+		},
+		{
+			desc:      "Only in one side",
+			from:      [][]spanner.GenericColumnValue{diffTestRow("8", "a"), diffTestRow("9", "b")},
+			to:        [][]spanner.GenericColumnValue{diffTestRow("1", "a"), diffTestRow("10", "b")},
+			want:      []string{"inserted:1", "deleted:8", "deleted:9", "inserted:10"},
+			wantStats: TableDiffStats{Table: "T", Inserted: 2, Deleted: 2},
+		},
+		{
+			desc:      "Descending key",
+			descKey:   true,
+			from:      [][]spanner.GenericColumnValue{diffTestRow("10", "a"), diffTestRow("9", "b")},
+			to:        [][]spanner.GenericColumnValue{diffTestRow("10", "a"), diffTestRow("8", "c")},
+			want:      []string{"deleted:9", "inserted:8"},
+			wantStats: TableDiffStats{Table: "T", Inserted: 1, Deleted: 1, Unchanged: 1},
+		},
+	} {
+		t.Run(tt.desc, func(t *testing.T) {
+			table := &Table{Name: "T", Columns: []string{"Id", "Name"}, PrimaryKey: []KeyColumn{{Name: "Id", Desc: tt.descKey}}}
+			var got []string
+			stats := TableDiffStats{Table: "T"}
+			err := diffRows(table, sliceRows(tt.from...), sliceRows(tt.to...), &stats, func(diff *RowDiff) error {
+				s := string(diff.Kind) + ":" + diff.Key()[0].Value.GetStringValue()
+				if diff.Changed != nil {
+					s += fmt.Sprint(diff.Changed)
+				}
+				got = append(got, s)
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("diffRows() failed: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("diffRows(): got = %q, want = %q", got, tt.want)
+			}
+			if stats != tt.wantStats {
+				t.Errorf("diffRows(): stats = %+v, want = %+v", stats, tt.wantStats)
+			}
+		})
+	}
+}
+
+func TestDiffWriter(t *testing.T) {
+	table := &Table{Name: "T", Columns: []string{"Id", "Name"}, PrimaryKey: []KeyColumn{{Name: "Id"}}}
+	diffs := []*RowDiff{
+		{Kind: DiffInserted, Table: table, To: diffTestRow("1", "a")},
+		{Kind: DiffDeleted, Table: table, From: diffTestRow("2", nil)},
+		{Kind: DiffChanged, Table: table, From: diffTestRow("3", "c"), To: diffTestRow("3", `C"`), Changed: []int{1}},
+	}
+	stats := []TableDiffStats{{Table: "T", Inserted: 1, Deleted: 1, Changed: 1, Unchanged: 2}}
+
+	for _, tt := range []struct {
+		format DiffFormat
+		want   string
+	}{
+		{
+			format: DiffFormatText,
+			want: `+ T (Id=1): Name="a"
+- T (Id=2): Name=NULL
+~ T (Id=3): Name: "c" -> "C\""
+T: 1 inserted, 1 deleted, 1 changed, 2 unchanged
+`,
+		},
+		{
+			format: DiffFormatJSON,
+			want: `{"type":"inserted","table":"T","key":{"Id":"1"},"to":{"Id":"1","Name":"a"}}
+{"type":"deleted","table":"T","key":{"Id":"2"},"from":{"Id":"2","Name":null}}
+{"type":"changed","table":"T","key":{"Id":"3"},"from":{"Name":"c"},"to":{"Name":"C\""}}
+{"type":"summary","table":"T","inserted":1,"deleted":1,"changed":1,"unchanged":2}
+`,
+		},
+	} {
+		t.Run(string(tt.format), func(t *testing.T) {
+			sb := &strings.Builder{}
+			w, err := NewDiffWriter(sb, tt.format)
+			if err != nil {
+				t.Fatalf("NewDiffWriter() failed: %v", err)
+			}
+			for _, diff := range diffs {
+				if err := w.WriteDiff(diff); err != nil {
+					t.Fatalf("WriteDiff() failed: %v", err)
+				}
+			}
+			if err := w.WriteStats(stats); err != nil {
+				t.Fatalf("WriteStats() failed: %v", err)
+			}
+			if got := sb.String(); got != tt.want {
+				t.Errorf("DiffWriter: got = %s, want = %s", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := NewDiffWriter(&strings.Builder{}, "xml"); err == nil {
+		t.Errorf("NewDiffWriter() with unsupported format: got no error")
+	}
+}
+
+func TestCommonTable(t *testing.T) {
+	from := &Table{Name: "T", Columns: []string{"Id", "A", "B"}, ColumnTypes: []string{"INT64", "STRING(MAX)", "INT64"}, PrimaryKey: []KeyColumn{{Name: "Id"}}}
+	to := &Table{Name: "T", Columns: []string{"Id", "B", "C"}, ColumnTypes: []string{"INT64", "INT64", "BOOL"}, PrimaryKey: []KeyColumn{{Name: "Id"}}}
+	got, err := commonTable(from, to)
+	if err != nil {
+		t.Fatalf("commonTable() failed: %v", err)
+	}
+	if want := []string{"Id", "B"}; !reflect.DeepEqual(got.Columns, want) {
+		t.Errorf("commonTable(): columns = %v, want = %v", got.Columns, want)
+	}
+
+	to.ColumnTypes[0] = "STRING(MAX)"
+	if _, err := commonTable(from, to); err == nil {
+		t.Errorf("commonTable() with a primary key column of different types: got no error")
+	}
+	to.PrimaryKey = []KeyColumn{{Name: "Id", Desc: true}}
+	if _, err := commonTable(from, to); err == nil {
+		t.Errorf("commonTable() with different primary keys: got no error")
+	}
+}
+
+func TestDiffDumpSource(t *testing.T) {
+	dump := "CREATE TABLE T (\n" +
+		"  Id INT64 NOT NULL,\n" +
+		"  Name STRING(MAX),\n" +
+		"  Score FLOAT64,\n" +
+		") PRIMARY KEY (Id DESC);\n" +
+		"CREATE TABLE Other (Id INT64 NOT NULL) PRIMARY KEY (Id);\n" +
+		"INSERT INTO `T` (`Id`, `Name`, `Score`) VALUES (1, \"a\", 1.5), (3, \"c\", NULL);\n" +
+		"INSERT INTO `Other` (`Id`) VALUES (1);\n" +
+		"INSERT OR UPDATE INTO `T` (`Id`, `Score`) VALUES (2, 2.5), (1, 0.5);\n"
+	s, err := readDumpSource(strings.NewReader(dump), []string{"T"})
+	if err != nil {
+		t.Fatalf("readDumpSource() failed: %v", err)
+	}
+	if len(s.tables()) != 1 || s.tables()[0].Name != "T" {
+		t.Fatalf("readDumpSource(): tables = %v, want = [T]", s.tables())
+	}
+
+	table := &Table{Name: "T", Columns: []string{"Id", "Score"}, ColumnTypes: []string{"INT64", "FLOAT64"}, PrimaryKey: []KeyColumn{{Name: "Id", Desc: true}}}
+	rows, stop, err := s.rows(context.Background(), table)
+	if err != nil {
+		t.Fatalf("rows() failed: %v", err)
+	}
+	defer stop()
+	var got []string
+	for {
+		values, err := rows()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			t.Fatalf("rows() failed: %v", err)
+		}
+		decoded, err := decodeValues(values)
+		if err != nil {
+			t.Fatalf("decodeValues() failed: %v", err)
+		}
+		got = append(got, strings.Join(decoded, ","))
+	}
+	if want := []string{"3,NULL", "2,2.5", "1,0.5"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("rows(): got = %q, want = %q", got, want)
+	}
+
+	if err := s.check(table); err != nil {
+		t.Errorf("check() failed: %v", err)
+	}
+	table.ColumnTypes[1] = "STRING(MAX)"
+	if err := s.check(table); err == nil {
+		t.Errorf("check() with a column of a different type: got no error")
+	}
+	if _, err := readDumpSource(strings.NewReader(dump), []string{"Unknown"}); err == nil {
+		t.Errorf("readDumpSource() with an unknown table: got no error")
+	}
+}
+
+func TestCheckDumpFilter(t *testing.T) {
+	filters := map[string]string{"Users": "Id > 10 AND Name IS NOT NULL", "Items": "TRUE"}
+	for _, tt := range []struct {
+		desc      string
+		filters   map[string]string
+		table     string
+		condition string
+		wantErr   bool
+	}{
+		{desc: "Same filter", filters: filters, table: "Users", condition: "Id > 10\n  AND Name IS NOT NULL"},
+		{desc: "Without condition", filters: filters, table: "Items"},
+		{desc: "Subset of the dump", filters: filters, table: "Items", condition: "Id > 10", wantErr: true},
+		{desc: "Superset of the dump", filters: filters, table: "Users", condition: "TRUE", wantErr: true},
+		{desc: "Table not in the dump", filters: filters, table: "Tags", condition: "TRUE", wantErr: true},
+		{desc: "No header", table: "Users", condition: "TRUE"},
+		{desc: "Condition without header", table: "Users", condition: "Id > 10", wantErr: true},
+	} {
+		t.Run(tt.desc, func(t *testing.T) {
+			err := checkDumpFilter(tt.filters, tt.table, tt.condition)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("checkDumpFilter(): err = %v, wantErr = %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// sliceSource is a diffSource of records in memory.
+type sliceSource struct {
+	tableList []*Table
+	records   map[string][][]spanner.GenericColumnValue
+}
+
+func (s *sliceSource) tables() []*Table {
+	return s.tableList
+}
+
+func (s *sliceSource) check(table *Table) error {
+	return nil
+}
+
+func (s *sliceSource) rows(ctx context.Context, table *Table) (rowSource, func(), error) {
+	return sliceRows(s.records[table.Name]...), func() {}, nil
+}
+
+func TestDiffTables_strictColumns(t *testing.T) {
+	t1 := &Table{Name: "T1", Columns: []string{"Id", "Name"}, ColumnTypes: []string{"INT64", "STRING(MAX)"}, PrimaryKey: []KeyColumn{{Name: "Id"}}}
+	t2Old := &Table{Name: "T2", Columns: []string{"Id"}, ColumnTypes: []string{"INT64"}, PrimaryKey: []KeyColumn{{Name: "Id"}}}
+	t2New := &Table{Name: "T2", Columns: []string{"Id", "Name"}, ColumnTypes: []string{"INT64", "STRING(MAX)"}, PrimaryKey: []KeyColumn{{Name: "Id"}}}
+	from := &sliceSource{tableList: []*Table{t1, t2Old}}
+	to := &sliceSource{
+		tableList: []*Table{t1, t2New},
+		records:   map[string][][]spanner.GenericColumnValue{"T1": {diffTestRow("1", "a")}, "T2": {diffTestRow("1", "b")}},
+	}
+
+	var got []string
+	fn := func(diff *RowDiff) error {
+		got = append(got, diff.Table.Name+":"+fmt.Sprint(diff.Table.Columns))
+		return nil
+	}
+	if _, err := diffTables(context.Background(), from, to, &diffConfig{}, fn); err != nil {
+		t.Fatalf("diffTables() failed: %v", err)
+	}
+	if want := []string{"T1:[Id Name]", "T2:[Id]"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("diffTables(): got = %q, want = %q", got, want)
+	}
+
+	// No differences are reported before the added column is detected.
+	got = nil
+	if _, err := diffTables(context.Background(), from, to, &diffConfig{strictColumns: true}, fn); err == nil {
+		t.Errorf("diffTables() with an added column: got no error")
+	}
+	if len(got) > 0 {
+		t.Errorf("diffTables() with an added column: got differences %q", got)
+	}
+}
@@ -22,22 +22,19 @@ import (
 	"errors"
 	"fmt"
 	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
 	"io"
+	"log"
+	"os"
 	"regexp"
 	"strings"
 	"time"
 
 	adminapi "cloud.google.com/go/spanner/admin/database/apiv1"
 	adminpb "cloud.google.com/go/spanner/admin/database/apiv1/databasepb"
-
-	schenerate_spanner "github.com/Jumpaku/schenerate/spanner"
+	"google.golang.org/api/option"
 )
 
-// This is an ad hoc value, but considering mutations limit (20,000),
-// 100 rows/statement would be safe in most cases.
-// https://cloud.google.com/spanner/quotas#limits_for_creating_reading_updating_and_deleting_data
-const defaultBulkSize = 100
-
 // Dumper is a dumper to export a database.
 type Dumper struct {
 	project   string
@@ -45,108 +42,178 @@ type Dumper struct {
 	database  string
 	query     map[string]string
 	out       io.Writer
-	timestamp *time.Time
+	readBound ReadBound
 	bulkSize  uint
 	upsert    bool
 	tables    []string
 
-	client      *spanner.Client
-	adminClient *adminapi.DatabaseAdminClient
+	parallelism  uint
+	partitioned  bool
+	queryOptions spanner.QueryOptions
+	checkpoint   *Checkpoint
+	pageSize     uint
+
+	maxMutations      uint
+	maxStatementBytes uint
+
+	progressOut      io.Writer
+	progressInterval time.Duration
+	report           *Report
+
+	// checkSchema enables comparing schema fingerprints taken before and after the dump.
+	checkSchema  bool
+	schemaBefore SchemaFingerprint
+
+	mask    *MaskPolicy
+	maskers map[string]*tableMasker
+
+	transformers []RowTransformer
+	encoder      Encoder
+
+	// snapshot is the transaction to read the database, which is begun by the first call of beginSnapshot.
+	snapshot       *spanner.ReadOnlyTransaction
+	snapshotTables []*Table
+	readTimestamp  time.Time
+
+	sort          bool
+	clientOptions []option.ClientOption
+
+	// Clients are closed by Cleanup only if they are created by NewDumper.
+	client          *spanner.Client
+	adminClient     *adminapi.DatabaseAdminClient
+	ownsClient      bool
+	ownsAdminClient bool
 }
 
-// NewDumper creates Dumper with specified configurations.
-func NewDumper(ctx context.Context, project, instance, database string, out io.Writer, timestamp *time.Time, bulkSize uint, query map[string]string, sort bool, upsert bool) (*Dumper, error) {
-	dbPath := fmt.Sprintf("projects/%s/instances/%s/databases/%s", project, instance, database)
-	client, err := spanner.NewClientWithConfig(ctx, dbPath, spanner.ClientConfig{
-		SessionPoolConfig: spanner.SessionPoolConfig{
-			MinOpened: 1,
-			MaxOpened: 1,
-		},
-	})
-	if err != nil {
-		return nil, fmt.Errorf("failed to create spanner client: %v", err)
+// NewDumper creates Dumper to export the database specified by dbPath in the format projects/<project>/instances/<instance>/databases/<database>.
+// It is configured by opts, whose defaults dump no tables to os.Stdout by a strong read (see Option).
+func NewDumper(ctx context.Context, dbPath string, opts ...Option) (*Dumper, error) {
+	match := databasePathRegexp.FindStringSubmatch(dbPath)
+	if match == nil {
+		return nil, fmt.Errorf("invalid database path %q: expected projects/<project>/instances/<instance>/databases/<database>", dbPath)
 	}
-
-	adminClient, err := adminapi.NewDatabaseAdminClient(ctx)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create spanner admin client: %v", err)
+	d := &Dumper{
+		project:     match[1],
+		instance:    match[2],
+		database:    match[3],
+		query:       map[string]string{},
+		out:         os.Stdout,
+		parallelism: 1,
 	}
-
-	if bulkSize == 0 {
-		bulkSize = defaultBulkSize
+	for _, opt := range opts {
+		opt(d)
 	}
-
-	tables := []string{}
-	dumperQuery := map[string]string{}
-	for table, where := range query {
-		t := strings.Trim(table, "`")
-		dumperQuery[t] = where
-		tables = append(tables, t)
+	if d.parallelism == 0 {
+		d.parallelism = 1
 	}
-	if sort {
-		q, err := schenerate_spanner.Open(ctx, project, instance, database)
+
+	if d.client == nil {
+		client, err := spanner.NewClientWithConfig(ctx, dbPath, spanner.ClientConfig{
+			QueryOptions: d.dataQueryOptions(),
+			SessionPoolConfig: spanner.SessionPoolConfig{
+				MinOpened: 1,
+				// A session for the transaction to fetch tables and one for each table being dumped concurrently.
+				MaxOpened: uint64(d.parallelism) + 1,
+			},
+		}, d.clientOptions...)
 		if err != nil {
-			return nil, fmt.Errorf("failed to open schema: %v", err)
+			return nil, fmt.Errorf("failed to create spanner client: %v", err)
 		}
-		s, err := schenerate_spanner.ListSchemas(ctx, q, tables)
+		d.client, d.ownsClient = client, true
+	}
+
+	if d.adminClient == nil {
+		adminClient, err := adminapi.NewDatabaseAdminClient(ctx, d.clientOptions...)
 		if err != nil {
-			return nil, fmt.Errorf("failed to list schemas: %v", err)
-		}
-		g := s.BuildGraph()
-		idx, cyclic := g.TopologicalSort()
-		if cyclic {
-			return nil, fmt.Errorf("cyclic dependency detected in tables")
+			d.Cleanup()
+			return nil, fmt.Errorf("failed to create spanner admin client: %v", err)
 		}
-		var ts []string
-		for _, i := range idx {
-			ts = append(ts, strings.Trim(g.Get(i).Name, "`"))
-		}
-		tables = ts
-
+		d.adminClient, d.ownsAdminClient = adminClient, true
 	}
 
-	d := &Dumper{
-		project:     project,
-		instance:    instance,
-		database:    database,
-		query:       dumperQuery,
-		tables:      tables,
-		out:         out,
-		bulkSize:    bulkSize,
-		timestamp:   timestamp,
-		upsert:      upsert,
-		client:      client,
-		adminClient: adminClient,
+	if d.sort {
+		tables, err := d.sortTables(ctx)
+		if err != nil {
+			d.Cleanup()
+			return nil, err
+		}
+		d.tables = tables
 	}
 
 	return d, nil
 }
 
+var databasePathRegexp = regexp.MustCompile(`^projects/([^/]+)/instances/([^/]+)/databases/([^/]+)$`)
+
+// dataQueryOptions returns the options of queries reading records, which exclude Data Boost available only for partitioned queries.
+func (d *Dumper) dataQueryOptions() spanner.QueryOptions {
+	options := d.queryOptions
+	options.DataBoostEnabled = false
+	return options
+}
+
+// outputEncoder returns the encoder of records, which is SQLEncoder configured by the dumper if not specified.
+func (d *Dumper) outputEncoder() Encoder {
+	if d.encoder != nil {
+		return d.encoder
+	}
+	return NewSQLEncoder(d.bulkSize, d.maxMutations, d.maxStatementBytes, d.upsert)
+}
+
+// Report returns the report of the last DumpTables, or nil if DumpTables has not been called.
+func (d *Dumper) Report() *Report {
+	return d.report
+}
+
 // Cleanup cleans up hold resources.
 func (d *Dumper) Cleanup() {
-	d.client.Close()
-	d.adminClient.Close()
+	if d.snapshot != nil {
+		d.snapshot.Close()
+	}
+	if d.ownsClient {
+		d.client.Close()
+	}
+	if d.ownsAdminClient {
+		d.adminClient.Close()
+	}
 }
 
 // DumpDDLs dumps all DDLs in the database.
 func (d *Dumper) DumpDDLs(ctx context.Context) error {
+	if err := d.beginSchemaCheck(ctx); err != nil {
+		return err
+	}
+	ddls, err := d.fetchDatabaseDDLs(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, ddl := range ddls {
+		fmt.Fprintf(d.out, "%s;\n", ddl)
+	}
+
+	return nil
+}
+
+// fetchDatabaseDDLs returns the current DDL statements in the database related to the tables to dump.
+func (d *Dumper) fetchDatabaseDDLs(ctx context.Context) ([]string, error) {
 	dbPath := fmt.Sprintf("projects/%s/instances/%s/databases/%s", d.project, d.instance, d.database)
 	resp, err := d.adminClient.GetDatabaseDdl(ctx, &adminpb.GetDatabaseDdlRequest{
 		Database: dbPath,
 	})
 	if err != nil {
-		return err
+		return nil, err
 	}
 
+	var ddls []string
 	for _, ddl := range resp.Statements {
 		tableName := strings.Trim(parseTableNameFromDDL(ddl), "`")
 		if _, ok := d.query[tableName]; len(d.query) > 0 && !ok {
 			continue
 		}
-		fmt.Fprintf(d.out, "%s;\n", ddl)
+		ddls = append(ddls, ddl)
 	}
-
-	return nil
+	return ddls, nil
 }
 
 func parseTableNameFromDDL(ddl string) string {
@@ -170,51 +237,386 @@ var tableRegexp = regexp.MustCompile("^\\s*CREATE\\s+TABLE\\s+`?([a-zA-Z0-9_]+)`
 var alterRegexp = regexp.MustCompile("^\\s*ALTER\\s+TABLE\\s+`?([a-zA-Z0-9_]+)`?")
 
 // DumpTables dumps all table records in the database.
+// If a checkpoint is given, the dump resumes from the progress recorded in it at the same read timestamp.
 func (d *Dumper) DumpTables(ctx context.Context) error {
-	txn := d.client.ReadOnlyTransaction()
-	if d.timestamp != nil {
-		txn = txn.WithTimestampBound(spanner.ReadTimestamp(*d.timestamp))
+	if err := d.beginSnapshot(ctx); err != nil {
+		return err
 	}
-	defer txn.Close()
+	resumed := d.checkpoint != nil && d.checkpoint.Resumed()
+	txn, tables, timestamp := d.snapshot, d.snapshotTables, d.readTimestamp
 
-	tables, err := FetchTables(ctx, txn, d.tables)
+	var refs []columnReference
+	if d.mask.hasPseudonyms() {
+		var err error
+		if refs, err = fetchColumnReferences(ctx, txn, d.dataQueryOptions()); err != nil {
+			return err
+		}
+	}
+	maskers, err := d.mask.bind(tables, refs)
 	if err != nil {
-		return fmt.Errorf("failed to fetch tables: %v", err)
+		return err
 	}
-	for _, t := range tables {
-		if err := d.dumpTable(ctx, t, txn); err != nil {
-			return fmt.Errorf("failed to dump table %s: %v", t.Name, err)
+	d.maskers = maskers
+
+	if d.checkpoint != nil {
+		if !resumed {
+			if err := d.checkpoint.start(timestamp); err != nil {
+				return err
+			}
 		}
+		var remaining []*Table
+		for _, t := range tables {
+			if !d.checkpoint.completed(t.Name) {
+				remaining = append(remaining, t)
+			}
+		}
+		tables = remaining
+	}
+	// Copy last keys not to read the checkpoint while it is updated.
+	lastKeys := map[string][]string{}
+	if resumed {
+		for table, key := range d.checkpoint.LastKeys {
+			lastKeys[table] = key
+		}
+	}
+
+	var batchTxn *spanner.BatchReadOnlyTransaction
+	if d.partitioned {
+		batchTxn, err = d.client.BatchReadOnlyTransaction(ctx, spanner.ReadTimestamp(timestamp))
+		if err != nil {
+			return fmt.Errorf("failed to begin batch transaction: %v", err)
+		}
+		defer batchTxn.Cleanup(ctx)
+	}
+
+	started := time.Now()
+	stats := make([]*tableStats, len(tables))
+	for i, table := range tables {
+		stats[i] = &tableStats{name: table.Name}
+	}
+	defer func() {
+		d.report = &Report{ReadTimestamp: timestamp, Duration: time.Since(started)}
+		for _, s := range stats {
+			if s.started.Load() != 0 {
+				d.report.Tables = append(d.report.Tables, s.report())
+			}
+		}
+	}()
+	if d.progressOut != nil && d.progressInterval > 0 {
+		stop := make(chan struct{})
+		defer close(stop)
+		go func() {
+			ticker := time.NewTicker(d.progressInterval)
+			defer ticker.Stop()
+			for {
+				select {
+				case now := <-ticker.C:
+					writeProgress(d.progressOut, stats, started, now)
+				case <-stop:
+					return
+				}
+			}
+		}()
+	}
+
+	encoder := d.outputEncoder()
+	if err := encoder.BeginDump(d.out, &DumpInfo{ReadTimestamp: timestamp, Tables: tables, Resumed: resumed}); err != nil {
+		return fmt.Errorf("failed to begin dump: %v", err)
+	}
+
+	// If parallelism is greater than 1, tables are dumped concurrently using a session for each table at the same read timestamp,
+	// and records of each table are buffered and written to the output in the order of tables.
+	err = runInOrder(ctx, d.parallelism, len(tables), d.out, func(ctx context.Context, i int, out io.Writer) error {
+		table := tables[i]
+		stats[i].start(time.Now())
+		defer func() { stats[i].finish(time.Now()) }()
+		out = countingWriter{w: out, count: &stats[i].bytes}
+		tableTxn := txn
+		var progress func(lastRow []string) error
+		if d.parallelism > 1 {
+			tableTxn = d.client.ReadOnlyTransaction().WithTimestampBound(spanner.ReadTimestamp(timestamp))
+			defer tableTxn.Close()
+		} else if d.checkpoint != nil {
+			// Records are written to the output directly only if tables are dumped one by one.
+			progress = func(lastRow []string) error { return d.checkpoint.recordLastRow(table, lastRow) }
+		}
+		if err := d.dumpTable(ctx, encoder, table, tableTxn, batchTxn, lastKeys[table.Name], out, stats[i], progress); err != nil {
+			return fmt.Errorf("failed to dump table %s: %v", table.Name, err)
+		}
+		return nil
+	}, func(i int) error {
+		if d.checkpoint == nil {
+			return nil
+		}
+		return d.checkpoint.complete(tables[i].Name)
+	})
+	if err != nil {
+		return err
+	}
+	if err := encoder.EndDump(d.out); err != nil {
+		return fmt.Errorf("failed to end dump: %v", err)
 	}
+	return d.endSchemaCheck(ctx)
+}
+
+// beginSnapshot begins the transaction to read the database and fetches tables to dump if not yet,
+// which fixes the read timestamp shared by DumpHeader and DumpTables.
+func (d *Dumper) beginSnapshot(ctx context.Context) error {
+	if d.snapshot != nil {
+		return nil
+	}
+	if err := d.beginSchemaCheck(ctx); err != nil {
+		return err
+	}
+	resumed := d.checkpoint != nil && d.checkpoint.Resumed()
+	bound := d.readBound
+	if resumed {
+		bound = ReadTimestamp(d.checkpoint.ReadTimestamp)
+	}
+	if err := d.checkVersionRetention(ctx, bound); err != nil {
+		return err
+	}
+	timestampBound, err := d.resolveReadTimestamp(ctx, bound)
+	if err != nil {
+		return err
+	}
+	txn := d.client.ReadOnlyTransaction().WithTimestampBound(timestampBound)
+
+	tables, err := FetchTablesWithOptions(ctx, txn, d.tables, d.dataQueryOptions())
+	if err != nil {
+		txn.Close()
+		if resumed && spanner.ErrCode(err) == codes.FailedPrecondition {
+			return fmt.Errorf("failed to fetch tables at %s recorded in the checkpoint, which may be out of the version retention period: %v", d.checkpoint.ReadTimestamp.Format(time.RFC3339Nano), err)
+		}
+		return fmt.Errorf("failed to fetch tables: %v", err)
+	}
+	timestamp, err := txn.Timestamp()
+	if err != nil {
+		txn.Close()
+		return fmt.Errorf("failed to get read timestamp: %v", err)
+	}
+	d.snapshot, d.snapshotTables, d.readTimestamp = txn, tables, timestamp
 	return nil
 }
 
-func (d *Dumper) dumpTable(ctx context.Context, table *Table, txn *spanner.ReadOnlyTransaction) error {
+// dumpTable dumps records of the table by the encoder. If batchTxn is not nil, the query is partitioned if possible.
+// If lastKey is not nil, records after the primary key are dumped.
+// If progress is not nil, records are read in the primary key order and progress is called with the last record whenever records are written.
+func (d *Dumper) dumpTable(ctx context.Context, encoder Encoder, table *Table, txn *spanner.ReadOnlyTransaction, batchTxn *spanner.BatchReadOnlyTransaction, lastKey []string, out io.Writer, stats *tableStats, progress func(lastRow []string) error) (err error) {
 	queryCondition := d.query[table.Name]
 	if queryCondition == "" {
 		queryCondition = "TRUE"
 	}
-	stmt := fmt.Sprintf("SELECT %s FROM `%s` WHERE %s", table.quotedColumnList(), table.Name, queryCondition)
-	iter := txn.Query(ctx, spanner.NewStatement(stmt))
+	sql := fmt.Sprintf("SELECT %s FROM `%s` WHERE (%s)", table.quotedColumnList(), table.Name, queryCondition)
+
+	var partitions []*spanner.Partition
+	if batchTxn != nil {
+		partitionSQL := sql
+		if lastKey != nil {
+			partitionSQL += " AND (" + keyAfterCondition(table.PrimaryKey, lastKey) + ")"
+		}
+		partitions, err = batchTxn.PartitionQueryWithOptions(ctx, spanner.NewStatement(partitionSQL), spanner.PartitionOptions{}, d.queryOptions)
+		if err != nil {
+			if spanner.ErrCode(err) != codes.InvalidArgument {
+				return fmt.Errorf("failed to partition query: %v", err)
+			}
+			log.Printf("Table %s is dumped by a normal query since the query is not root-partitionable: %v", table.Name, spanner.ErrDesc(err))
+			batchTxn = nil
+		}
+	}
+	if batchTxn != nil {
+		// Records of partitions are not in the primary key order.
+		progress = nil
+	}
+
+	w, err := d.newTableWriter(encoder, table, out, stats, progress)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		// Buffered records are written even if an error occurs, so their progress is also recorded.
+		if closeErr := w.close(); err == nil {
+			err = closeErr
+		}
+	}()
+
+	if batchTxn != nil {
+		return d.dumpPartitions(ctx, batchTxn, partitions, w)
+	}
+
+	if d.pageSize > 0 && table.hasDumpedPrimaryKey() {
+		return d.dumpPages(ctx, table, txn, sql, lastKey, w)
+	}
+
+	if lastKey != nil {
+		sql += " AND (" + keyAfterCondition(table.PrimaryKey, lastKey) + ")"
+	}
+	if progress != nil && len(table.PrimaryKey) > 0 {
+		sql += " " + orderByPrimaryKey(table.PrimaryKey)
+	}
+	iter := txn.QueryWithOptions(ctx, spanner.NewStatement(sql), d.dataQueryOptions())
 	defer iter.Stop()
+	return writeRows(iter, w)
+}
 
-	writer := NewBufferedWriter(table, d.out, d.bulkSize, d.upsert)
-	defer writer.Flush()
+// dumpPartitions reads partitions concurrently and writes their records in the order of partitions.
+// If parallelism is greater than 1, records of each partition are held in memory until preceding partitions are written.
+func (d *Dumper) dumpPartitions(ctx context.Context, batchTxn *spanner.BatchReadOnlyTransaction, partitions []*spanner.Partition, w *tableWriter) error {
+	rows := make([][][]spanner.GenericColumnValue, len(partitions))
+	return runInOrder(ctx, d.parallelism, len(partitions), io.Discard, func(ctx context.Context, i int, _ io.Writer) error {
+		iter := batchTxn.Execute(ctx, partitions[i])
+		defer iter.Stop()
+		err := iter.Do(func(row *spanner.Row) error {
+			values, err := rowValues(row)
+			if err != nil {
+				return err
+			}
+			if d.parallelism <= 1 {
+				return w.write(values)
+			}
+			rows[i] = append(rows[i], values)
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("failed to read partition %d: %v", i, err)
+		}
+		return nil
+	}, func(i int) error {
+		for _, values := range rows[i] {
+			if err := w.write(values); err != nil {
+				return err
+			}
+		}
+		rows[i] = nil
+		return nil
+	})
+}
+
+func writeRows(iter *spanner.RowIterator, w *tableWriter) error {
 	for {
 		row, err := iter.Next()
 		if errors.Is(err, iterator.Done) {
-			break
+			return nil
 		}
 		if err != nil {
 			return err
 		}
 
-		values, err := DecodeRow(row)
+		values, err := rowValues(row)
 		if err != nil {
 			return err
 		}
-		writer.Write(values)
+		if err := w.write(values); err != nil {
+			return err
+		}
 	}
+}
 
-	return nil
+// tableWriter writes records of a table by an encoder and calls progress with the last record whenever records are written.
+// Records are counted in stats.
+// Records are transformed by transformers and then masked by mask if not nil before written,
+// while progress is called with decoded records before transformed.
+type tableWriter struct {
+	table        *Table
+	encoder      TableEncoder
+	transformers []RowTransformer
+	mask         *tableMasker
+	stats        *tableStats
+	progress     func(lastRow []string) error
+	lastRow      []string
+}
+
+func (d *Dumper) newTableWriter(encoder Encoder, table *Table, out io.Writer, stats *tableStats, progress func(lastRow []string) error) (*tableWriter, error) {
+	if stats == nil {
+		stats = &tableStats{name: table.Name}
+	}
+	tableEncoder, err := encoder.BeginTable(out, table)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin table: %v", err)
+	}
+	return &tableWriter{
+		table:        table,
+		encoder:      tableEncoder,
+		transformers: d.transformers,
+		mask:         d.maskers[table.Name],
+		stats:        stats,
+		progress:     progress,
+	}, nil
+}
+
+// buffered returns the number of records which are not yet written by the encoder.
+func (w *tableWriter) buffered() int {
+	if b, ok := w.encoder.(interface{ Buffered() int }); ok {
+		return b.Buffered()
+	}
+	return 0
+}
+
+func (w *tableWriter) write(values []spanner.GenericColumnValue) error {
+	w.stats.rows.Add(1)
+	rows := [][]spanner.GenericColumnValue{values}
+	if len(w.transformers) > 0 || w.mask != nil {
+		var err error
+		if rows, err = w.transform(values); err != nil {
+			return err
+		}
+	}
+
+	flushed := false
+	for _, row := range rows {
+		buffered := w.buffered()
+		if err := w.encoder.WriteRow(row); err != nil {
+			return err
+		}
+		flushed = flushed || w.buffered() <= buffered
+	}
+	if w.progress == nil {
+		return nil
+	}
+	row, err := decodeValues(values)
+	if err != nil {
+		return err
+	}
+	written := w.lastRow
+	w.lastRow = row
+	switch {
+	case !flushed:
+		return nil
+	case w.buffered() == 0:
+		// The buffer is flushed with the records of the row.
+		return w.progress(row)
+	default:
+		// The buffer is flushed before all records of the row are buffered.
+		return w.progress(written)
+	}
+}
+
+// transform transforms and masks the row into records to write.
+// Transformers are given a copy of the row, so that the row is kept intact for progress and keys of pages.
+func (w *tableWriter) transform(values []spanner.GenericColumnValue) ([][]spanner.GenericColumnValue, error) {
+	rows, err := transformRow(w.transformers, w.table, copyValues(values))
+	if err != nil {
+		return nil, err
+	}
+	if w.mask == nil {
+		return rows, nil
+	}
+	for i, row := range rows {
+		if rows[i], err = w.mask.apply(row); err != nil {
+			return nil, fmt.Errorf("failed to mask record: %v", err)
+		}
+	}
+	return rows, nil
+}
+
+// close writes buffered records and ends the table.
+func (w *tableWriter) close() error {
+	buffered := w.buffered()
+	if err := w.encoder.EndTable(); err != nil {
+		return err
+	}
+	if buffered == 0 || w.progress == nil {
+		return nil
+	}
+	return w.progress(w.lastRow)
 }
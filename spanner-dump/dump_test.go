@@ -1,6 +1,34 @@
 package spanner_dump
 
-import "testing"
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"cloud.google.com/go/spanner"
+)
+
+// literalValues parses literals emitted by DecodeColumn into values of the column types of the table.
+func literalValues(t *testing.T, table *Table, literals ...string) []spanner.GenericColumnValue {
+	t.Helper()
+	values := make([]spanner.GenericColumnValue, len(literals))
+	for i, literal := range literals {
+		typ, err := parseSpannerType(table.ColumnTypes[i])
+		if err != nil {
+			t.Fatalf("parseSpannerType() failed: %v", err)
+		}
+		p, err := newTokenParser(literal)
+		if err != nil {
+			t.Fatalf("newTokenParser() failed: %v", err)
+		}
+		v, err := p.parseValue(typ)
+		if err != nil {
+			t.Fatalf("parseValue() failed: %v", err)
+		}
+		values[i] = spanner.GenericColumnValue{Type: typ, Value: v}
+	}
+	return values
+}
 
 func TestParseTableNameFromDDL(t *testing.T) {
 	tests := []struct {
@@ -87,3 +115,32 @@ func TestParseTableNameFromDDL(t *testing.T) {
 		})
 	}
 }
+
+func TestTableWriter_progress(t *testing.T) {
+	table := &Table{Name: "t", Columns: []string{"A"}, ColumnTypes: []string{"INT64"}}
+	out := &bytes.Buffer{}
+	var progress []string
+	d := &Dumper{bulkSize: 2, maxStatementBytes: uint(len("INSERT OR UPDATE INTO `t` (`A`) VALUES ;\n") + len("(1), (22), "))}
+	w, err := d.newTableWriter(d.outputEncoder(), table, out, nil, func(lastRow []string) error {
+		progress = append(progress, lastRow[0])
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("newTableWriter() failed: %v", err)
+	}
+	// Flushed before "333" and "4" exceed the statement bytes, after "5" fills the bulk, and on close.
+	for _, v := range []string{"1", "22", "333", "4", "5", "6"} {
+		if err := w.write(literalValues(t, table, v)); err != nil {
+			t.Fatalf("write() failed: %v", err)
+		}
+	}
+	if err := w.close(); err != nil {
+		t.Fatalf("close() failed: %v", err)
+	}
+	if got, want := strings.Join(progress, ","), "22,333,5,6"; got != want {
+		t.Errorf("progress: got = %q, want = %q", got, want)
+	}
+	if got := strings.Count(out.String(), "INSERT"); got != 4 {
+		t.Errorf("statements: got = %d, want = %d", got, 4)
+	}
+}
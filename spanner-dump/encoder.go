@@ -0,0 +1,363 @@
+package spanner_dump
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"cloud.google.com/go/spanner"
+)
+
+// Encoder encodes records of tables into an output format.
+//
+// Dumper calls BeginDump at the beginning of DumpTables, BeginTable for each table to write its records, and EndDump at the end.
+// EndDump is not called if the dump fails.
+// If tables are dumped concurrently, BeginTable is called concurrently with a buffer of each table as out,
+// and the buffers are written to the output in the dump order.
+type Encoder interface {
+	// BeginDump begins the dump of the tables.
+	BeginDump(out io.Writer, dump *DumpInfo) error
+	// BeginTable begins writing records of the table to out.
+	// It can be called again for a table after EndTable to continue writing its records.
+	BeginTable(out io.Writer, table *Table) (TableEncoder, error)
+	// EndDump ends the dump after all tables are written.
+	EndDump(out io.Writer) error
+}
+
+// TableEncoder encodes records of a table.
+//
+// If TableEncoder has a method Buffered() int returning the number of records not yet written to the output,
+// the checkpoint records progress of only written records. Otherwise records are regarded as written by WriteRow.
+type TableEncoder interface {
+	// WriteRow writes a record, whose values are in the order of the columns of the table.
+	WriteRow(values []spanner.GenericColumnValue) error
+	// EndTable writes buffered records and ends writing records of the table.
+	EndTable() error
+}
+
+// DumpInfo describes a dump given to Encoder.
+type DumpInfo struct {
+	// ReadTimestamp is the timestamp at which records are read, or zero if records are not read from a database.
+	ReadTimestamp time.Time
+	// Tables are the tables to dump, or nil if they are not known in advance.
+	Tables []*Table
+	// Resumed reports whether the dump resumes an interrupted dump from a checkpoint,
+	// in which case Tables excludes completed tables, and the output should be appended to the interrupted one.
+	Resumed bool
+}
+
+// EncoderConfig is the configuration given to encoders created by NewEncoder.
+type EncoderConfig struct {
+	// OutDir is the directory to write files for formats which write a file for each table.
+	OutDir string
+	// BulkSize, MaxMutations, MaxStatementBytes, and Upsert configure INSERT statements (see NewBufferedWriterWithLimits).
+	BulkSize          uint
+	MaxMutations      uint
+	MaxStatementBytes uint
+	Upsert            bool
+	// GoPackage and GoFunc are the package and the function of Go code written by FormatGo.
+	GoPackage string
+	GoFunc    string
+}
+
+// Format is an output format of table records.
+type Format string
+
+const (
+	// FormatSQL outputs INSERT statements.
+	FormatSQL Format = "sql"
+	// FormatCSV outputs a CSV file with a header row for each table.
+	FormatCSV Format = "csv"
+	// FormatJSONL outputs JSON objects of records line by line.
+	FormatJSONL Format = "jsonl"
+)
+
+var (
+	formatsMu sync.RWMutex
+	formats   = map[Format]func(config EncoderConfig) (Encoder, error){
+		FormatSQL: func(config EncoderConfig) (Encoder, error) {
+			return NewSQLEncoder(config.BulkSize, config.MaxMutations, config.MaxStatementBytes, config.Upsert), nil
+		},
+		FormatCSV: func(config EncoderConfig) (Encoder, error) {
+			if config.OutDir == "" {
+				return nil, fmt.Errorf("output directory is required for %s format", FormatCSV)
+			}
+			return NewCSVEncoder(config.OutDir), nil
+		},
+		FormatJSONL: func(config EncoderConfig) (Encoder, error) {
+			return NewJSONLEncoder(), nil
+		},
+		FormatGo: func(config EncoderConfig) (Encoder, error) {
+			return NewGoEncoder(config.GoPackage, config.GoFunc, config.Upsert)
+		},
+		FormatYAML: func(config EncoderConfig) (Encoder, error) {
+			if config.OutDir == "" {
+				return nil, fmt.Errorf("output directory is required for %s format", FormatYAML)
+			}
+			return NewYAMLEncoder(config.OutDir), nil
+		},
+	}
+)
+
+// RegisterFormat registers a format whose encoder is created by newEncoder, which replaces the format if already registered.
+// The name of the format must be lower case.
+func RegisterFormat(format Format, newEncoder func(config EncoderConfig) (Encoder, error)) {
+	formatsMu.Lock()
+	defer formatsMu.Unlock()
+	formats[format] = newEncoder
+}
+
+// Formats returns the names of the registered formats in lexical order.
+func Formats() []Format {
+	formatsMu.RLock()
+	defer formatsMu.RUnlock()
+	var names []Format
+	for format := range formats {
+		names = append(names, format)
+	}
+	sort.Slice(names, func(i, j int) bool { return names[i] < names[j] })
+	return names
+}
+
+// ParseFormat parses a name of a registered format.
+func ParseFormat(s string) (Format, error) {
+	f := Format(strings.ToLower(s))
+	formatsMu.RLock()
+	defer formatsMu.RUnlock()
+	if _, ok := formats[f]; !ok {
+		return "", fmt.Errorf("unknown format: %s", s)
+	}
+	return f, nil
+}
+
+// NewEncoder creates an encoder of the registered format with the configuration.
+func NewEncoder(format Format, config EncoderConfig) (Encoder, error) {
+	formatsMu.RLock()
+	newEncoder, ok := formats[format]
+	formatsMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown format: %s", format)
+	}
+	return newEncoder(config)
+}
+
+// SQLEncoder is an Encoder writing records in INSERT statements, which is the default encoder of Dumper.
+type SQLEncoder struct {
+	bulkSize          uint
+	maxMutations      uint
+	maxStatementBytes uint
+	upsert            bool
+}
+
+// NewSQLEncoder creates SQLEncoder writing INSERT statements split by bulkSize, maxMutations, and maxStatementBytes as NewBufferedWriterWithLimits does.
+// If upsert is true, INSERT OR UPDATE is used instead of INSERT.
+func NewSQLEncoder(bulkSize, maxMutations, maxStatementBytes uint, upsert bool) *SQLEncoder {
+	return &SQLEncoder{bulkSize: bulkSize, maxMutations: maxMutations, maxStatementBytes: maxStatementBytes, upsert: upsert}
+}
+
+func (e *SQLEncoder) BeginDump(out io.Writer, dump *DumpInfo) error {
+	return nil
+}
+
+func (e *SQLEncoder) BeginTable(out io.Writer, table *Table) (TableEncoder, error) {
+	return &sqlTableEncoder{writer: NewBufferedWriterWithLimits(table, out, e.bulkSize, e.maxMutations, e.maxStatementBytes, e.upsert)}, nil
+}
+
+func (e *SQLEncoder) EndDump(out io.Writer) error {
+	return nil
+}
+
+type sqlTableEncoder struct {
+	writer *BufferedWriter
+}
+
+func (e *sqlTableEncoder) WriteRow(values []spanner.GenericColumnValue) error {
+	record, err := decodeValues(values)
+	if err != nil {
+		return err
+	}
+	e.writer.Write(record)
+	return nil
+}
+
+func (e *sqlTableEncoder) Buffered() int {
+	return e.writer.Len()
+}
+
+func (e *sqlTableEncoder) EndTable() error {
+	e.writer.Flush()
+	return nil
+}
+
+// csvFlushRows is the number of records buffered before flushed to a CSV file.
+const csvFlushRows = 1000
+
+// CSVEncoder is an Encoder writing a file named <table>.csv with a header row for each table in a directory.
+// NULL is written as an empty field, BYTES in base64, and ARRAY in JSON.
+//
+// Files are created when their tables begin, and appended to if their tables begin again or the dump is resumed.
+type CSVEncoder struct {
+	dir string
+
+	mu      sync.Mutex
+	resumed bool
+	// started are tables whose files have been created in the dump.
+	started map[string]bool
+}
+
+// NewCSVEncoder creates CSVEncoder writing files in dir.
+func NewCSVEncoder(dir string) *CSVEncoder {
+	return &CSVEncoder{dir: dir, started: map[string]bool{}}
+}
+
+func (e *CSVEncoder) BeginDump(out io.Writer, dump *DumpInfo) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.resumed = dump.Resumed
+	return nil
+}
+
+func (e *CSVEncoder) BeginTable(out io.Writer, table *Table) (TableEncoder, error) {
+	e.mu.Lock()
+	continued := e.started[table.Name]
+	e.started[table.Name] = true
+	resumed := e.resumed
+	e.mu.Unlock()
+
+	path := filepath.Join(e.dir, table.Name+".csv")
+	header := !continued
+	flag := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if continued {
+		flag = os.O_WRONLY | os.O_APPEND
+	} else if resumed {
+		// The file of a table continued from the interrupted dump already has the header.
+		if _, err := os.Stat(path); err == nil {
+			header, flag = false, os.O_WRONLY|os.O_APPEND
+		} else if !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+	}
+	f, err := os.OpenFile(path, flag, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	w := &csvTableEncoder{file: f}
+	w.writer = csv.NewWriter(&w.buffer)
+	if header {
+		if err := w.writer.Write(table.Columns); err != nil {
+			_ = f.Close()
+			return nil, err
+		}
+	}
+	return w, nil
+}
+
+func (e *CSVEncoder) EndDump(out io.Writer) error {
+	return nil
+}
+
+// csvTableEncoder holds records in buffer and writes them to file only by flush,
+// so that the file does not have records after the progress recorded by the checkpoint or a partially written record.
+type csvTableEncoder struct {
+	file     *os.File
+	writer   *csv.Writer
+	buffer   bytes.Buffer
+	buffered int
+}
+
+func (e *csvTableEncoder) WriteRow(values []spanner.GenericColumnValue) error {
+	record := make([]string, len(values))
+	for i, v := range values {
+		record[i] = formatValueText(v.Value)
+	}
+	if err := e.writer.Write(record); err != nil {
+		return err
+	}
+	e.buffered++
+	if e.buffered >= csvFlushRows {
+		return e.flush()
+	}
+	return nil
+}
+
+func (e *csvTableEncoder) flush() error {
+	e.writer.Flush()
+	if err := e.writer.Error(); err != nil {
+		return err
+	}
+	if _, err := e.file.Write(e.buffer.Bytes()); err != nil {
+		return err
+	}
+	e.buffer.Reset()
+	e.buffered = 0
+	return nil
+}
+
+func (e *csvTableEncoder) Buffered() int {
+	return e.buffered
+}
+
+func (e *csvTableEncoder) EndTable() error {
+	err := e.flush()
+	if closeErr := e.file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// JSONLEncoder is an Encoder writing a record as a line of {"table": <name>, "values": {<column>: <value>, ...}},
+// where values are in the JSON encoding of Cloud Spanner API (e.g. INT64 as a string, BYTES in base64).
+type JSONLEncoder struct{}
+
+// NewJSONLEncoder creates JSONLEncoder.
+func NewJSONLEncoder() *JSONLEncoder {
+	return &JSONLEncoder{}
+}
+
+func (e *JSONLEncoder) BeginDump(out io.Writer, dump *DumpInfo) error {
+	return nil
+}
+
+func (e *JSONLEncoder) BeginTable(out io.Writer, table *Table) (TableEncoder, error) {
+	return &jsonlTableEncoder{out: out, table: table}, nil
+}
+
+func (e *JSONLEncoder) EndDump(out io.Writer) error {
+	return nil
+}
+
+type jsonlTableEncoder struct {
+	out   io.Writer
+	table *Table
+}
+
+func (e *jsonlTableEncoder) WriteRow(values []spanner.GenericColumnValue) error {
+	sb := &strings.Builder{}
+	sb.WriteString(`{"table":`)
+	writeJSONString(sb, e.table.Name)
+	sb.WriteString(`,"values":{`)
+	for i, v := range values {
+		if i > 0 {
+			sb.WriteString(",")
+		}
+		writeJSONString(sb, e.table.Columns[i])
+		sb.WriteString(":")
+		writeValueJSON(sb, v.Value)
+	}
+	sb.WriteString("}}\n")
+	_, err := io.WriteString(e.out, sb.String())
+	return err
+}
+
+func (e *jsonlTableEncoder) EndTable() error {
+	return nil
+}
@@ -0,0 +1,201 @@
+package spanner_dump
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"cloud.google.com/go/spanner"
+)
+
+// recordingEncoder records calls of Encoder methods.
+type recordingEncoder struct {
+	calls []string
+}
+
+func (e *recordingEncoder) BeginDump(out io.Writer, dump *DumpInfo) error {
+	e.calls = append(e.calls, "BeginDump")
+	return nil
+}
+
+func (e *recordingEncoder) BeginTable(out io.Writer, table *Table) (TableEncoder, error) {
+	e.calls = append(e.calls, "BeginTable "+table.Name)
+	return &recordingTableEncoder{encoder: e, out: out}, nil
+}
+
+func (e *recordingEncoder) EndDump(out io.Writer) error {
+	e.calls = append(e.calls, "EndDump")
+	return nil
+}
+
+type recordingTableEncoder struct {
+	encoder *recordingEncoder
+	out     io.Writer
+}
+
+func (e *recordingTableEncoder) WriteRow(values []spanner.GenericColumnValue) error {
+	e.encoder.calls = append(e.encoder.calls, fmt.Sprintf("WriteRow %s %s", values[0].Type.GetCode(), values[0].Value.GetStringValue()))
+	_, err := fmt.Fprintln(e.out, values[0].Value.GetStringValue())
+	return err
+}
+
+func (e *recordingTableEncoder) EndTable() error {
+	e.encoder.calls = append(e.encoder.calls, "EndTable")
+	return nil
+}
+
+func TestTableWriter_encoder(t *testing.T) {
+	table := &Table{Name: "t", Columns: []string{"A"}, ColumnTypes: []string{"INT64"}}
+	encoder := &recordingEncoder{}
+	out := &bytes.Buffer{}
+	var progress []string
+	d := &Dumper{encoder: encoder}
+	w, err := d.newTableWriter(d.outputEncoder(), table, out, nil, func(lastRow []string) error {
+		progress = append(progress, lastRow[0])
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("newTableWriter() failed: %v", err)
+	}
+	for _, v := range []string{"1", "2"} {
+		if err := w.write(literalValues(t, table, v)); err != nil {
+			t.Fatalf("write() failed: %v", err)
+		}
+	}
+	if err := w.close(); err != nil {
+		t.Fatalf("close() failed: %v", err)
+	}
+	if got, want := strings.Join(encoder.calls, ","), "BeginTable t,WriteRow INT64 1,WriteRow INT64 2,EndTable"; got != want {
+		t.Errorf("calls: got = %q, want = %q", got, want)
+	}
+	if got, want := out.String(), "1\n2\n"; got != want {
+		t.Errorf("output: got = %q, want = %q", got, want)
+	}
+	// Records are regarded as written by WriteRow without Buffered.
+	if got, want := strings.Join(progress, ","), "1,2"; got != want {
+		t.Errorf("progress: got = %q, want = %q", got, want)
+	}
+}
+
+func TestCSVEncoder(t *testing.T) {
+	dir := t.TempDir()
+	table := &Table{Name: "t", Columns: []string{"A", "B"}, ColumnTypes: []string{"INT64", "STRING(MAX)"}}
+	write := func(e *CSVEncoder, resumed bool, rows ...[]string) {
+		t.Helper()
+		if err := e.BeginDump(nil, &DumpInfo{Resumed: resumed}); err != nil {
+			t.Fatalf("BeginDump() failed: %v", err)
+		}
+		for _, row := range rows {
+			w, err := e.BeginTable(nil, table)
+			if err != nil {
+				t.Fatalf("BeginTable() failed: %v", err)
+			}
+			if err := w.WriteRow(literalValues(t, table, row...)); err != nil {
+				t.Fatalf("WriteRow() failed: %v", err)
+			}
+			if err := w.EndTable(); err != nil {
+				t.Fatalf("EndTable() failed: %v", err)
+			}
+		}
+		if err := e.EndDump(nil); err != nil {
+			t.Fatalf("EndDump() failed: %v", err)
+		}
+	}
+	// A table which begins again and a resumed dump are appended without the header.
+	write(NewCSVEncoder(dir), false, []string{"1", `"a"`}, []string{"2", "NULL"})
+	write(NewCSVEncoder(dir), true, []string{"3", `"c,d"`})
+
+	got, err := os.ReadFile(filepath.Join(dir, "t.csv"))
+	if err != nil {
+		t.Fatalf("failed to read output: %v", err)
+	}
+	if want := "A,B\n1,a\n2,\n3,\"c,d\"\n"; string(got) != want {
+		t.Errorf("output: got = %q, want = %q", got, want)
+	}
+
+	// A dump which is not resumed overwrites the file.
+	write(NewCSVEncoder(dir), false, []string{"4", `"e"`})
+	got, err = os.ReadFile(filepath.Join(dir, "t.csv"))
+	if err != nil {
+		t.Fatalf("failed to read output: %v", err)
+	}
+	if want := "A,B\n4,e\n"; string(got) != want {
+		t.Errorf("output: got = %q, want = %q", got, want)
+	}
+}
+
+func TestRegisterFormat(t *testing.T) {
+	const format Format = "test-recording"
+	if _, err := ParseFormat(string(format)); err == nil {
+		t.Fatalf("ParseFormat() succeeded before registered")
+	}
+	encoder := &recordingEncoder{}
+	RegisterFormat(format, func(config EncoderConfig) (Encoder, error) { return encoder, nil })
+	defer func() {
+		formatsMu.Lock()
+		defer formatsMu.Unlock()
+		delete(formats, format)
+	}()
+
+	got, err := ParseFormat("TEST-RECORDING")
+	if err != nil || got != format {
+		t.Fatalf("ParseFormat() = %q, %v, want %q", got, err, format)
+	}
+	out := &bytes.Buffer{}
+	c, err := NewConverter(format, out, EncoderConfig{})
+	if err != nil {
+		t.Fatalf("NewConverter() failed: %v", err)
+	}
+	if err := c.Convert(strings.NewReader(testDump)); err != nil {
+		t.Fatalf("Convert() failed: %v", err)
+	}
+	if err := c.Close(); err != nil {
+		t.Fatalf("Close() failed: %v", err)
+	}
+	want := "BeginDump,BeginTable t1,WriteRow INT64 1,WriteRow INT64 2,WriteRow INT64 3,EndTable,EndDump"
+	if got := strings.Join(encoder.calls, ","); got != want {
+		t.Errorf("calls: got = %q, want = %q", got, want)
+	}
+}
+
+func TestCSVEncoder_flush(t *testing.T) {
+	dir := t.TempDir()
+	table := &Table{Name: "t", Columns: []string{"A"}, ColumnTypes: []string{"STRING(MAX)"}}
+	w, err := NewCSVEncoder(dir).BeginTable(nil, table)
+	if err != nil {
+		t.Fatalf("BeginTable() failed: %v", err)
+	}
+	// Records larger than the buffer of csv.Writer are not written before flushed.
+	long := `"` + strings.Repeat("a", 10000) + `"`
+	for i := 0; i < csvFlushRows-1; i++ {
+		if err := w.WriteRow(literalValues(t, table, long)); err != nil {
+			t.Fatalf("WriteRow() failed: %v", err)
+		}
+	}
+	if info, err := os.Stat(filepath.Join(dir, "t.csv")); err != nil || info.Size() != 0 {
+		t.Errorf("file before flush: size = %d, err = %v, want empty", info.Size(), err)
+	}
+	if got := w.(interface{ Buffered() int }).Buffered(); got != csvFlushRows-1 {
+		t.Errorf("Buffered(): got = %d, want = %d", got, csvFlushRows-1)
+	}
+	if err := w.WriteRow(literalValues(t, table, long)); err != nil {
+		t.Fatalf("WriteRow() failed: %v", err)
+	}
+	if got := w.(interface{ Buffered() int }).Buffered(); got != 0 {
+		t.Errorf("Buffered() after flush: got = %d, want = 0", got)
+	}
+	if err := w.EndTable(); err != nil {
+		t.Fatalf("EndTable() failed: %v", err)
+	}
+	got, err := os.ReadFile(filepath.Join(dir, "t.csv"))
+	if err != nil {
+		t.Fatalf("failed to read output: %v", err)
+	}
+	if n := strings.Count(string(got), "\n"); n != csvFlushRows+1 {
+		t.Errorf("output: got %d lines, want %d lines", n, csvFlushRows+1)
+	}
+}
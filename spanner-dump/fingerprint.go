@@ -0,0 +1,132 @@
+package spanner_dump
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// SchemaFingerprint maps schema objects, e.g. TABLE Users, to their definitions.
+// It consists of the DDL statements related to the dumped tables and the column lists of the dumped tables.
+type SchemaFingerprint map[string]string
+
+// SchemaChange is a schema object changed between two fingerprints.
+type SchemaChange struct {
+	// Object is the schema object, e.g. TABLE Users, INDEX UsersByName, or COLUMNS OF Users.
+	Object string
+	// Kind is one of added, removed, and modified.
+	Kind string
+}
+
+func (c SchemaChange) String() string {
+	return c.Object + " " + c.Kind
+}
+
+// Diff returns schema objects changed from f to other in the order of objects.
+func (f SchemaFingerprint) Diff(other SchemaFingerprint) []SchemaChange {
+	var changes []SchemaChange
+	for object, definition := range f {
+		otherDefinition, ok := other[object]
+		switch {
+		case !ok:
+			changes = append(changes, SchemaChange{Object: object, Kind: "removed"})
+		case otherDefinition != definition:
+			changes = append(changes, SchemaChange{Object: object, Kind: "modified"})
+		}
+	}
+	for object := range other {
+		if _, ok := f[object]; !ok {
+			changes = append(changes, SchemaChange{Object: object, Kind: "added"})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Object < changes[j].Object })
+	return changes
+}
+
+// SchemaChangedError is returned by DumpTables if the schema is changed during the dump,
+// which means that the dumped records may be inconsistent with the dumped DDL statements.
+type SchemaChangedError struct {
+	Changes []SchemaChange
+}
+
+func (e *SchemaChangedError) Error() string {
+	changes := make([]string, len(e.Changes))
+	for i, c := range e.Changes {
+		changes[i] = c.String()
+	}
+	return fmt.Sprintf("schema changed during the dump: %s", strings.Join(changes, ", "))
+}
+
+// FetchSchemaFingerprint fetches the fingerprint of the current schema of the tables to dump.
+func (d *Dumper) FetchSchemaFingerprint(ctx context.Context) (SchemaFingerprint, error) {
+	ddls, err := d.fetchDatabaseDDLs(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch DDLs: %v", err)
+	}
+	// FetchTablesWithOptions runs multiple queries, which are not allowed in a single-use transaction.
+	txn := d.client.ReadOnlyTransaction()
+	defer txn.Close()
+	tables, err := FetchTablesWithOptions(ctx, txn, d.tables, d.dataQueryOptions())
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch tables: %v", err)
+	}
+	return newSchemaFingerprint(ddls, tables), nil
+}
+
+func newSchemaFingerprint(ddls []string, tables []*Table) SchemaFingerprint {
+	f := SchemaFingerprint{}
+	for _, ddl := range ddls {
+		f[schemaObjectName(ddl)] = strings.Join(strings.Fields(ddl), " ")
+	}
+	for _, t := range tables {
+		f["COLUMNS OF "+t.Name] = strings.Join(t.Columns, ", ")
+	}
+	return f
+}
+
+var (
+	createObjectRegexp  = regexp.MustCompile("(?i)^\\s*CREATE\\s+(?:OR\\s+REPLACE\\s+)?(?:(?:UNIQUE|NULL_FILTERED|SEARCH|VECTOR)\\s+)*(TABLE|INDEX|VIEW|CHANGE\\s+STREAM|SEQUENCE|MODEL|ROLE|PROTO\\s+BUNDLE)\\s+(?:IF\\s+NOT\\s+EXISTS\\s+)?`?([a-zA-Z0-9_.]+)`?")
+	addConstraintRegexp = regexp.MustCompile("(?i)^\\s*ALTER\\s+TABLE\\s+`?[a-zA-Z0-9_.]+`?\\s+ADD\\s+CONSTRAINT\\s+`?([a-zA-Z0-9_.]+)`?")
+)
+
+// schemaObjectName returns the schema object defined by ddl, e.g. TABLE Users.
+// The statement itself is returned if the object is unknown.
+func schemaObjectName(ddl string) string {
+	if match := createObjectRegexp.FindStringSubmatch(ddl); match != nil {
+		return strings.ToUpper(strings.Join(strings.Fields(match[1]), " ")) + " " + match[2]
+	}
+	if match := addConstraintRegexp.FindStringSubmatch(ddl); match != nil {
+		return "CONSTRAINT " + match[1]
+	}
+	return strings.Join(strings.Fields(ddl), " ")
+}
+
+// beginSchemaCheck takes the fingerprint of the schema before the dump if checkSchema is enabled and not yet taken.
+func (d *Dumper) beginSchemaCheck(ctx context.Context) error {
+	if !d.checkSchema || d.schemaBefore != nil {
+		return nil
+	}
+	f, err := d.FetchSchemaFingerprint(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to fingerprint schema: %v", err)
+	}
+	d.schemaBefore = f
+	return nil
+}
+
+// endSchemaCheck compares the fingerprint of the schema after the dump with the one before the dump.
+func (d *Dumper) endSchemaCheck(ctx context.Context) error {
+	if !d.checkSchema {
+		return nil
+	}
+	after, err := d.FetchSchemaFingerprint(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to fingerprint schema: %v", err)
+	}
+	if changes := d.schemaBefore.Diff(after); len(changes) > 0 {
+		return &SchemaChangedError{Changes: changes}
+	}
+	return nil
+}
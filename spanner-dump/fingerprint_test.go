@@ -0,0 +1,67 @@
+package spanner_dump
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSchemaObjectName(t *testing.T) {
+	for _, tt := range []struct {
+		ddl  string
+		want string
+	}{
+		{ddl: "CREATE TABLE Users (\n  Id INT64 NOT NULL,\n) PRIMARY KEY(Id)", want: "TABLE Users"},
+		{ddl: "CREATE TABLE `Order` (Id INT64) PRIMARY KEY(Id)", want: "TABLE Order"},
+		{ddl: "CREATE UNIQUE NULL_FILTERED INDEX UsersByName ON Users(Name)", want: "INDEX UsersByName"},
+		{ddl: "CREATE OR REPLACE VIEW ActiveUsers SQL SECURITY INVOKER AS SELECT * FROM Users", want: "VIEW ActiveUsers"},
+		{ddl: "CREATE CHANGE STREAM  UsersStream FOR Users", want: "CHANGE STREAM UsersStream"},
+		{ddl: "ALTER TABLE Orders ADD CONSTRAINT FK_Orders_Users FOREIGN KEY(UserId) REFERENCES Users(Id)", want: "CONSTRAINT FK_Orders_Users"},
+		{ddl: "ALTER DATABASE db\n  SET OPTIONS (version_retention_period = '7d')", want: "ALTER DATABASE db SET OPTIONS (version_retention_period = '7d')"},
+	} {
+		t.Run(tt.want, func(t *testing.T) {
+			if got := schemaObjectName(tt.ddl); got != tt.want {
+				t.Errorf("schemaObjectName(): got = %q, want = %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSchemaFingerprint_Diff(t *testing.T) {
+	before := newSchemaFingerprint(
+		[]string{
+			"CREATE TABLE Users (Id INT64, Name STRING(MAX)) PRIMARY KEY(Id)",
+			"CREATE INDEX UsersByName ON Users(Name)",
+			"CREATE TABLE Items (Id INT64) PRIMARY KEY(Id)",
+		},
+		[]*Table{{Name: "Users", Columns: []string{"Id", "Name"}}, {Name: "Items", Columns: []string{"Id"}}},
+	)
+	after := newSchemaFingerprint(
+		[]string{
+			"CREATE TABLE Users (\n  Id INT64,\n  Name STRING(MAX),\n  Age INT64,\n) PRIMARY KEY(Id)",
+			"CREATE INDEX UsersByAge ON Users(Age)",
+			"CREATE TABLE Items (Id INT64)\n  PRIMARY KEY(Id)",
+		},
+		[]*Table{{Name: "Users", Columns: []string{"Id", "Name", "Age"}}, {Name: "Items", Columns: []string{"Id"}}},
+	)
+
+	if changes := before.Diff(before); len(changes) != 0 {
+		t.Errorf("Diff(): got = %v, want no changes", changes)
+	}
+
+	want := []SchemaChange{
+		{Object: "COLUMNS OF Users", Kind: "modified"},
+		{Object: "INDEX UsersByAge", Kind: "added"},
+		{Object: "INDEX UsersByName", Kind: "removed"},
+		{Object: "TABLE Users", Kind: "modified"},
+	}
+	got := before.Diff(after)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Diff(): got = %v, want = %v", got, want)
+	}
+
+	err := &SchemaChangedError{Changes: got}
+	wantMessage := "schema changed during the dump: COLUMNS OF Users modified, INDEX UsersByAge added, INDEX UsersByName removed, TABLE Users modified"
+	if err.Error() != wantMessage {
+		t.Errorf("Error(): got = %q, want = %q", err.Error(), wantMessage)
+	}
+}
@@ -0,0 +1,103 @@
+package spanner_dump
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/spanner"
+)
+
+// LoadFixtures inserts records in YAML files written in FormatYAML into the database of the client,
+// where the table of each file is the file name without the extension, e.g. User.yml for table User.
+// Tables are loaded one by one in the dependency order, i.e. parents of interleaving and tables referenced by foreign keys first,
+// and records of each table are inserted in the order of the file by commits split to keep the estimated mutations within the limit.
+// If upsert is true, records are inserted or updated. The number of loaded records of each table is written to progress if not nil.
+func LoadFixtures(ctx context.Context, client *spanner.Client, files []string, upsert bool, progress io.Writer) error {
+	tableFiles := map[string]string{}
+	var names []string
+	for _, file := range files {
+		name := strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+		if previous, ok := tableFiles[name]; ok {
+			return fmt.Errorf("both %s and %s are fixtures of table %s", previous, file, name)
+		}
+		tableFiles[name] = file
+		names = append(names, name)
+	}
+
+	txn := client.ReadOnlyTransaction()
+	tables, err := FetchTables(ctx, txn, names)
+	if err != nil {
+		txn.Close()
+		return fmt.Errorf("failed to fetch tables: %v", err)
+	}
+	refs, err := fetchColumnReferences(ctx, txn, spanner.QueryOptions{})
+	txn.Close()
+	if err != nil {
+		return fmt.Errorf("failed to fetch dependencies of tables: %v", err)
+	}
+	if names, err = sortTablesByDependency(names, refs); err != nil {
+		return err
+	}
+	tableMap := map[string]*Table{}
+	for _, table := range tables {
+		tableMap[table.Name] = table
+	}
+
+	for _, name := range names {
+		table, file := tableMap[name], tableFiles[name]
+		rows, err := readFixtureFile(table, file)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %v", file, err)
+		}
+		started := time.Now()
+		if err := insertFixtures(ctx, client, table, rows, upsert); err != nil {
+			return fmt.Errorf("failed to load records into table %s: %v", name, err)
+		}
+		if progress != nil {
+			fmt.Fprintf(progress, "Loaded %d records into %s in %v\n", len(rows), name, time.Since(started).Round(time.Millisecond))
+		}
+	}
+	return nil
+}
+
+func readFixtureFile(table *Table, file string) ([]fixtureRow, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return readFixtures(table, f)
+}
+
+// insertFixtures inserts the records by commits of at most defaultMaxMutations estimated mutations.
+func insertFixtures(ctx context.Context, client *spanner.Client, table *Table, rows []fixtureRow, upsert bool) error {
+	newMutation := spanner.Insert
+	if upsert {
+		newMutation = spanner.InsertOrUpdate
+	}
+	rowsPerCommit := int(defaultMaxMutations / max(table.mutationsPerRow(), 1))
+	if rowsPerCommit < 1 {
+		rowsPerCommit = 1
+	}
+	for len(rows) > 0 {
+		n := min(rowsPerCommit, len(rows))
+		mutations := make([]*spanner.Mutation, n)
+		for i, row := range rows[:n] {
+			values := make([]any, len(row.values))
+			for j, v := range row.values {
+				values[j] = v
+			}
+			mutations[i] = newMutation(table.Name, row.columns, values)
+		}
+		if _, err := client.Apply(ctx, mutations); err != nil {
+			return err
+		}
+		rows = rows[n:]
+	}
+	return nil
+}
@@ -0,0 +1,342 @@
+package spanner_dump
+
+import (
+	"encoding/base64"
+	"fmt"
+	gotoken "go/token"
+	"io"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/civil"
+	"cloud.google.com/go/spanner"
+	pb "google.golang.org/genproto/googleapis/spanner/v1"
+	structpb "google.golang.org/protobuf/types/known/structpb"
+)
+
+// FormatGo outputs a Go source file with a function returning mutations to insert records.
+const FormatGo Format = "go"
+
+const (
+	defaultGoPackage = "fixtures"
+	defaultGoFunc    = "Mutations"
+)
+
+// GoEncoder is an Encoder writing a Go source file with a function returning []*spanner.Mutation to insert the records,
+// which are grouped per table in the dump order.
+// Values are written as typed Go literals, e.g. int64(1), civil.Date{...}, *big.NewRat(5, 4), and spanner.NullJSON{...},
+// and NULL as typed NULL values such as spanner.NullInt64{}.
+type GoEncoder struct {
+	pkg      string
+	funcName string
+	upsert   bool
+}
+
+// NewGoEncoder creates GoEncoder writing the function named funcName in the package pkg,
+// which are "Mutations" and "fixtures" if empty.
+// If upsert is true, spanner.InsertOrUpdate is used instead of spanner.Insert.
+func NewGoEncoder(pkg, funcName string, upsert bool) (*GoEncoder, error) {
+	if pkg == "" {
+		pkg = defaultGoPackage
+	}
+	if funcName == "" {
+		funcName = defaultGoFunc
+	}
+	if !gotoken.IsIdentifier(pkg) {
+		return nil, fmt.Errorf("invalid Go package name: %q", pkg)
+	}
+	if !gotoken.IsIdentifier(funcName) {
+		return nil, fmt.Errorf("invalid Go function name: %q", funcName)
+	}
+	return &GoEncoder{pkg: pkg, funcName: funcName, upsert: upsert}, nil
+}
+
+func (e *GoEncoder) BeginDump(out io.Writer, dump *DumpInfo) error {
+	if dump.Resumed {
+		return fmt.Errorf("%s format does not support resuming a dump", FormatGo)
+	}
+	description := "the records"
+	if !dump.ReadTimestamp.IsZero() {
+		description = "the records read at " + dump.ReadTimestamp.UTC().Format(time.RFC3339Nano)
+	}
+	_, err := fmt.Fprintf(out, `// Code generated by spanner-dump-where. DO NOT EDIT.
+
+package %s
+
+import (
+	"encoding/json"
+	"math"
+	"math/big"
+	"time"
+
+	"cloud.google.com/go/civil"
+	"cloud.google.com/go/spanner"
+)
+
+// Blank references keep the imports used regardless of the types of the records.
+var (
+	_ = json.RawMessage(nil)
+	_ = math.Inf
+	_ = big.NewRat
+	_ = time.UTC
+	_ = civil.Date{}
+)
+
+// %s returns mutations to insert %s.
+func %s() []*spanner.Mutation {
+	return []*spanner.Mutation{
+`, e.pkg, e.funcName, description, e.funcName)
+	return err
+}
+
+func (e *GoEncoder) BeginTable(out io.Writer, table *Table) (TableEncoder, error) {
+	columns := make([]string, len(table.Columns))
+	for i, column := range table.Columns {
+		columns[i] = strconv.Quote(column)
+	}
+	function := "spanner.Insert"
+	if e.upsert {
+		function = "spanner.InsertOrUpdate"
+	}
+	if _, err := fmt.Fprintf(out, "\t\t// %s\n", table.Name); err != nil {
+		return nil, err
+	}
+	return &goTableEncoder{
+		out:    out,
+		table:  table,
+		prefix: fmt.Sprintf("\t\t%s(%s, []string{%s}, []any{", function, strconv.Quote(table.Name), strings.Join(columns, ", ")),
+	}, nil
+}
+
+func (e *GoEncoder) EndDump(out io.Writer) error {
+	_, err := io.WriteString(out, "\t}\n}\n")
+	return err
+}
+
+type goTableEncoder struct {
+	out    io.Writer
+	table  *Table
+	prefix string
+}
+
+func (e *goTableEncoder) WriteRow(values []spanner.GenericColumnValue) error {
+	sb := &strings.Builder{}
+	sb.WriteString(e.prefix)
+	for i, v := range values {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		literal, err := goLiteral(v.Type, v.Value)
+		if err != nil {
+			return fmt.Errorf("failed to encode column %s: %v", e.table.Columns[i], err)
+		}
+		sb.WriteString(literal)
+	}
+	sb.WriteString("}),\n")
+	_, err := io.WriteString(e.out, sb.String())
+	return err
+}
+
+func (e *goTableEncoder) EndTable() error {
+	return nil
+}
+
+// goLiteral returns a typed Go expression of the value, which is a Null type of spanner if the value is NULL.
+func goLiteral(typ *pb.Type, v *structpb.Value) (string, error) {
+	if typ.GetCode() == pb.TypeCode_ARRAY {
+		return goArrayLiteral(typ.GetArrayElementType(), v)
+	}
+	if _, ok := v.GetKind().(*structpb.Value_NullValue); ok {
+		return goNullLiteral(typ, v)
+	}
+	switch typ.GetCode() {
+	case pb.TypeCode_INT64, pb.TypeCode_FLOAT64:
+		s, err := goScalarLiteral(typ, v)
+		if err != nil || strings.HasPrefix(s, "math.") {
+			return s, err
+		}
+		return fmt.Sprintf("%s(%s)", strings.ToLower(typ.GetCode().String()), s), nil
+	default:
+		return goScalarLiteral(typ, v)
+	}
+}
+
+// goArrayLiteral returns a slice of Go values of the element type, or of Null types of spanner if any element is NULL.
+func goArrayLiteral(elemType *pb.Type, v *structpb.Value) (string, error) {
+	plainType, nullType, err := goTypeNames(elemType)
+	if err != nil {
+		return "", err
+	}
+	if _, ok := v.GetKind().(*structpb.Value_NullValue); ok {
+		return fmt.Sprintf("[]%s(nil)", plainType), nil
+	}
+	list, ok := v.GetKind().(*structpb.Value_ListValue)
+	if !ok {
+		return "", fmt.Errorf("unexpected value for ARRAY: %v", v)
+	}
+	elemLiteral, sliceType := goScalarLiteral, plainType
+	for _, e := range list.ListValue.GetValues() {
+		if _, ok := e.GetKind().(*structpb.Value_NullValue); ok {
+			elemLiteral, sliceType = goNullLiteral, nullType
+			break
+		}
+	}
+	elems := make([]string, len(list.ListValue.GetValues()))
+	for i, e := range list.ListValue.GetValues() {
+		if elems[i], err = elemLiteral(elemType, e); err != nil {
+			return "", err
+		}
+	}
+	return fmt.Sprintf("[]%s{%s}", sliceType, strings.Join(elems, ", ")), nil
+}
+
+// goTypeNames returns the Go type of non-NULL values of the type and the Go type which can hold NULL.
+func goTypeNames(typ *pb.Type) (plainType, nullType string, err error) {
+	switch typ.GetCode() {
+	case pb.TypeCode_BOOL:
+		return "bool", "spanner.NullBool", nil
+	case pb.TypeCode_INT64:
+		return "int64", "spanner.NullInt64", nil
+	case pb.TypeCode_FLOAT64:
+		return "float64", "spanner.NullFloat64", nil
+	case pb.TypeCode_STRING:
+		return "string", "spanner.NullString", nil
+	case pb.TypeCode_BYTES:
+		return "[]byte", "[]byte", nil
+	case pb.TypeCode_NUMERIC:
+		return "big.Rat", "spanner.NullNumeric", nil
+	case pb.TypeCode_DATE:
+		return "civil.Date", "spanner.NullDate", nil
+	case pb.TypeCode_TIMESTAMP:
+		return "time.Time", "spanner.NullTime", nil
+	case pb.TypeCode_JSON:
+		return "spanner.NullJSON", "spanner.NullJSON", nil
+	default:
+		return "", "", fmt.Errorf("unsupported type: %s", typeName(typ))
+	}
+}
+
+// goNullLiteral returns a Go expression of the value as a Null type of spanner, which can also hold NULL.
+func goNullLiteral(typ *pb.Type, v *structpb.Value) (string, error) {
+	_, nullType, err := goTypeNames(typ)
+	if err != nil {
+		return "", err
+	}
+	if _, ok := v.GetKind().(*structpb.Value_NullValue); ok {
+		if typ.GetCode() == pb.TypeCode_BYTES {
+			return "[]byte(nil)", nil
+		}
+		return nullType + "{}", nil
+	}
+	s, err := goScalarLiteral(typ, v)
+	if err != nil {
+		return "", err
+	}
+	switch typ.GetCode() {
+	case pb.TypeCode_BYTES, pb.TypeCode_JSON:
+		return s, nil
+	case pb.TypeCode_BOOL:
+		return fmt.Sprintf("%s{Bool: %s, Valid: true}", nullType, s), nil
+	case pb.TypeCode_INT64:
+		return fmt.Sprintf("%s{Int64: %s, Valid: true}", nullType, s), nil
+	case pb.TypeCode_FLOAT64:
+		return fmt.Sprintf("%s{Float64: %s, Valid: true}", nullType, s), nil
+	case pb.TypeCode_STRING:
+		return fmt.Sprintf("%s{StringVal: %s, Valid: true}", nullType, s), nil
+	case pb.TypeCode_NUMERIC:
+		return fmt.Sprintf("%s{Numeric: %s, Valid: true}", nullType, s), nil
+	case pb.TypeCode_DATE:
+		return fmt.Sprintf("%s{Date: %s, Valid: true}", nullType, s), nil
+	default:
+		return fmt.Sprintf("%s{Time: %s, Valid: true}", nullType, s), nil
+	}
+}
+
+// goScalarLiteral returns a Go expression of the non-NULL value, which is an untyped constant for BOOL, INT64, FLOAT64, and STRING.
+func goScalarLiteral(typ *pb.Type, v *structpb.Value) (string, error) {
+	switch typ.GetCode() {
+	case pb.TypeCode_BOOL:
+		b, ok := v.GetKind().(*structpb.Value_BoolValue)
+		if !ok {
+			return "", fmt.Errorf("unexpected value for BOOL: %v", v)
+		}
+		return strconv.FormatBool(b.BoolValue), nil
+	case pb.TypeCode_FLOAT64:
+		var f float64
+		switch k := v.GetKind().(type) {
+		case *structpb.Value_NumberValue:
+			f = k.NumberValue
+		case *structpb.Value_StringValue:
+			// NaN and infinities are encoded in strings.
+			var err error
+			if f, err = strconv.ParseFloat(k.StringValue, 64); err != nil {
+				return "", fmt.Errorf("unexpected value for FLOAT64: %v", v)
+			}
+		default:
+			return "", fmt.Errorf("unexpected value for FLOAT64: %v", v)
+		}
+		switch {
+		case math.IsNaN(f):
+			return "math.NaN()", nil
+		case math.IsInf(f, 1):
+			return "math.Inf(1)", nil
+		case math.IsInf(f, -1):
+			return "math.Inf(-1)", nil
+		}
+		s := strconv.FormatFloat(f, 'g', -1, 64)
+		if f == 0 && math.Signbit(f) {
+			// -0 is a constant 0 in Go.
+			return "math.Copysign(0, -1)", nil
+		}
+		return s, nil
+	}
+
+	s, ok := v.GetKind().(*structpb.Value_StringValue)
+	if !ok {
+		return "", fmt.Errorf("unexpected value for %s: %v", typeName(typ), v)
+	}
+	switch typ.GetCode() {
+	case pb.TypeCode_INT64:
+		if _, err := strconv.ParseInt(s.StringValue, 10, 64); err != nil {
+			return "", fmt.Errorf("invalid INT64 %q: %v", s.StringValue, err)
+		}
+		return s.StringValue, nil
+	case pb.TypeCode_STRING:
+		return strconv.Quote(s.StringValue), nil
+	case pb.TypeCode_BYTES:
+		b, err := base64.StdEncoding.DecodeString(s.StringValue)
+		if err != nil {
+			return "", fmt.Errorf("invalid BYTES %q: %v", s.StringValue, err)
+		}
+		return fmt.Sprintf("[]byte(%s)", strconv.Quote(string(b))), nil
+	case pb.TypeCode_NUMERIC:
+		r, ok := new(big.Rat).SetString(s.StringValue)
+		if !ok {
+			return "", fmt.Errorf("invalid NUMERIC %q", s.StringValue)
+		}
+		if r.Num().IsInt64() && r.Denom().IsInt64() {
+			return fmt.Sprintf("*big.NewRat(%s, %s)", r.Num(), r.Denom()), nil
+		}
+		return fmt.Sprintf("func() big.Rat { r, _ := new(big.Rat).SetString(%s); return *r }()", strconv.Quote(s.StringValue)), nil
+	case pb.TypeCode_DATE:
+		d, err := civil.ParseDate(s.StringValue)
+		if err != nil {
+			return "", fmt.Errorf("invalid DATE %q: %v", s.StringValue, err)
+		}
+		return fmt.Sprintf("civil.Date{Year: %d, Month: %d, Day: %d}", d.Year, d.Month, d.Day), nil
+	case pb.TypeCode_TIMESTAMP:
+		t, err := time.Parse(time.RFC3339Nano, s.StringValue)
+		if err != nil {
+			return "", fmt.Errorf("invalid TIMESTAMP %q: %v", s.StringValue, err)
+		}
+		t = t.UTC()
+		return fmt.Sprintf("time.Date(%d, time.%s, %d, %d, %d, %d, %d, time.UTC)", t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond()), nil
+	case pb.TypeCode_JSON:
+		return fmt.Sprintf("spanner.NullJSON{Value: json.RawMessage(%s), Valid: true}", strconv.Quote(s.StringValue)), nil
+	default:
+		return "", fmt.Errorf("unsupported type: %s", typeName(typ))
+	}
+}
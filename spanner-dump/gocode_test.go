@@ -0,0 +1,88 @@
+package spanner_dump
+
+import (
+	"go/format"
+	"strings"
+	"testing"
+)
+
+func TestGoLiteral(t *testing.T) {
+	for _, tt := range []struct {
+		typ     string
+		literal string
+		want    string
+	}{
+		{typ: "INT64", literal: "-1", want: "int64(-1)"},
+		{typ: "INT64", literal: "NULL", want: "spanner.NullInt64{}"},
+		{typ: "FLOAT64", literal: "1.5", want: "float64(1.5)"},
+		{typ: "FLOAT64", literal: `CAST("-inf" AS FLOAT64)`, want: "math.Inf(-1)"},
+		{typ: "STRING(MAX)", literal: `"a\"b"`, want: `"a\"b"`},
+		{typ: "BOOL", literal: "false", want: "false"},
+		{typ: "BYTES(MAX)", literal: `b"\x00a"`, want: `[]byte("\x00a")`},
+		{typ: "BYTES(MAX)", literal: "NULL", want: "[]byte(nil)"},
+		{typ: "NUMERIC", literal: `NUMERIC "-0.25"`, want: "*big.NewRat(-1, 4)"},
+		{typ: "NUMERIC", literal: `NUMERIC "99999999999999999999.5"`, want: `func() big.Rat { r, _ := new(big.Rat).SetString("99999999999999999999.5"); return *r }()`},
+		{typ: "DATE", literal: `DATE "2020-01-23"`, want: "civil.Date{Year: 2020, Month: 1, Day: 23}"},
+		{typ: "TIMESTAMP", literal: `TIMESTAMP "2020-01-23T03:04:05.000000006Z"`, want: "time.Date(2020, time.January, 23, 3, 4, 5, 6, time.UTC)"},
+		{typ: "JSON", literal: `JSON "{\"a\":1}"`, want: `spanner.NullJSON{Value: json.RawMessage("{\"a\":1}"), Valid: true}`},
+		{typ: "ARRAY<INT64>", literal: "[1, 2]", want: "[]int64{1, 2}"},
+		{typ: "ARRAY<INT64>", literal: "[1, NULL]", want: "[]spanner.NullInt64{spanner.NullInt64{Int64: 1, Valid: true}, spanner.NullInt64{}}"},
+		{typ: "ARRAY<STRING(MAX)>", literal: "NULL", want: "[]string(nil)"},
+		{typ: "ARRAY<DATE>", literal: "[]", want: "[]civil.Date{}"},
+	} {
+		t.Run(tt.typ+" "+tt.literal, func(t *testing.T) {
+			table := &Table{Name: "t", Columns: []string{"C"}, ColumnTypes: []string{tt.typ}}
+			v := literalValues(t, table, tt.literal)[0]
+			got, err := goLiteral(v.Type, v.Value)
+			if err != nil {
+				t.Fatalf("goLiteral() failed: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("goLiteral(): got = %s, want = %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConverter_Convert_go(t *testing.T) {
+	out := &strings.Builder{}
+	c, err := NewConverter(FormatGo, out, EncoderConfig{Upsert: true})
+	if err != nil {
+		t.Fatalf("NewConverter() failed: %v", err)
+	}
+	if err := c.Convert(strings.NewReader(testDump)); err != nil {
+		t.Fatalf("Convert() failed: %v", err)
+	}
+	if err := c.Close(); err != nil {
+		t.Fatalf("Close() failed: %v", err)
+	}
+
+	got := out.String()
+	formatted, err := format.Source([]byte(got))
+	if err != nil {
+		t.Fatalf("output is not valid Go source: %v\n%s", err, got)
+	}
+	if string(formatted) != got {
+		t.Errorf("output is not formatted:\n%s", got)
+	}
+	for _, want := range []string{
+		"package fixtures\n",
+		"func Mutations() []*spanner.Mutation {\n",
+		"\t\t// t1\n",
+		`spanner.InsertOrUpdate("t1", []string{"Id", "Name", "Data", "Tags"}, []any{int64(1), "foo", []byte("ab"), []spanner.NullString{spanner.NullString{StringVal: "a", Valid: true}, spanner.NullString{}}}),`,
+		`spanner.InsertOrUpdate("t1", []string{"Id", "Name", "Data", "Tags"}, []any{int64(2), spanner.NullString{}, []byte(nil), []string(nil)}),`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("output does not contain %q:\n%s", want, got)
+		}
+	}
+}
+
+func TestNewGoEncoder(t *testing.T) {
+	if _, err := NewGoEncoder("my-fixtures", "", false); err == nil {
+		t.Errorf("NewGoEncoder() with an invalid package name succeeded")
+	}
+	if _, err := NewGoEncoder("", "1Mutations", false); err == nil {
+		t.Errorf("NewGoEncoder() with an invalid function name succeeded")
+	}
+}
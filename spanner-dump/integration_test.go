@@ -21,6 +21,7 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 	"sync/atomic"
 	"testing"
@@ -184,7 +185,7 @@ func TestDump(t *testing.T) {
 	defer tearDown()
 
 	out := &bytes.Buffer{}
-	dumper, err := NewDumper(ctx, testProjectId, testInstanceId, databaseId, out, nil, 1, nil, false, false)
+	dumper, err := NewDumper(ctx, fmt.Sprintf("projects/%s/instances/%s/databases/%s", testProjectId, testInstanceId, databaseId), WithOutput(out), WithBulkSize(1))
 	if err != nil {
 		t.Fatalf("failed to create dumper: %v", err)
 	}
@@ -208,4 +209,70 @@ func TestDump(t *testing.T) {
 	if got != want {
 		t.Errorf("DumpTables() = %q, but want = %q", got, want)
 	}
+	out.Reset()
+	parallelDumper, err := NewDumper(ctx, fmt.Sprintf("projects/%s/instances/%s/databases/%s", testProjectId, testInstanceId, databaseId), WithOutput(out), WithBulkSize(1), WithParallelism(3))
+	if err != nil {
+		t.Fatalf("failed to create dumper: %v", err)
+	}
+	if err := parallelDumper.DumpTables(ctx); err != nil {
+		t.Fatalf("failed to dump tables in parallel: %v", err)
+	}
+	if got := out.String(); got != want {
+		t.Errorf("DumpTables() in parallel = %q, but want = %q", got, want)
+	}
+}
+
+func TestLoadDump(t *testing.T) {
+	if skipIntegrateTest {
+		t.Skip("skip integration test")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 180*time.Second)
+	defer cancel()
+
+	databaseId, tearDown := setup(t, ctx, nil, nil)
+	defer tearDown()
+	dbPath := fmt.Sprintf("projects/%s/instances/%s/databases/%s", testProjectId, testInstanceId, databaseId)
+
+	ddls := "CREATE TABLE t1 (\n  Id INT64 NOT NULL,\n  Name STRING(MAX),\n) PRIMARY KEY(Id);\n" +
+		"CREATE INDEX idx ON t1(Name);\n" +
+		"CREATE TABLE t2 (\n  Id INT64 NOT NULL,\n  T2Id INT64 NOT NULL,\n) PRIMARY KEY(Id, T2Id),\n  INTERLEAVE IN PARENT t1 ON DELETE CASCADE;\n"
+	dmls := "INSERT INTO `t1` (`Id`, `Name`) VALUES (1, \"foo\"), (2, NULL);\n" +
+		"INSERT INTO `t2` (`Id`, `T2Id`) VALUES (1, 1), (1, 2);\n"
+	file := filepath.Join(t.TempDir(), "dump.sql")
+	if err := os.WriteFile(file, []byte(ddls+dmls), 0644); err != nil {
+		t.Fatalf("failed to write dump: %v", err)
+	}
+
+	client, err := spanner.NewClient(ctx, dbPath)
+	if err != nil {
+		t.Fatalf("failed to create spanner client: %v", err)
+	}
+	defer client.Close()
+	adminClient, err := adminapi.NewDatabaseAdminClient(ctx)
+	if err != nil {
+		t.Fatalf("failed to create spanner admin client: %v", err)
+	}
+	defer adminClient.Close()
+
+	progress := &bytes.Buffer{}
+	if err := LoadDump(ctx, client, adminClient, []string{file}, progress); err != nil {
+		t.Fatalf("LoadDump() failed: %v", err)
+	}
+	if !strings.Contains(progress.String(), "Applied 3 DDL statements") || !strings.Contains(progress.String(), "Loaded 2 records into t2") {
+		t.Errorf("LoadDump(): progress = %q", progress.String())
+	}
+
+	out := &bytes.Buffer{}
+	dumper, err := NewDumper(ctx, dbPath, WithOutput(out), WithTable("t1", "TRUE"), WithTable("t2", "TRUE"), WithClient(client), WithAdminClient(adminClient))
+	if err != nil {
+		t.Fatalf("failed to create dumper: %v", err)
+	}
+	defer dumper.Cleanup()
+	if err := dumper.DumpTables(ctx); err != nil {
+		t.Fatalf("failed to dump tables: %v", err)
+	}
+	if got := out.String(); got != dmls {
+		t.Errorf("DumpTables() after LoadDump() = %q, but want = %q", got, dmls)
+	}
 }
@@ -0,0 +1,226 @@
+// Package sqltoken splits GoogleSQL statements into tokens,
+// which is shared by the parser of dump files and the query parser of spannerfake.
+package sqltoken
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Kind is a kind of tokens.
+type Kind int
+
+const (
+	EOF Kind = iota
+	Ident
+	Number
+	String
+	Bytes
+	Param
+	Symbol
+)
+
+// Token is a token of a statement.
+type Token struct {
+	Kind Kind
+	// Text is an unescaped value for string, bytes and quoted identifier tokens, or a name for parameter tokens.
+	Text string
+	// Quoted is true if an identifier is enclosed by backticks.
+	Quoted bool
+}
+
+// Tokenize splits a single SQL statement into tokens, skipping comments.
+func Tokenize(stmt string) ([]Token, error) {
+	var tokens []Token
+	for i := 0; i < len(stmt); {
+		c := stmt[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '#' || strings.HasPrefix(stmt[i:], "--"):
+			for i < len(stmt) && stmt[i] != '\n' {
+				i++
+			}
+		case strings.HasPrefix(stmt[i:], "/*"):
+			end := strings.Index(stmt[i+2:], "*/")
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated block comment")
+			}
+			i += end + 4
+		case c == '`':
+			text, n, err := UnquoteLiteral(stmt[i:], false)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, Token{Kind: Ident, Text: text, Quoted: true})
+			i += n
+		case c == '"' || c == '\'':
+			text, n, err := UnquoteLiteral(stmt[i:], false)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, Token{Kind: String, Text: text})
+			i += n
+		case isLiteralPrefix(stmt[i:]):
+			prefix := strings.ToLower(stmt[i : i+strings.IndexAny(stmt[i:], `"'`)])
+			text, n, err := UnquoteLiteral(stmt[i+len(prefix):], strings.Contains(prefix, "r"))
+			if err != nil {
+				return nil, err
+			}
+			kind := String
+			if strings.Contains(prefix, "b") {
+				kind = Bytes
+			}
+			tokens = append(tokens, Token{Kind: kind, Text: text})
+			i += len(prefix) + n
+		case c == '@' || isIdentStart(c):
+			j := i + 1
+			for j < len(stmt) && (isIdentStart(stmt[j]) || '0' <= stmt[j] && stmt[j] <= '9') {
+				j++
+			}
+			if c == '@' {
+				tokens = append(tokens, Token{Kind: Param, Text: stmt[i+1 : j]})
+			} else {
+				tokens = append(tokens, Token{Kind: Ident, Text: stmt[i:j]})
+			}
+			i = j
+		case '0' <= c && c <= '9' || c == '.' && i+1 < len(stmt) && '0' <= stmt[i+1] && stmt[i+1] <= '9':
+			j := i
+			for j < len(stmt) {
+				d := stmt[j]
+				if (d == '+' || d == '-') && (stmt[j-1] == 'e' || stmt[j-1] == 'E') && !strings.HasPrefix(strings.ToLower(stmt[i:j]), "0x") {
+					j++
+					continue
+				}
+				if d == '.' || d == '_' || '0' <= d && d <= '9' || 'a' <= d && d <= 'z' || 'A' <= d && d <= 'Z' {
+					j++
+					continue
+				}
+				break
+			}
+			tokens = append(tokens, Token{Kind: Number, Text: stmt[i:j]})
+			i = j
+		default:
+			n := 1
+			for _, symbol := range []string{"<=", ">=", "!=", "<>"} {
+				if strings.HasPrefix(stmt[i:], symbol) {
+					n = len(symbol)
+				}
+			}
+			tokens = append(tokens, Token{Kind: Symbol, Text: stmt[i : i+n]})
+			i += n
+		}
+	}
+	return tokens, nil
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || c < utf8.RuneSelf && unicode.IsLetter(rune(c))
+}
+
+func isLiteralPrefix(s string) bool {
+	for _, prefix := range []string{"rb", "br", "r", "b"} {
+		if len(s) > len(prefix) && strings.EqualFold(s[:len(prefix)], prefix) && (s[len(prefix)] == '"' || s[len(prefix)] == '\'') {
+			return true
+		}
+	}
+	return false
+}
+
+// UnquoteLiteral unquotes a quoted literal at the beginning of s.
+// It returns the unescaped value and the length of the consumed literal.
+func UnquoteLiteral(s string, raw bool) (string, int, error) {
+	quote := s[:1]
+	if quote != "`" && strings.HasPrefix(s, strings.Repeat(quote, 3)) {
+		quote = strings.Repeat(quote, 3)
+	}
+	sb := &strings.Builder{}
+	for i := len(quote); i < len(s); {
+		if strings.HasPrefix(s[i:], quote) {
+			return sb.String(), i + len(quote), nil
+		}
+		if s[i] != '\\' {
+			sb.WriteByte(s[i])
+			i++
+			continue
+		}
+		if i+1 >= len(s) {
+			break
+		}
+		if raw {
+			sb.WriteString(s[i : i+2])
+			i += 2
+			continue
+		}
+		n, err := unescape(sb, s[i:])
+		if err != nil {
+			return "", 0, err
+		}
+		i += n
+	}
+	return "", 0, fmt.Errorf("unterminated quoted literal: %s", s)
+}
+
+// unescape writes a value of the escape sequence at the beginning of s and returns its length.
+// See: https://cloud.google.com/spanner/docs/reference/standard-sql/lexical#escape_sequences
+func unescape(sb *strings.Builder, s string) (int, error) {
+	switch c := s[1]; c {
+	case 'a':
+		sb.WriteByte('\a')
+	case 'b':
+		sb.WriteByte('\b')
+	case 'f':
+		sb.WriteByte('\f')
+	case 'n':
+		sb.WriteByte('\n')
+	case 'r':
+		sb.WriteByte('\r')
+	case 't':
+		sb.WriteByte('\t')
+	case 'v':
+		sb.WriteByte('\v')
+	case '\\', '?', '"', '\'', '`':
+		sb.WriteByte(c)
+	case 'x', 'X':
+		if len(s) < 4 {
+			return 0, fmt.Errorf("invalid escape sequence: %s", s)
+		}
+		v, err := strconv.ParseUint(s[2:4], 16, 8)
+		if err != nil {
+			return 0, fmt.Errorf("invalid escape sequence: %s", s[:4])
+		}
+		sb.WriteByte(byte(v))
+		return 4, nil
+	case 'u', 'U':
+		n := 4
+		if c == 'U' {
+			n = 8
+		}
+		if len(s) < 2+n {
+			return 0, fmt.Errorf("invalid escape sequence: %s", s)
+		}
+		v, err := strconv.ParseUint(s[2:2+n], 16, 32)
+		if err != nil {
+			return 0, fmt.Errorf("invalid escape sequence: %s", s[:2+n])
+		}
+		sb.WriteRune(rune(v))
+		return 2 + n, nil
+	default:
+		if '0' <= c && c <= '7' {
+			if len(s) < 4 {
+				return 0, fmt.Errorf("invalid escape sequence: %s", s)
+			}
+			v, err := strconv.ParseUint(s[1:4], 8, 8)
+			if err != nil {
+				return 0, fmt.Errorf("invalid escape sequence: %s", s[:4])
+			}
+			sb.WriteByte(byte(v))
+			return 4, nil
+		}
+		return 0, fmt.Errorf("invalid escape sequence: %s", s[:2])
+	}
+	return 2, nil
+}
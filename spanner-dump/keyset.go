@@ -0,0 +1,170 @@
+package spanner_dump
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/spanner"
+	"google.golang.org/grpc/codes"
+)
+
+// maxPageAttempts is the maximum number of attempts to read a page.
+const maxPageAttempts = 5
+
+// pageRetryDelay is the delay before the first retry of a page, which doubles on each retry.
+var pageRetryDelay = time.Second
+
+// dumpPages reads records selected by sql in pages of d.pageSize records in the primary key order,
+// continuing from the primary key of the last record of the previous page.
+// Each page is read entirely before written so that it can be retried without writing duplicated records.
+// If lastKey is not nil, records after the primary key are dumped.
+func (d *Dumper) dumpPages(ctx context.Context, table *Table, txn *spanner.ReadOnlyTransaction, sql string, lastKey []string, w *tableWriter) error {
+	for {
+		pageSQL := sql
+		if lastKey != nil {
+			pageSQL += " AND (" + keyAfterCondition(table.PrimaryKey, lastKey) + ")"
+		}
+		pageSQL += fmt.Sprintf(" %s LIMIT %d", orderByPrimaryKey(table.PrimaryKey), d.pageSize)
+
+		var rows [][]spanner.GenericColumnValue
+		err := retryPage(ctx, func() error {
+			rows = nil
+			return txn.QueryWithOptions(ctx, spanner.NewStatement(pageSQL), d.dataQueryOptions()).Do(func(row *spanner.Row) error {
+				values, err := rowValues(row)
+				if err != nil {
+					return err
+				}
+				rows = append(rows, values)
+				return nil
+			})
+		})
+		if err != nil {
+			return fmt.Errorf("failed to read page: %v", err)
+		}
+
+		for _, values := range rows {
+			if err := w.write(values); err != nil {
+				return err
+			}
+		}
+		if uint(len(rows)) < d.pageSize {
+			return nil
+		}
+		lastRow, err := decodeValues(rows[len(rows)-1])
+		if err != nil {
+			return err
+		}
+		lastKey, _ = primaryKeyValues(table, lastRow)
+	}
+}
+
+// retryPage calls read until it succeeds, retrying with exponential backoff while it fails with transient errors.
+func retryPage(ctx context.Context, read func() error) error {
+	delay := pageRetryDelay
+	for attempt := 1; ; attempt++ {
+		err := read()
+		if err == nil || attempt >= maxPageAttempts || ctx.Err() != nil || !isTransient(err) {
+			return err
+		}
+		log.Printf("Retrying to read a page in %v: %v", delay, err)
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		delay *= 2
+	}
+}
+
+func isTransient(err error) bool {
+	switch spanner.ErrCode(err) {
+	case codes.Unavailable, codes.DeadlineExceeded, codes.Aborted, codes.ResourceExhausted, codes.Internal:
+		return true
+	default:
+		return false
+	}
+}
+
+// hasDumpedPrimaryKey reports whether the table has primary key columns and all of them are dumped.
+func (t *Table) hasDumpedPrimaryKey() bool {
+	if len(t.PrimaryKey) == 0 {
+		return false
+	}
+	for _, k := range t.PrimaryKey {
+		if indexOfString(t.Columns, k.Name) < 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// primaryKeyValues extracts values of primary key columns from a decoded row of the table.
+// It returns ok = false if the table has no primary key columns or some of them are not dumped (e.g. generated columns).
+func primaryKeyValues(table *Table, row []string) (key []string, ok bool) {
+	if !table.hasDumpedPrimaryKey() {
+		return nil, false
+	}
+	for _, k := range table.PrimaryKey {
+		key = append(key, row[indexOfString(table.Columns, k.Name)])
+	}
+	return key, true
+}
+
+func indexOfString(ss []string, s string) int {
+	for i, v := range ss {
+		if v == s {
+			return i
+		}
+	}
+	return -1
+}
+
+// keyAfterCondition builds an SQL boolean expression which holds for records after the key in the primary key order.
+// NULL sorts first in ascending order and last in descending order.
+func keyAfterCondition(primaryKey []KeyColumn, key []string) string {
+	var disjuncts []string
+	for i := range key {
+		var conjuncts []string
+		for j := 0; j < i; j++ {
+			conjuncts = append(conjuncts, keyEqualCondition(primaryKey[j].Name, key[j]))
+		}
+		after := keyGreaterCondition(primaryKey[i], key[i])
+		if after == "FALSE" {
+			continue
+		}
+		conjuncts = append(conjuncts, after)
+		disjuncts = append(disjuncts, "("+strings.Join(conjuncts, " AND ")+")")
+	}
+	if len(disjuncts) == 0 {
+		return "FALSE"
+	}
+	return strings.Join(disjuncts, " OR ")
+}
+
+func keyEqualCondition(column, value string) string {
+	if value == "NULL" {
+		return fmt.Sprintf("`%s` IS NULL", column)
+	}
+	return fmt.Sprintf("`%s` = %s", column, value)
+}
+
+func keyGreaterCondition(column KeyColumn, value string) string {
+	switch {
+	case value == "NULL" && column.Desc:
+		return "FALSE"
+	case value == "NULL":
+		return fmt.Sprintf("`%s` IS NOT NULL", column.Name)
+	case column.Desc:
+		return fmt.Sprintf("(`%s` < %s OR `%s` IS NULL)", column.Name, value, column.Name)
+	default:
+		return fmt.Sprintf("`%s` > %s", column.Name, value)
+	}
+}
+
+// orderByPrimaryKey builds an ORDER BY clause in the primary key order.
+func orderByPrimaryKey(primaryKey []KeyColumn) string {
+	return "ORDER BY " + keyColumnList(primaryKey)
+}
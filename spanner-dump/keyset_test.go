@@ -0,0 +1,151 @@
+package spanner_dump
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestKeyAfterCondition(t *testing.T) {
+	for _, tt := range []struct {
+		desc       string
+		primaryKey []KeyColumn
+		key        []string
+		want       string
+	}{
+		{
+			desc:       "Single column",
+			primaryKey: []KeyColumn{{Name: "Id"}},
+			key:        []string{"1"},
+			want:       "(`Id` > 1)",
+		},
+		{
+			desc:       "Multiple columns",
+			primaryKey: []KeyColumn{{Name: "A"}, {Name: "B", Desc: true}},
+			key:        []string{`"a"`, "2"},
+			want:       "(`A` > \"a\") OR (`A` = \"a\" AND (`B` < 2 OR `B` IS NULL))",
+		},
+		{
+			desc:       "NULL in ascending order",
+			primaryKey: []KeyColumn{{Name: "A"}, {Name: "B"}},
+			key:        []string{"NULL", "NULL"},
+			want:       "(`A` IS NOT NULL) OR (`A` IS NULL AND `B` IS NOT NULL)",
+		},
+		{
+			desc:       "NULL in descending order",
+			primaryKey: []KeyColumn{{Name: "A", Desc: true}},
+			key:        []string{"NULL"},
+			want:       "FALSE",
+		},
+	} {
+		t.Run(tt.desc, func(t *testing.T) {
+			if got := keyAfterCondition(tt.primaryKey, tt.key); got != tt.want {
+				t.Errorf("keyAfterCondition(): got = %s, want = %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOrderByPrimaryKey(t *testing.T) {
+	got := orderByPrimaryKey([]KeyColumn{{Name: "A"}, {Name: "B", Desc: true}})
+	if want := "ORDER BY `A`, `B` DESC"; got != want {
+		t.Errorf("orderByPrimaryKey(): got = %s, want = %s", got, want)
+	}
+}
+
+func TestPrimaryKeyValues(t *testing.T) {
+	table := &Table{Name: "t", Columns: []string{"B", "A", "C"}, PrimaryKey: []KeyColumn{{Name: "A"}, {Name: "B"}}}
+	key, ok := primaryKeyValues(table, []string{"1", "2", "3"})
+	if !ok || !equalStringSlice(key, []string{"2", "1"}) {
+		t.Errorf("primaryKeyValues(): got = %v, %v", key, ok)
+	}
+
+	table.PrimaryKey = []KeyColumn{{Name: "Generated"}}
+	if _, ok := primaryKeyValues(table, []string{"1", "2", "3"}); ok {
+		t.Errorf("primaryKeyValues() with a key column not dumped: ok = true, want = false")
+	}
+}
+
+func TestRetryPage(t *testing.T) {
+	defer func(d time.Duration) { pageRetryDelay = d }(pageRetryDelay)
+	pageRetryDelay = time.Millisecond
+
+	permanent := errors.New("permanent")
+	for _, tt := range []struct {
+		desc         string
+		errs         []error
+		wantAttempts int
+		wantErr      bool
+	}{
+		{
+			desc:         "Success",
+			errs:         []error{nil},
+			wantAttempts: 1,
+		},
+		{
+			desc:         "Transient errors",
+			errs:         []error{status.Error(codes.Unavailable, ""), status.Error(codes.DeadlineExceeded, ""), nil},
+			wantAttempts: 3,
+		},
+		{
+			desc:         "Permanent error",
+			errs:         []error{status.Error(codes.Unavailable, ""), permanent},
+			wantAttempts: 2,
+			wantErr:      true,
+		},
+		{
+			desc:         "Too many attempts",
+			errs:         []error{status.Error(codes.Aborted, ""), status.Error(codes.Aborted, ""), status.Error(codes.Aborted, ""), status.Error(codes.Aborted, ""), status.Error(codes.Aborted, ""), nil},
+			wantAttempts: maxPageAttempts,
+			wantErr:      true,
+		},
+	} {
+		t.Run(tt.desc, func(t *testing.T) {
+			attempts := 0
+			err := retryPage(context.Background(), func() error {
+				attempts++
+				return tt.errs[attempts-1]
+			})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("retryPage(): err = %v, wantErr = %v", err, tt.wantErr)
+			}
+			if attempts != tt.wantAttempts {
+				t.Errorf("retryPage(): attempts = %d, want = %d", attempts, tt.wantAttempts)
+			}
+		})
+	}
+}
+
+func TestHasDumpedPrimaryKey(t *testing.T) {
+	for _, tt := range []struct {
+		desc  string
+		table *Table
+		want  bool
+	}{
+		{
+			desc:  "Dumped primary key",
+			table: &Table{Columns: []string{"A", "B"}, PrimaryKey: []KeyColumn{{Name: "B"}, {Name: "A", Desc: true}}},
+			want:  true,
+		},
+		{
+			desc:  "No primary key",
+			table: &Table{Columns: []string{"A"}},
+			want:  false,
+		},
+		{
+			desc:  "Generated primary key",
+			table: &Table{Columns: []string{"A"}, PrimaryKey: []KeyColumn{{Name: "A"}, {Name: "G"}}},
+			want:  false,
+		},
+	} {
+		t.Run(tt.desc, func(t *testing.T) {
+			if got := tt.table.hasDumpedPrimaryKey(); got != tt.want {
+				t.Errorf("hasDumpedPrimaryKey(): got = %v, want = %v", got, tt.want)
+			}
+		})
+	}
+}
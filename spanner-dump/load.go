@@ -0,0 +1,273 @@
+package spanner_dump
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"cloud.google.com/go/spanner"
+	adminapi "cloud.google.com/go/spanner/admin/database/apiv1"
+	adminpb "cloud.google.com/go/spanner/admin/database/apiv1/databasepb"
+	instanceapi "cloud.google.com/go/spanner/admin/instance/apiv1"
+	instancepb "cloud.google.com/go/spanner/admin/instance/apiv1/instancepb"
+	pb "google.golang.org/genproto/googleapis/spanner/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// EmulatorInstanceConfig is the instance configuration of the Cloud Spanner emulator.
+const EmulatorInstanceConfig = "emulator-config"
+
+// CreateDatabaseIfNotExists creates the instance and the database specified by dbPath if they do not exist,
+// where the instance is created with instanceConfig, e.g. EmulatorInstanceConfig for the emulator.
+// Created resources are reported to progress if not nil.
+func CreateDatabaseIfNotExists(ctx context.Context, instanceAdminClient *instanceapi.InstanceAdminClient, adminClient *adminapi.DatabaseAdminClient, dbPath string, instanceConfig string, progress io.Writer) error {
+	match := databasePathRegexp.FindStringSubmatch(dbPath)
+	if match == nil {
+		return fmt.Errorf("invalid database path %q: expected projects/<project>/instances/<instance>/databases/<database>", dbPath)
+	}
+	projectPath := "projects/" + match[1]
+	instancePath := projectPath + "/instances/" + match[2]
+
+	if _, err := instanceAdminClient.GetInstance(ctx, &instancepb.GetInstanceRequest{Name: instancePath}); status.Code(err) == codes.NotFound {
+		op, err := instanceAdminClient.CreateInstance(ctx, &instancepb.CreateInstanceRequest{
+			Parent:     projectPath,
+			InstanceId: match[2],
+			Instance: &instancepb.Instance{
+				Name:        instancePath,
+				Config:      projectPath + "/instanceConfigs/" + instanceConfig,
+				DisplayName: match[2],
+				NodeCount:   1,
+			},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create instance: %v", err)
+		}
+		if _, err := op.Wait(ctx); err != nil {
+			return fmt.Errorf("failed to create instance: %v", err)
+		}
+		if progress != nil {
+			fmt.Fprintf(progress, "Created instance %s\n", instancePath)
+		}
+	} else if err != nil {
+		return fmt.Errorf("failed to get instance: %v", err)
+	}
+
+	if _, err := adminClient.GetDatabase(ctx, &adminpb.GetDatabaseRequest{Name: dbPath}); status.Code(err) == codes.NotFound {
+		op, err := adminClient.CreateDatabase(ctx, &adminpb.CreateDatabaseRequest{
+			Parent:          instancePath,
+			CreateStatement: fmt.Sprintf("CREATE DATABASE `%s`", match[3]),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create database: %v", err)
+		}
+		if _, err := op.Wait(ctx); err != nil {
+			return fmt.Errorf("failed to create database: %v", err)
+		}
+		if progress != nil {
+			fmt.Fprintf(progress, "Created database %s\n", dbPath)
+		}
+	} else if err != nil {
+		return fmt.Errorf("failed to get database: %v", err)
+	}
+	return nil
+}
+
+// LoadDump loads dump files produced by Dumper into the database of the client in the order of files and statements.
+// DDL statements are applied by adminClient in a batch before the following records are inserted,
+// and records of INSERT statements are inserted by commits split to keep the estimated mutations within the limit.
+// The time taken to apply DDL statements and to insert records of each table is written to progress if not nil.
+func LoadDump(ctx context.Context, client *spanner.Client, adminClient *adminapi.DatabaseAdminClient, files []string, progress io.Writer) error {
+	l := &dumpLoader{
+		client:      client,
+		adminClient: adminClient,
+		progress:    progress,
+		columnTypes: map[string]map[string]*pb.Type{},
+		indexes:     map[string][]Index{},
+	}
+	for _, file := range files {
+		if err := l.loadFile(ctx, file); err != nil {
+			return fmt.Errorf("failed to load %s: %v", file, err)
+		}
+	}
+	if err := l.flushMutations(ctx); err != nil {
+		return err
+	}
+	return l.applyDDLs(ctx)
+}
+
+// dumpLoader holds DDL statements and mutations which are not yet applied.
+type dumpLoader struct {
+	client      *spanner.Client
+	adminClient *adminapi.DatabaseAdminClient
+	progress    io.Writer
+
+	columnTypes map[string]map[string]*pb.Type
+	indexes     map[string][]Index
+	ddls        []string
+
+	// table is the table of mutations, whose number of records and the estimated number of mutations are records and mutationCount.
+	table         string
+	mutations     []*spanner.Mutation
+	mutationCount uint
+	records       int
+	started       time.Time
+}
+
+func (l *dumpLoader) loadFile(ctx context.Context, file string) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := newStatementScanner(f)
+	for {
+		stmt, err := scanner.next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		isDDL, err := l.loadDDL(stmt)
+		if err != nil {
+			return err
+		}
+		if isDDL {
+			if err := l.flushMutations(ctx); err != nil {
+				return err
+			}
+			l.ddls = append(l.ddls, stmt)
+			continue
+		}
+
+		insert, ok, err := parseInsert(stmt, l.lookupColumnTypes)
+		if err != nil {
+			return fmt.Errorf("failed to parse statement: %v", err)
+		}
+		if !ok {
+			return fmt.Errorf("unsupported statement: %.100s", stmt)
+		}
+		if err := l.applyDDLs(ctx); err != nil {
+			return err
+		}
+		if err := l.insert(ctx, insert); err != nil {
+			return err
+		}
+	}
+}
+
+// loadDDL loads the table schema of a CREATE TABLE or CREATE INDEX statement and reports whether the statement is a DDL statement.
+func (l *dumpLoader) loadDDL(stmt string) (bool, error) {
+	table, index, ok, err := parseCreateIndex(stmt)
+	if err != nil {
+		return true, fmt.Errorf("failed to parse DDL: %v", err)
+	}
+	if ok {
+		l.indexes[table] = append(l.indexes[table], index)
+		return true, nil
+	}
+
+	name, columns, ok, err := parseCreateTable(stmt)
+	if err != nil {
+		return true, fmt.Errorf("failed to parse DDL: %v", err)
+	}
+	if !ok {
+		return ddlRegexp.MatchString(stmt), nil
+	}
+	types := map[string]*pb.Type{}
+	for _, column := range columns {
+		types[column.name] = column.typ
+	}
+	l.columnTypes[name] = types
+	return true, nil
+}
+
+func (l *dumpLoader) lookupColumnTypes(table string) (map[string]*pb.Type, error) {
+	types, ok := l.columnTypes[table]
+	if !ok {
+		return nil, fmt.Errorf("schema of table %s is not found", table)
+	}
+	return types, nil
+}
+
+func (l *dumpLoader) applyDDLs(ctx context.Context) error {
+	if len(l.ddls) == 0 {
+		return nil
+	}
+	started := time.Now()
+	op, err := l.adminClient.UpdateDatabaseDdl(ctx, &adminpb.UpdateDatabaseDdlRequest{
+		Database:   l.client.DatabaseName(),
+		Statements: l.ddls,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to apply DDL statements: %v", err)
+	}
+	if err := op.Wait(ctx); err != nil {
+		return fmt.Errorf("failed to apply DDL statements: %v", err)
+	}
+	if l.progress != nil {
+		fmt.Fprintf(l.progress, "Applied %d DDL statements in %v\n", len(l.ddls), time.Since(started).Round(time.Millisecond))
+	}
+	l.ddls = nil
+	return nil
+}
+
+func (l *dumpLoader) insert(ctx context.Context, insert *insertStatement) error {
+	if insert.table != l.table {
+		if err := l.flushMutations(ctx); err != nil {
+			return err
+		}
+		l.table, l.records, l.started = insert.table, 0, time.Now()
+	}
+
+	newMutation := spanner.Insert
+	if insert.upsert {
+		newMutation = spanner.InsertOrUpdate
+	}
+	table := &Table{Name: insert.table, Columns: insert.columns, Indexes: l.indexes[insert.table]}
+	n := table.mutationsPerRow()
+	types := l.columnTypes[insert.table]
+	for _, row := range insert.rows {
+		if l.mutationCount > 0 && l.mutationCount+n > defaultMaxMutations {
+			if err := l.commit(ctx); err != nil {
+				return err
+			}
+		}
+		values := make([]any, len(row))
+		for i, v := range row {
+			values[i] = spanner.GenericColumnValue{Type: types[insert.columns[i]], Value: v}
+		}
+		l.mutations = append(l.mutations, newMutation(insert.table, insert.columns, values))
+		l.mutationCount += n
+		l.records++
+	}
+	return nil
+}
+
+func (l *dumpLoader) commit(ctx context.Context) error {
+	if len(l.mutations) == 0 {
+		return nil
+	}
+	if _, err := l.client.Apply(ctx, l.mutations); err != nil {
+		return fmt.Errorf("failed to insert records into table %s: %v", l.table, err)
+	}
+	l.mutations, l.mutationCount = nil, 0
+	return nil
+}
+
+// flushMutations commits the remaining mutations and reports records loaded into the current table.
+func (l *dumpLoader) flushMutations(ctx context.Context) error {
+	if err := l.commit(ctx); err != nil {
+		return err
+	}
+	if l.table != "" && l.progress != nil {
+		fmt.Fprintf(l.progress, "Loaded %d records into %s in %v\n", l.records, l.table, time.Since(l.started).Round(time.Millisecond))
+	}
+	l.table = ""
+	return nil
+}
@@ -0,0 +1,518 @@
+package spanner_dump
+
+import (
+	"bufio"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"cloud.google.com/go/civil"
+	"cloud.google.com/go/spanner"
+	pb "google.golang.org/genproto/googleapis/spanner/v1"
+	structpb "google.golang.org/protobuf/types/known/structpb"
+)
+
+// MaskRule masks values of columns whose table and column names match Table and Column patterns (see path.Match).
+// Strategy is one of the following, where Arg is the text after = in the rule:
+//   - null: replaces values with NULL, which is not allowed for NOT NULL columns.
+//   - constant=VALUE: replaces values with VALUE in the format of the column type, e.g. 2020-01-23 for DATE.
+//   - hash: replaces STRING, BYTES, and INT64 values with their keyed HMAC-SHA256 digests.
+//   - pseudonym: replaces values in the same way as hash, also for all columns connected to the column by foreign keys
+//     and interleaved primary key prefixes, so that the masked records remain joinable and importable.
+//     It takes precedence over other rules for the connected columns.
+//   - email, phone, name: replaces STRING values with fake values derived from their keyed HMAC-SHA256 digests,
+//     where phone keeps non-digit characters and the number of digits, and name keeps whether there is a family name.
+//   - shift=DURATION: shifts DATE and TIMESTAMP values by DURATION such as 30d or -12h, which must be whole days for DATE.
+//   - truncate=N: truncates STRING values to N characters and BYTES values to N bytes,
+//     or TIMESTAMP values to a multiple of a duration such as 1h or 1d.
+//
+// NULL values are kept as they are, and masked STRING and BYTES values are truncated to the length of the column.
+// Primary key and unique index columns can be masked only by hash, pseudonym, and shift, which keep distinct values distinct,
+// and since truncated digests may collide, hash and pseudonym of them require
+// STRING columns of at least minUniqueDigestChars characters and BYTES columns of at least minUniqueDigestBytes bytes.
+// Values of ARRAY columns are masked element by element, while constant does not support ARRAY columns.
+type MaskRule struct {
+	Table    string
+	Column   string
+	Strategy string
+	Arg      string
+}
+
+func (r MaskRule) String() string {
+	s := r.Table + "." + r.Column + ":" + r.Strategy
+	if r.Arg != "" {
+		s += "=" + r.Arg
+	}
+	return s
+}
+
+// minUniqueDigestChars and minUniqueDigestBytes are the lengths of 64-bit digests in hex and bytes,
+// which are as unlikely to collide as digests of INT64 columns.
+const (
+	minUniqueDigestChars = 16
+	minUniqueDigestBytes = 8
+)
+
+var maskStrategies = map[string]bool{
+	"null": false, "constant": true, "hash": false, "pseudonym": false, "email": false, "phone": false, "name": false, "shift": true, "truncate": true,
+}
+
+// ParseMaskRule parses a rule in the format TABLE.COLUMN:STRATEGY[=ARG] such as User.Email:hash or *.CreatedAt:shift=-30d.
+func ParseMaskRule(s string) (MaskRule, error) {
+	target, strategy, ok := strings.Cut(strings.TrimSpace(s), ":")
+	if !ok {
+		return MaskRule{}, fmt.Errorf("invalid mask rule %q: expected TABLE.COLUMN:STRATEGY", s)
+	}
+	i := strings.LastIndex(target, ".")
+	if i < 0 {
+		return MaskRule{}, fmt.Errorf("invalid mask rule %q: expected TABLE.COLUMN:STRATEGY", s)
+	}
+	rule := MaskRule{Table: target[:i], Column: target[i+1:]}
+	rule.Strategy, rule.Arg, _ = strings.Cut(strategy, "=")
+	for _, pattern := range []string{rule.Table, rule.Column} {
+		if _, err := path.Match(pattern, ""); err != nil || pattern == "" {
+			return MaskRule{}, fmt.Errorf("invalid mask rule %q: invalid pattern %q", s, pattern)
+		}
+	}
+	requiresArg, ok := maskStrategies[rule.Strategy]
+	if !ok {
+		return MaskRule{}, fmt.Errorf("invalid mask rule %q: unknown strategy %q", s, rule.Strategy)
+	}
+	if requiresArg && rule.Arg == "" {
+		return MaskRule{}, fmt.Errorf("invalid mask rule %q: %s requires an argument", s, rule.Strategy)
+	}
+	if !requiresArg && rule.Arg != "" {
+		return MaskRule{}, fmt.Errorf("invalid mask rule %q: %s does not take an argument", s, rule.Strategy)
+	}
+	return rule, nil
+}
+
+// LoadMaskRules reads rules one per line, ignoring empty lines and lines beginning with #.
+func LoadMaskRules(r io.Reader) ([]MaskRule, error) {
+	var rules []MaskRule
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		rule, err := ParseMaskRule(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", n, err)
+		}
+		rules = append(rules, rule)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// MaskPolicy masks values of dumped records by the first rule matched by each column.
+// Key is the key of HMAC used by hash, pseudonym, email, phone, and name.
+type MaskPolicy struct {
+	Rules []MaskRule
+	Key   []byte
+}
+
+// tableMasker masks decoded records of a table.
+type tableMasker struct {
+	columns []columnMask
+}
+
+type columnMask struct {
+	index int
+	typ   *pb.Type
+	mask  func(v *structpb.Value) (*structpb.Value, error)
+}
+
+// bind builds maskers of the tables, which checks that the matched rules are applicable to the types and constraints of the columns.
+// refs are column references in the database to find columns to pseudonymize (see pseudonymGroups).
+// Tables without masked columns have no maskers.
+func (p *MaskPolicy) bind(tables []*Table, refs []columnReference) (map[string]*tableMasker, error) {
+	if p == nil || len(p.Rules) == 0 {
+		return nil, nil
+	}
+	groups := p.pseudonymGroups(tables, refs)
+	// Pseudonyms are truncated to the length of the column, so columns in the same group must have the same length.
+	type groupMember struct {
+		column tableColumn
+		typ    string
+	}
+	firstMembers := map[tableColumn]groupMember{}
+	maskers := map[string]*tableMasker{}
+	for _, table := range tables {
+		m := &tableMasker{}
+		for i, column := range table.Columns {
+			rule, ok := p.match(table.Name, column)
+			if group, pseudonymized := groups[tableColumn{table.Name, column}]; pseudonymized {
+				rule, ok = MaskRule{Table: table.Name, Column: column, Strategy: "pseudonym"}, true
+				member := groupMember{column: tableColumn{table.Name, column}, typ: table.ColumnTypes[i]}
+				if first, exists := firstMembers[group]; !exists {
+					firstMembers[group] = member
+				} else if typeLength(first.typ) != typeLength(member.typ) {
+					return nil, fmt.Errorf("failed to mask column %s by %s: the length of %s differs from %s of %s connected to it",
+						member.column, rule, member.typ, first.typ, first.column)
+				}
+			}
+			if !ok {
+				continue
+			}
+			c, err := p.columnMask(rule, table, i)
+			if err != nil {
+				return nil, fmt.Errorf("failed to mask column %s.%s by %s: %v", table.Name, column, rule, err)
+			}
+			m.columns = append(m.columns, c)
+		}
+		if len(m.columns) > 0 {
+			maskers[table.Name] = m
+		}
+	}
+	return maskers, nil
+}
+
+func (p *MaskPolicy) match(table, column string) (MaskRule, bool) {
+	for _, rule := range p.Rules {
+		tableMatched, _ := path.Match(rule.Table, table)
+		columnMatched, _ := path.Match(rule.Column, column)
+		if tableMatched && columnMatched {
+			return rule, true
+		}
+	}
+	return MaskRule{}, false
+}
+
+func (p *MaskPolicy) columnMask(rule MaskRule, table *Table, i int) (columnMask, error) {
+	if i >= len(table.ColumnTypes) || table.ColumnTypes[i] == "" {
+		return columnMask{}, fmt.Errorf("unknown column type")
+	}
+	typ, err := parseSpannerType(table.ColumnTypes[i])
+	if err != nil {
+		return columnMask{}, err
+	}
+	length := typeLength(table.ColumnTypes[i])
+	if err := checkUniqueMask(rule, table, i, typ, length); err != nil {
+		return columnMask{}, err
+	}
+	switch rule.Strategy {
+	case "null":
+		if i < len(table.NotNull) && table.NotNull[i] {
+			return columnMask{}, fmt.Errorf("NOT NULL column cannot be NULL")
+		}
+		return columnMask{index: i, typ: typ, mask: func(*structpb.Value) (*structpb.Value, error) {
+			return structpb.NewNullValue(), nil
+		}}, nil
+	case "constant":
+		v, err := constantValue(typ, length, rule.Arg)
+		if err != nil {
+			return columnMask{}, err
+		}
+		return columnMask{index: i, typ: typ, mask: func(*structpb.Value) (*structpb.Value, error) {
+			return v, nil
+		}}, nil
+	}
+
+	elemType := typ
+	if typ.Code == pb.TypeCode_ARRAY {
+		elemType = typ.ArrayElementType
+	}
+	mask, err := p.valueMask(rule, elemType, length)
+	if err != nil {
+		return columnMask{}, err
+	}
+	if typ.Code == pb.TypeCode_ARRAY {
+		elemMask := mask
+		mask = func(v *structpb.Value) (*structpb.Value, error) {
+			values := v.GetListValue().GetValues()
+			masked := make([]*structpb.Value, len(values))
+			for j, e := range values {
+				if _, isNull := e.GetKind().(*structpb.Value_NullValue); isNull {
+					masked[j] = e
+					continue
+				}
+				m, err := elemMask(e)
+				if err != nil {
+					return nil, err
+				}
+				masked[j] = m
+			}
+			return structpb.NewListValue(&structpb.ListValue{Values: masked}), nil
+		}
+	}
+	return columnMask{index: i, typ: typ, mask: mask}, nil
+}
+
+// checkUniqueMask checks that the rule keeps distinct values distinct if the column is a primary key or unique index column,
+// so that the masked records can be loaded without violating the uniqueness.
+// Only hash and pseudonym with digests long enough, and shift, keep values distinct.
+func checkUniqueMask(rule MaskRule, table *Table, i int, typ *pb.Type, length int) error {
+	if !table.isUniqueColumn(table.Columns[i]) {
+		return nil
+	}
+	switch rule.Strategy {
+	case "hash", "pseudonym":
+		switch {
+		case typ.Code == pb.TypeCode_STRING && length > 0 && length < minUniqueDigestChars:
+			return fmt.Errorf("%s of a key column requires at least %d characters to avoid collisions, but the type is %s", rule.Strategy, minUniqueDigestChars, table.ColumnTypes[i])
+		case typ.Code == pb.TypeCode_BYTES && length > 0 && length < minUniqueDigestBytes:
+			return fmt.Errorf("%s of a key column requires at least %d bytes to avoid collisions, but the type is %s", rule.Strategy, minUniqueDigestBytes, table.ColumnTypes[i])
+		}
+		return nil
+	case "shift":
+		return nil
+	default:
+		return fmt.Errorf("%s of a key column may make distinct values the same", rule.Strategy)
+	}
+}
+
+// valueMask returns a function to mask non-NULL values of typ.
+func (p *MaskPolicy) valueMask(rule MaskRule, typ *pb.Type, length int) (func(v *structpb.Value) (*structpb.Value, error), error) {
+	unsupported := fmt.Errorf("%s does not support %s", rule.Strategy, typ.Code)
+	keyed := func(f func(sum []byte, s string) string) (func(v *structpb.Value) (*structpb.Value, error), error) {
+		if len(p.Key) == 0 {
+			return nil, fmt.Errorf("%s requires a mask key", rule.Strategy)
+		}
+		return func(v *structpb.Value) (*structpb.Value, error) {
+			s := v.GetStringValue()
+			mac := hmac.New(sha256.New, p.Key)
+			mac.Write([]byte(s))
+			return structpb.NewStringValue(f(mac.Sum(nil), s)), nil
+		}, nil
+	}
+	switch rule.Strategy {
+	case "hash", "pseudonym":
+		switch typ.Code {
+		case pb.TypeCode_STRING:
+			return keyed(func(sum []byte, _ string) string { return fitString(hex.EncodeToString(sum), length) })
+		case pb.TypeCode_BYTES:
+			return keyed(func(sum []byte, _ string) string {
+				if length > 0 && length < len(sum) {
+					sum = sum[:length]
+				}
+				return base64.StdEncoding.EncodeToString(sum)
+			})
+		case pb.TypeCode_INT64:
+			return keyed(func(sum []byte, _ string) string {
+				return strconv.FormatInt(int64(binary.BigEndian.Uint64(sum)>>1), 10)
+			})
+		}
+		return nil, unsupported
+	case "email", "phone", "name":
+		if typ.Code != pb.TypeCode_STRING {
+			return nil, unsupported
+		}
+		fake := map[string]func(sum []byte, s string) string{"email": fakeEmail, "phone": fakePhone, "name": fakeName}[rule.Strategy]
+		return keyed(func(sum []byte, s string) string { return fitString(fake(sum, s), length) })
+	case "shift":
+		d, err := parseMaskDuration(rule.Arg)
+		if err != nil {
+			return nil, err
+		}
+		switch typ.Code {
+		case pb.TypeCode_DATE:
+			if d%(24*time.Hour) != 0 {
+				return nil, fmt.Errorf("shift of DATE must be whole days")
+			}
+			return func(v *structpb.Value) (*structpb.Value, error) {
+				date, err := civil.ParseDate(v.GetStringValue())
+				if err != nil {
+					return nil, err
+				}
+				return structpb.NewStringValue(date.AddDays(int(d / (24 * time.Hour))).String()), nil
+			}, nil
+		case pb.TypeCode_TIMESTAMP:
+			return func(v *structpb.Value) (*structpb.Value, error) {
+				t, err := time.Parse(time.RFC3339Nano, v.GetStringValue())
+				if err != nil {
+					return nil, err
+				}
+				return structpb.NewStringValue(t.Add(d).UTC().Format(time.RFC3339Nano)), nil
+			}, nil
+		}
+		return nil, unsupported
+	case "truncate":
+		switch typ.Code {
+		case pb.TypeCode_STRING, pb.TypeCode_BYTES:
+			n, err := strconv.Atoi(rule.Arg)
+			if err != nil || n < 0 {
+				return nil, fmt.Errorf("truncate of %s requires a non-negative length", typ.Code)
+			}
+			if typ.Code == pb.TypeCode_STRING {
+				return func(v *structpb.Value) (*structpb.Value, error) {
+					return structpb.NewStringValue(fitString(v.GetStringValue(), n)), nil
+				}, nil
+			}
+			return func(v *structpb.Value) (*structpb.Value, error) {
+				b, err := base64.StdEncoding.DecodeString(v.GetStringValue())
+				if err != nil {
+					return nil, err
+				}
+				if len(b) > n {
+					b = b[:n]
+				}
+				return structpb.NewStringValue(base64.StdEncoding.EncodeToString(b)), nil
+			}, nil
+		case pb.TypeCode_TIMESTAMP:
+			d, err := parseMaskDuration(rule.Arg)
+			if err != nil || d <= 0 {
+				return nil, fmt.Errorf("truncate of TIMESTAMP requires a positive duration")
+			}
+			return func(v *structpb.Value) (*structpb.Value, error) {
+				t, err := time.Parse(time.RFC3339Nano, v.GetStringValue())
+				if err != nil {
+					return nil, err
+				}
+				return structpb.NewStringValue(t.UTC().Truncate(d).Format(time.RFC3339Nano)), nil
+			}, nil
+		}
+		return nil, unsupported
+	}
+	return nil, fmt.Errorf("unknown strategy %q", rule.Strategy)
+}
+
+// apply returns a copy of the record whose masked columns are replaced with masked values.
+func (m *tableMasker) apply(values []spanner.GenericColumnValue) ([]spanner.GenericColumnValue, error) {
+	masked := append([]spanner.GenericColumnValue(nil), values...)
+	for _, c := range m.columns {
+		v := values[c.index].Value
+		if _, isNull := v.GetKind().(*structpb.Value_NullValue); v == nil || isNull {
+			continue
+		}
+		v, err := c.mask(v)
+		if err != nil {
+			return nil, err
+		}
+		masked[c.index] = spanner.GenericColumnValue{Type: c.typ, Value: v}
+	}
+	return masked, nil
+}
+
+// constantValue parses s as a value of typ in the encoding of Cloud Spanner API.
+func constantValue(typ *pb.Type, length int, s string) (*structpb.Value, error) {
+	constant := s
+	var err error
+	switch typ.Code {
+	case pb.TypeCode_STRING:
+		if length > 0 && utf8.RuneCountInString(s) > length {
+			err = fmt.Errorf("longer than %d characters", length)
+		}
+	case pb.TypeCode_BYTES:
+		if length > 0 && len(s) > length {
+			err = fmt.Errorf("longer than %d bytes", length)
+		}
+		s = base64.StdEncoding.EncodeToString([]byte(s))
+	case pb.TypeCode_BOOL:
+		var b bool
+		if b, err = strconv.ParseBool(s); err == nil {
+			return structpb.NewBoolValue(b), nil
+		}
+	case pb.TypeCode_INT64:
+		_, err = strconv.ParseInt(s, 10, 64)
+	case pb.TypeCode_FLOAT64:
+		var f float64
+		if f, err = strconv.ParseFloat(s, 64); err == nil {
+			return structpb.NewNumberValue(f), nil
+		}
+	case pb.TypeCode_NUMERIC:
+		if _, ok := new(big.Rat).SetString(s); !ok {
+			err = fmt.Errorf("invalid NUMERIC")
+		}
+	case pb.TypeCode_DATE:
+		_, err = civil.ParseDate(s)
+	case pb.TypeCode_TIMESTAMP:
+		var t time.Time
+		if t, err = time.Parse(time.RFC3339Nano, s); err == nil {
+			s = t.UTC().Format(time.RFC3339Nano)
+		}
+	case pb.TypeCode_JSON:
+		if !json.Valid([]byte(s)) {
+			err = fmt.Errorf("invalid JSON")
+		}
+	default:
+		return nil, fmt.Errorf("constant does not support %s", typ.Code)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid constant %q of %s: %v", constant, typ.Code, err)
+	}
+	return structpb.NewStringValue(s), nil
+}
+
+var typeLengthRegexp = regexp.MustCompile(`(?:STRING|BYTES)\((\d+)\)`)
+
+// typeLength returns the length of a STRING or BYTES type such as STRING(36), or 0 for MAX.
+func typeLength(typ string) int {
+	match := typeLengthRegexp.FindStringSubmatch(typ)
+	if match == nil {
+		return 0
+	}
+	n, _ := strconv.Atoi(match[1])
+	return n
+}
+
+// fitString truncates s to length characters if length is positive.
+func fitString(s string, length int) string {
+	if length <= 0 || utf8.RuneCountInString(s) <= length {
+		return s
+	}
+	return string([]rune(s)[:length])
+}
+
+// parseMaskDuration parses a duration such as 1h30m, also accepting days such as 30d or -7d.
+func parseMaskDuration(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q", s)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration %q", s)
+	}
+	return d, nil
+}
+
+func fakeEmail(sum []byte, _ string) string {
+	return "user-" + hex.EncodeToString(sum[:5]) + "@example.com"
+}
+
+// fakePhone replaces each digit with a digit derived from sum, keeping other characters such as + and -.
+func fakePhone(sum []byte, s string) string {
+	var sb strings.Builder
+	n := 0
+	for _, r := range s {
+		if '0' <= r && r <= '9' {
+			r = rune('0' + sum[n%len(sum)]%10)
+			n++
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
+
+var (
+	fakeGivenNames  = []string{"Alex", "Blake", "Casey", "Drew", "Emery", "Finley", "Harper", "Jamie", "Jordan", "Kai", "Logan", "Morgan", "Parker", "Quinn", "Riley", "Taylor"}
+	fakeFamilyNames = []string{"Anderson", "Brown", "Clark", "Davis", "Evans", "Garcia", "Hill", "Johnson", "King", "Lee", "Miller", "Nguyen", "Smith", "Turner", "Walker", "Young"}
+)
+
+// fakeName returns a given name derived from sum, followed by a family name if s consists of multiple words.
+func fakeName(sum []byte, s string) string {
+	name := fakeGivenNames[int(sum[0])%len(fakeGivenNames)]
+	if len(strings.Fields(s)) > 1 {
+		name += " " + fakeFamilyNames[int(sum[1])%len(fakeFamilyNames)]
+	}
+	return name
+}
@@ -0,0 +1,204 @@
+package spanner_dump
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestParseMaskRule(t *testing.T) {
+	for _, tt := range []struct {
+		rule    string
+		want    MaskRule
+		wantErr bool
+	}{
+		{rule: "User.Email:hash", want: MaskRule{Table: "User", Column: "Email", Strategy: "hash"}},
+		{rule: "*.CreatedAt:shift=-30d", want: MaskRule{Table: "*", Column: "CreatedAt", Strategy: "shift", Arg: "-30d"}},
+		{rule: "User.Note:constant=a:b=c", want: MaskRule{Table: "User", Column: "Note", Strategy: "constant", Arg: "a:b=c"}},
+		{rule: "User.Email", wantErr: true},
+		{rule: "Email:hash", wantErr: true},
+		{rule: "User.Email:encrypt", wantErr: true},
+		{rule: "User.Email:constant", wantErr: true},
+		{rule: "User.Email:hash=1", wantErr: true},
+		{rule: "User[.Email:hash", wantErr: true},
+	} {
+		t.Run(tt.rule, func(t *testing.T) {
+			got, err := ParseMaskRule(tt.rule)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseMaskRule(): err = %v, wantErr = %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseMaskRule(): got = %+v, want = %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadMaskRules(t *testing.T) {
+	rules, err := LoadMaskRules(strings.NewReader("# PII\nUser.Email:email\n\n  User.Phone:phone  \n"))
+	if err != nil {
+		t.Fatalf("LoadMaskRules() failed: %v", err)
+	}
+	if len(rules) != 2 || rules[0].Column != "Email" || rules[1].Strategy != "phone" {
+		t.Errorf("LoadMaskRules(): got = %+v", rules)
+	}
+	if _, err := LoadMaskRules(strings.NewReader("User.Email:email\nUser.Phone\n")); err == nil || !strings.HasPrefix(err.Error(), "line 2:") {
+		t.Errorf("LoadMaskRules(): err = %v, want an error at line 2", err)
+	}
+}
+
+func TestMaskPolicy_bind(t *testing.T) {
+	table := &Table{
+		Name:        "User",
+		Columns:     []string{"Id", "Email", "BirthDate", "Tags", "Score", "Code", "Token", "Nick", "Age", "Since"},
+		ColumnTypes: []string{"INT64", "STRING(MAX)", "DATE", "ARRAY<STRING(MAX)>", "FLOAT64", "STRING(8)", "BYTES(4)", "STRING(8)", "INT64", "DATE"},
+		NotNull:     []bool{true, true, false, false, false, false, false, false, false, false},
+		PrimaryKey:  []KeyColumn{{Name: "Id"}},
+		Indexes:     []Index{{Name: "UserByCode", Columns: []string{"Code", "Token", "Since", "Nick"}, UniqueKeys: []string{"Code", "Token", "Since"}}},
+	}
+	for _, tt := range []struct {
+		rule    string
+		key     string
+		wantErr string
+	}{
+		{rule: "User.Email:null", wantErr: "NOT NULL column cannot be NULL"},
+		{rule: "User.BirthDate:null"},
+		{rule: "User.Email:hash", wantErr: "hash requires a mask key"},
+		{rule: "User.Email:hash", key: "k"},
+		{rule: "User.Score:hash", key: "k", wantErr: "hash does not support FLOAT64"},
+		{rule: "User.Code:hash", key: "k", wantErr: "hash of a key column requires at least 16 characters"},
+		{rule: "User.Token:pseudonym", key: "k", wantErr: "pseudonym of a key column requires at least 8 bytes"},
+		{rule: "User.Nick:hash", key: "k"},
+		{rule: "User.Nick:truncate=4"},
+		{rule: "User.Id:hash", key: "k"},
+		{rule: "User.Id:constant=1", wantErr: "constant of a key column may make distinct values the same"},
+		{rule: "User.Code:truncate=4", wantErr: "truncate of a key column may make distinct values the same"},
+		{rule: "User.Code:email", key: "k", wantErr: "email of a key column may make distinct values the same"},
+		{rule: "User.Code:name", key: "k", wantErr: "name of a key column may make distinct values the same"},
+		{rule: "User.Code:phone", key: "k", wantErr: "phone of a key column may make distinct values the same"},
+		{rule: "User.Since:null", wantErr: "null of a key column may make distinct values the same"},
+		{rule: "User.Since:shift=1d"},
+		{rule: "User.BirthDate:shift=12h", wantErr: "shift of DATE must be whole days"},
+		{rule: "User.Age:constant=abc", wantErr: `invalid constant "abc" of INT64`},
+		{rule: "User.Tags:constant=a", wantErr: "constant does not support ARRAY"},
+		{rule: "User.Tags:truncate=1"},
+		{rule: "Item.Email:null"},
+	} {
+		t.Run(tt.rule, func(t *testing.T) {
+			rule, err := ParseMaskRule(tt.rule)
+			if err != nil {
+				t.Fatalf("ParseMaskRule() failed: %v", err)
+			}
+			p := &MaskPolicy{Rules: []MaskRule{rule}, Key: []byte(tt.key)}
+			_, err = p.bind([]*Table{table}, nil)
+			if tt.wantErr == "" && err != nil {
+				t.Errorf("bind() failed: %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("bind(): err = %v, want = %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestTableMasker_apply(t *testing.T) {
+	table := &Table{
+		Name:        "User",
+		Columns:     []string{"Id", "Email", "Phone", "Name", "BirthDate", "UpdatedAt", "Note", "Code", "Tags", "Avatar", "Secret"},
+		ColumnTypes: []string{"INT64", "STRING(20)", "STRING(MAX)", "STRING(MAX)", "DATE", "TIMESTAMP", "STRING(MAX)", "STRING(8)", "ARRAY<STRING(MAX)>", "BYTES(MAX)", "STRING(MAX)"},
+	}
+	p := &MaskPolicy{Key: []byte("key")}
+	for _, s := range []string{
+		"User.Email:email", "User.Phone:phone", "User.Name:name", "User.BirthDate:shift=-1d", "User.UpdatedAt:truncate=1d",
+		"User.Note:constant=masked", "User.Code:hash", "User.Tags:truncate=2", "User.Avatar:truncate=1", "*.Secret:null",
+	} {
+		rule, err := ParseMaskRule(s)
+		if err != nil {
+			t.Fatalf("ParseMaskRule() failed: %v", err)
+		}
+		p.Rules = append(p.Rules, rule)
+	}
+	maskers, err := p.bind([]*Table{table}, nil)
+	if err != nil {
+		t.Fatalf("bind() failed: %v", err)
+	}
+	m := maskers["User"]
+
+	values := []string{"1", `"alice@example.org"`, `"+1 (555) 010-9999"`, `"Alice Liddell"`, `DATE "2000-03-01"`, `TIMESTAMP "2020-01-23T12:34:56Z"`, `"note"`, `"code"`, `["abc", NULL]`, `B"\x01\x02"`, `"secret"`}
+	apply := func(values []string) []string {
+		masked, err := m.apply(literalValues(t, table, values...))
+		if err != nil {
+			t.Fatalf("apply() failed: %v", err)
+		}
+		decoded, err := decodeValues(masked)
+		if err != nil {
+			t.Fatalf("decodeValues() failed: %v", err)
+		}
+		return decoded
+	}
+	got := apply(values)
+	if got[0] != "1" {
+		t.Errorf("Id: got = %s, want = 1", got[0])
+	}
+	if !strings.HasPrefix(got[1], `"user-`) || len(got[1]) != 20+2 {
+		t.Errorf("Email: got = %s, want a fake email truncated to 20 characters", got[1])
+	}
+	digits := regexp.MustCompile(`[0-9]`)
+	if phone := got[2]; digits.ReplaceAllString(phone, "0") != digits.ReplaceAllString(values[2], "0") || phone == values[2] {
+		t.Errorf("Phone: got = %s, want a phone number in the same format", phone)
+	}
+	if name := strings.Fields(got[3]); len(name) != 2 || got[3] == values[3] {
+		t.Errorf("Name: got = %s, want a fake given name and family name", got[3])
+	}
+	want := map[int]string{4: `DATE "2000-02-29"`, 5: `TIMESTAMP "2020-01-23T00:00:00Z"`, 6: `"masked"`, 8: `["ab", NULL]`, 9: `b"\x01"`, 10: "NULL"}
+	for i, w := range want {
+		if got[i] != w {
+			t.Errorf("%s: got = %s, want = %s", table.Columns[i], got[i], w)
+		}
+	}
+	if len(got[7]) != 8+2 {
+		t.Errorf("Code: got = %s, want a hash truncated to 8 characters", got[7])
+	}
+
+	// Masking is deterministic, and NULL values are kept.
+	again := apply([]string{"2", "NULL", `"+1 (555) 010-9999"`, "NULL", "NULL", "NULL", "NULL", `"code"`, "NULL", "NULL", "NULL"})
+	if again[1] != "NULL" || again[2] != got[2] || again[7] != got[7] {
+		t.Errorf("apply(): got = %v", again)
+	}
+}
+
+func TestTableWriter_mask(t *testing.T) {
+	table := &Table{Name: "t", Columns: []string{"Id", "Email"}, ColumnTypes: []string{"STRING(MAX)", "STRING(MAX)"}, PrimaryKey: []KeyColumn{{Name: "Id"}}}
+	idRule, _ := ParseMaskRule("t.Id:hash")
+	emailRule, _ := ParseMaskRule("t.Email:constant=x")
+	maskers, err := (&MaskPolicy{Rules: []MaskRule{idRule, emailRule}, Key: []byte("k")}).bind([]*Table{table}, nil)
+	if err != nil {
+		t.Fatalf("bind() failed: %v", err)
+	}
+	out := &bytes.Buffer{}
+	var progress []string
+	d := &Dumper{bulkSize: 1, maskers: maskers}
+	w, err := d.newTableWriter(d.outputEncoder(), table, out, nil, func(lastRow []string) error {
+		progress = append(progress, lastRow[0])
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("newTableWriter() failed: %v", err)
+	}
+	if err := w.write(literalValues(t, table, `"id"`, `"a@example.com"`)); err != nil {
+		t.Fatalf("write() failed: %v", err)
+	}
+	mac := hmac.New(sha256.New, []byte("k"))
+	mac.Write([]byte("id"))
+	if got, want := out.String(), fmt.Sprintf("INSERT INTO `t` (`Id`, `Email`) VALUES (\"%x\", \"x\");\n", mac.Sum(nil)); got != want {
+		t.Errorf("output: got = %q, want = %q", got, want)
+	}
+	// Progress is recorded with the primary key before masked to continue reading records.
+	if got, want := strings.Join(progress, ","), `"id"`; got != want {
+		t.Errorf("progress: got = %q, want = %q", got, want)
+	}
+}
@@ -0,0 +1,130 @@
+package spanner_dump
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"runtime/debug"
+	"strings"
+	"time"
+)
+
+const modulePath = "github.com/Jumpaku/spanner-dump-whare"
+
+// Metadata describes a dump to reproduce it.
+type Metadata struct {
+	// ToolVersion is the version of this module, or "(devel)" if unknown.
+	ToolVersion string `json:"toolVersion"`
+	Project     string `json:"project"`
+	Instance    string `json:"instance"`
+	Database    string `json:"database"`
+	// ReadTimestamp is the timestamp of the snapshot which the dump reads.
+	ReadTimestamp time.Time `json:"readTimestamp"`
+	// Filters maps table names to WHERE conditions to select records.
+	Filters map[string]string `json:"filters"`
+	// Tables are names of tables in the dump order.
+	Tables []string `json:"tables"`
+}
+
+// Metadata returns the metadata of the dump.
+// It begins the snapshot to read the database if DumpTables has not been called, which fixes the read timestamp of the dump.
+func (d *Dumper) Metadata(ctx context.Context) (*Metadata, error) {
+	if err := d.beginSnapshot(ctx); err != nil {
+		return nil, err
+	}
+	m := &Metadata{
+		ToolVersion:   toolVersion(),
+		Project:       d.project,
+		Instance:      d.instance,
+		Database:      d.database,
+		ReadTimestamp: d.readTimestamp,
+		Filters:       map[string]string{},
+	}
+	for table, where := range d.query {
+		m.Filters[table] = where
+	}
+	for _, table := range d.snapshotTables {
+		m.Tables = append(m.Tables, table.Name)
+	}
+	return m, nil
+}
+
+// DumpHeader dumps the metadata of the dump as a comment block.
+func (d *Dumper) DumpHeader(ctx context.Context) error {
+	m, err := d.Metadata(ctx)
+	if err != nil {
+		return err
+	}
+	return m.WriteHeader(d.out)
+}
+
+// WriteHeader writes the metadata as a comment block of SQL.
+func (m *Metadata) WriteHeader(w io.Writer) error {
+	sb := &strings.Builder{}
+	fmt.Fprintf(sb, "-- Dumped by spanner-dump-where %s\n", m.ToolVersion)
+	fmt.Fprintf(sb, "-- Database: projects/%s/instances/%s/databases/%s\n", m.Project, m.Instance, m.Database)
+	fmt.Fprintf(sb, "-- Read timestamp: %s\n", m.ReadTimestamp.Format(time.RFC3339Nano))
+	fmt.Fprintf(sb, "-- Tables: %s\n", strings.Join(m.Tables, ", "))
+	for _, table := range m.Tables {
+		if where, ok := m.Filters[table]; ok {
+			// Conditions may span multiple lines, which are joined not to break the comment.
+			fmt.Fprintf(sb, "-- Filter on %s: %s\n", table, strings.Join(strings.Fields(where), " "))
+		}
+	}
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// readHeaderFilters reads the header written by WriteHeader at the beginning of a dump,
+// and returns the filters of the dumped tables, where tables without filters are mapped to "TRUE", or nil if the dump has no header.
+// The returned reader reads the whole dump including the header.
+func readHeaderFilters(r io.Reader) (map[string]string, io.Reader, error) {
+	br := bufio.NewReader(r)
+	consumed := &strings.Builder{}
+	var filters map[string]string
+	for first := true; ; first = false {
+		line, err := br.ReadString('\n')
+		consumed.WriteString(line)
+		if err != nil && err != io.EOF {
+			return nil, nil, err
+		}
+		line = strings.TrimRight(line, "\r\n")
+		switch {
+		case first && !strings.HasPrefix(line, "-- Dumped by spanner-dump-where"):
+			return nil, io.MultiReader(strings.NewReader(consumed.String()), br), nil
+		case first:
+			filters = map[string]string{}
+		case strings.HasPrefix(line, "-- Tables: "):
+			for _, table := range strings.Split(strings.TrimPrefix(line, "-- Tables: "), ", ") {
+				filters[table] = "TRUE"
+			}
+		case strings.HasPrefix(line, "-- Filter on "):
+			if table, where, ok := strings.Cut(strings.TrimPrefix(line, "-- Filter on "), ": "); ok {
+				filters[table] = where
+			}
+		case !strings.HasPrefix(line, "--"):
+			return filters, io.MultiReader(strings.NewReader(consumed.String()), br), nil
+		}
+		if err == io.EOF {
+			return filters, strings.NewReader(consumed.String()), nil
+		}
+	}
+}
+
+// toolVersion returns the version of this module in the running binary.
+func toolVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "(devel)"
+	}
+	if info.Main.Path == modulePath && info.Main.Version != "" {
+		return info.Main.Version
+	}
+	for _, dep := range info.Deps {
+		if dep.Path == modulePath {
+			return dep.Version
+		}
+	}
+	return "(devel)"
+}
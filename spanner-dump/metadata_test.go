@@ -0,0 +1,91 @@
+package spanner_dump
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMetadata_WriteHeader(t *testing.T) {
+	m := &Metadata{
+		ToolVersion:   "v1.2.3",
+		Project:       "p",
+		Instance:      "i",
+		Database:      "d",
+		ReadTimestamp: time.Date(2020, 1, 23, 3, 0, 0, 123, time.UTC),
+		Filters:       map[string]string{"Users": "Id > 10\n  AND Name IS NOT NULL", "Items": "TRUE"},
+		Tables:        []string{"Users", "Items"},
+	}
+	out := &bytes.Buffer{}
+	if err := m.WriteHeader(out); err != nil {
+		t.Fatalf("WriteHeader() failed: %v", err)
+	}
+	want := "" +
+		"-- Dumped by spanner-dump-where v1.2.3\n" +
+		"-- Database: projects/p/instances/i/databases/d\n" +
+		"-- Read timestamp: 2020-01-23T03:00:00.000000123Z\n" +
+		"-- Tables: Users, Items\n" +
+		"-- Filter on Users: Id > 10 AND Name IS NOT NULL\n" +
+		"-- Filter on Items: TRUE\n"
+	if got := out.String(); got != want {
+		t.Errorf("WriteHeader(): got = %q, want = %q", got, want)
+	}
+
+	// The header is skipped as comments when the dump is parsed.
+	stmt, err := newStatementScanner(strings.NewReader(out.String() + "INSERT INTO `Users` (`Id`) VALUES (1);")).next()
+	if err != nil || stmt != "INSERT INTO `Users` (`Id`) VALUES (1)" {
+		t.Errorf("next() after header: got = (%q, %v)", stmt, err)
+	}
+}
+
+func TestReadHeaderFilters(t *testing.T) {
+	header := "" +
+		"-- Dumped by spanner-dump-where v1.2.3\n" +
+		"-- Database: projects/p/instances/i/databases/d\n" +
+		"-- Read timestamp: 2020-01-23T03:00:00.000000123Z\n" +
+		"-- Tables: Users, Items, Tags\n" +
+		"-- Filter on Users: Id > 10 AND Name IS NOT NULL\n" +
+		"-- Filter on Items: TRUE\n"
+	body := "INSERT INTO `Users` (`Id`) VALUES (11);\n"
+	for _, tt := range []struct {
+		desc string
+		dump string
+		want map[string]string
+	}{
+		{
+			desc: "Header",
+			dump: header + body,
+			want: map[string]string{"Users": "Id > 10 AND Name IS NOT NULL", "Items": "TRUE", "Tags": "TRUE"},
+		},
+		{
+			desc: "Only header",
+			dump: header,
+			want: map[string]string{"Users": "Id > 10 AND Name IS NOT NULL", "Items": "TRUE", "Tags": "TRUE"},
+		},
+		{
+			desc: "No header",
+			dump: "-- comment\n" + body,
+		},
+		{
+			desc: "Empty",
+		},
+	} {
+		t.Run(tt.desc, func(t *testing.T) {
+			filters, r, err := readHeaderFilters(strings.NewReader(tt.dump))
+			if err != nil {
+				t.Fatalf("readHeaderFilters() failed: %v", err)
+			}
+			if !reflect.DeepEqual(filters, tt.want) {
+				t.Errorf("readHeaderFilters(): got = %v, want = %v", filters, tt.want)
+			}
+			// The returned reader reads the whole dump.
+			b, err := io.ReadAll(r)
+			if err != nil || string(b) != tt.dump {
+				t.Errorf("readHeaderFilters(): dump = (%q, %v), want = %q", b, err, tt.dump)
+			}
+		})
+	}
+}
@@ -0,0 +1,134 @@
+package spanner_dump
+
+import (
+	"io"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/spanner"
+	adminapi "cloud.google.com/go/spanner/admin/database/apiv1"
+	"google.golang.org/api/option"
+)
+
+// Option configures Dumper created by NewDumper.
+type Option func(d *Dumper)
+
+// WithOutput specifies the writer to write the dump, which is os.Stdout by default.
+func WithOutput(out io.Writer) Option {
+	return func(d *Dumper) { d.out = out }
+}
+
+// WithTable adds a table to dump with an SQL boolean expression to filter records, e.g. "Age > 20" or "TRUE".
+// Tables are dumped in the order of addition unless WithSort is specified.
+func WithTable(table, where string) Option {
+	return func(d *Dumper) {
+		table = strings.Trim(table, "`")
+		if _, ok := d.query[table]; !ok {
+			d.tables = append(d.tables, table)
+		}
+		d.query[table] = where
+	}
+}
+
+// WithSort sorts tables in the dependency order, i.e. parents of interleaving and tables referenced by foreign keys first.
+func WithSort(sort bool) Option {
+	return func(d *Dumper) { d.sort = sort }
+}
+
+// WithReadBound specifies the snapshot which records are read at, which is a strong read by default.
+func WithReadBound(bound ReadBound) Option {
+	return func(d *Dumper) { d.readBound = bound }
+}
+
+// WithUpsert uses INSERT OR UPDATE instead of INSERT.
+func WithUpsert(upsert bool) Option {
+	return func(d *Dumper) { d.upsert = upsert }
+}
+
+// WithBulkSize limits the number of records in each INSERT statement if bulkSize is greater than 0.
+func WithBulkSize(bulkSize uint) Option {
+	return func(d *Dumper) { d.bulkSize = bulkSize }
+}
+
+// WithMaxMutations limits the number of estimated mutations of each INSERT statement (see NewBufferedWriterWithLimits).
+func WithMaxMutations(maxMutations uint) Option {
+	return func(d *Dumper) { d.maxMutations = maxMutations }
+}
+
+// WithMaxStatementBytes limits the number of bytes of each INSERT statement (see NewBufferedWriterWithLimits).
+func WithMaxStatementBytes(maxStatementBytes uint) Option {
+	return func(d *Dumper) { d.maxStatementBytes = maxStatementBytes }
+}
+
+// WithParallelism dumps up to parallelism tables concurrently at the same read timestamp, which is 1 by default.
+// Records of each table are buffered in memory and written in the dump order.
+func WithParallelism(parallelism uint) Option {
+	return func(d *Dumper) { d.parallelism = parallelism }
+}
+
+// WithPartitioned executes root-partitionable queries as partitioned queries,
+// whose partitions are read concurrently up to the parallelism.
+func WithPartitioned(partitioned bool) Option {
+	return func(d *Dumper) { d.partitioned = partitioned }
+}
+
+// WithQueryOptions applies options such as priority and request tag to queries reading records,
+// while DataBoostEnabled is applied only to partitioned queries.
+func WithQueryOptions(queryOptions spanner.QueryOptions) Option {
+	return func(d *Dumper) { d.queryOptions = queryOptions }
+}
+
+// WithCheckpoint records the progress of DumpTables in the checkpoint, and resumes the dump from it if it is resumed.
+func WithCheckpoint(checkpoint *Checkpoint) Option {
+	return func(d *Dumper) { d.checkpoint = checkpoint }
+}
+
+// WithPageSize reads records of each table in pages of pageSize records in the primary key order if pageSize is greater than 0,
+// where each page is read by a short query retried independently.
+func WithPageSize(pageSize uint) Option {
+	return func(d *Dumper) { d.pageSize = pageSize }
+}
+
+// WithProgress writes progress of tables being dumped to out every interval if interval is positive.
+func WithProgress(out io.Writer, interval time.Duration) Option {
+	return func(d *Dumper) { d.progressOut, d.progressInterval = out, interval }
+}
+
+// WithSchemaCheck makes DumpTables return SchemaChangedError if the schema of the dumped tables is changed during the dump.
+func WithSchemaCheck(checkSchema bool) Option {
+	return func(d *Dumper) { d.checkSchema = checkSchema }
+}
+
+// WithMask masks values of dumped records by the policy.
+func WithMask(mask *MaskPolicy) Option {
+	return func(d *Dumper) { d.mask = mask }
+}
+
+// WithRowTransformer registers a transformer of dumped records (see AddRowTransformer).
+func WithRowTransformer(t RowTransformer) Option {
+	return func(d *Dumper) { d.AddRowTransformer(t) }
+}
+
+// WithEncoder writes records by the encoder instead of SQLEncoder, which ignores WithBulkSize, WithUpsert, WithMaxMutations, and WithMaxStatementBytes.
+// Note that DumpHeader and DumpDDLs write SQL regardless of the encoder.
+func WithEncoder(encoder Encoder) Option {
+	return func(d *Dumper) { d.encoder = encoder }
+}
+
+// WithClient uses the client to read the database instead of creating a new one, which is not closed by Cleanup.
+// Note that the session pool of the client should have at least parallelism + 1 sessions.
+func WithClient(client *spanner.Client) Option {
+	return func(d *Dumper) { d.client = client }
+}
+
+// WithAdminClient uses the client to get DDL statements and metadata of the database instead of creating a new one,
+// which is not closed by Cleanup.
+func WithAdminClient(adminClient *adminapi.DatabaseAdminClient) Option {
+	return func(d *Dumper) { d.adminClient = adminClient }
+}
+
+// WithClientOptions applies the options such as credentials and endpoints to clients created by NewDumper.
+// Clients connect to the emulator if the environment variable SPANNER_EMULATOR_HOST is set.
+func WithClientOptions(opts ...option.ClientOption) Option {
+	return func(d *Dumper) { d.clientOptions = append(d.clientOptions, opts...) }
+}
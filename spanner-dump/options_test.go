@@ -0,0 +1,40 @@
+package spanner_dump
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestNewDumper_invalidPath(t *testing.T) {
+	for _, dbPath := range []string{
+		"",
+		"projects/p/instances/i",
+		"projects/p/instances/i/databases/",
+		"projects/p/instances/i/databases/d/tables/t",
+	} {
+		if _, err := NewDumper(context.Background(), dbPath); err == nil {
+			t.Errorf("NewDumper(%q) succeeded, want error", dbPath)
+		}
+	}
+}
+
+func TestWithTable(t *testing.T) {
+	d := &Dumper{query: map[string]string{}}
+	for _, opt := range []Option{
+		WithTable("`B`", "TRUE"),
+		WithTable("A", "Id > 1"),
+		WithTable("B", "Id = 2"),
+	} {
+		opt(d)
+	}
+	if got, want := strings.Join(d.tables, ","), "B,A"; got != want {
+		t.Errorf("tables = %v, want %v", got, want)
+	}
+	if got, want := d.query["B"], "Id = 2"; got != want {
+		t.Errorf("query[B] = %q, want %q", got, want)
+	}
+	if got, want := d.query["A"], "Id > 1"; got != want {
+		t.Errorf("query[A] = %q, want %q", got, want)
+	}
+}
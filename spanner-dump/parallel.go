@@ -0,0 +1,82 @@
+package spanner_dump
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"sync"
+)
+
+// runInOrder runs n tasks concurrently and writes their outputs to out in the order of tasks.
+// The output of each task is buffered until all preceding tasks are written, and
+// at most parallelism tasks are running or waiting to be written at once.
+// If parallelism is 1 or less, tasks run one by one and write to out directly.
+// If done is not nil, it is called after the output of each task is written.
+// If a task or done fails, the context of running tasks is canceled and runInOrder returns the error after they return,
+// so that no task uses resources of the caller after runInOrder returns.
+func runInOrder(ctx context.Context, parallelism uint, n int, out io.Writer, task func(ctx context.Context, i int, out io.Writer) error, done func(i int) error) error {
+	if done == nil {
+		done = func(int) error { return nil }
+	}
+	if parallelism <= 1 {
+		for i := 0; i < n; i++ {
+			if err := task(ctx, i, out); err != nil {
+				return err
+			}
+			if err := done(i); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	var wg sync.WaitGroup
+	defer func() {
+		cancel()
+		wg.Wait()
+	}()
+
+	type result struct {
+		buffer *bytes.Buffer
+		err    error
+	}
+	results := make([]chan result, n)
+	for i := range results {
+		results[i] = make(chan result, 1)
+	}
+	slots := make(chan struct{}, parallelism)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < n; i++ {
+			select {
+			case slots <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				buffer := &bytes.Buffer{}
+				err := task(ctx, i, buffer)
+				results[i] <- result{buffer: buffer, err: err}
+			}(i)
+		}
+	}()
+
+	for i := 0; i < n; i++ {
+		r := <-results[i]
+		if r.err != nil {
+			return r.err
+		}
+		if _, err := out.Write(r.buffer.Bytes()); err != nil {
+			return err
+		}
+		if err := done(i); err != nil {
+			return err
+		}
+		<-slots
+	}
+	return nil
+}
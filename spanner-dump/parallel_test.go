@@ -0,0 +1,92 @@
+package spanner_dump
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRunInOrder(t *testing.T) {
+	for _, parallelism := range []uint{1, 2, 5} {
+		t.Run(fmt.Sprintf("parallelism=%d", parallelism), func(t *testing.T) {
+			var running, maxRunning int32
+			out := &bytes.Buffer{}
+			err := runInOrder(context.Background(), parallelism, 10, out, func(ctx context.Context, i int, out io.Writer) error {
+				n := atomic.AddInt32(&running, 1)
+				defer atomic.AddInt32(&running, -1)
+				for {
+					m := atomic.LoadInt32(&maxRunning)
+					if n <= m || atomic.CompareAndSwapInt32(&maxRunning, m, n) {
+						break
+					}
+				}
+				// Later tasks finish earlier.
+				time.Sleep(time.Duration(10-i) * time.Millisecond)
+				fmt.Fprintf(out, "%d,", i)
+				return nil
+			}, func(i int) error {
+				fmt.Fprintf(out, "done %d,", i)
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("runInOrder() failed: %v", err)
+			}
+			want := ""
+			for i := 0; i < 10; i++ {
+				want += fmt.Sprintf("%d,done %d,", i, i)
+			}
+			if got := out.String(); got != want {
+				t.Errorf("runInOrder(): got = %q, want = %q", got, want)
+			}
+			if maxRunning > int32(parallelism) {
+				t.Errorf("runInOrder(): %d tasks ran at once, but parallelism is %d", maxRunning, parallelism)
+			}
+		})
+	}
+}
+
+func TestRunInOrder_error(t *testing.T) {
+	wantErr := errors.New("failed")
+	out := &bytes.Buffer{}
+	err := runInOrder(context.Background(), 3, 10, out, func(ctx context.Context, i int, out io.Writer) error {
+		if i == 4 {
+			return wantErr
+		}
+		fmt.Fprintf(out, "%d,", i)
+		return nil
+	}, nil)
+	if !errors.Is(err, wantErr) {
+		t.Errorf("runInOrder(): err = %v, want = %v", err, wantErr)
+	}
+	if got := out.String(); !strings.HasPrefix(got, "0,1,2,3,") || strings.Contains(got, "5,") {
+		t.Errorf("runInOrder(): got = %q, want outputs of tasks before the failed one", got)
+	}
+}
+
+func TestRunInOrder_waitRunning(t *testing.T) {
+	wantErr := errors.New("failed")
+	var running atomic.Int32
+	err := runInOrder(context.Background(), 3, 10, &bytes.Buffer{}, func(ctx context.Context, i int, out io.Writer) error {
+		if i == 0 {
+			return wantErr
+		}
+		running.Add(1)
+		defer running.Add(-1)
+		// Running tasks keep using their resources for a while after canceled.
+		<-ctx.Done()
+		time.Sleep(50 * time.Millisecond)
+		return ctx.Err()
+	}, nil)
+	if !errors.Is(err, wantErr) {
+		t.Errorf("runInOrder(): err = %v, want = %v", err, wantErr)
+	}
+	if n := running.Load(); n != 0 {
+		t.Errorf("runInOrder() returned while %d tasks are running", n)
+	}
+}
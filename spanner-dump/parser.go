@@ -0,0 +1,725 @@
+package spanner_dump
+
+import (
+	"bufio"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/civil"
+	"github.com/Jumpaku/spanner-dump-whare/spanner-dump/internal/sqltoken"
+	pb "google.golang.org/genproto/googleapis/spanner/v1"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// statementScanner splits SQL text into statements separated by ';'.
+// Comments are dropped from the returned statements.
+type statementScanner struct {
+	r *bufio.Reader
+}
+
+func newStatementScanner(r io.Reader) *statementScanner {
+	return &statementScanner{r: bufio.NewReader(r)}
+}
+
+// next returns the next non-empty statement without the trailing ';'.
+// It returns io.EOF when no statement remains.
+func (s *statementScanner) next() (string, error) {
+	sb := &strings.Builder{}
+	for {
+		c, _, err := s.r.ReadRune()
+		if err == io.EOF {
+			if stmt := strings.TrimSpace(sb.String()); stmt != "" {
+				return stmt, nil
+			}
+			return "", io.EOF
+		}
+		if err != nil {
+			return "", err
+		}
+
+		switch c {
+		case ';':
+			if stmt := strings.TrimSpace(sb.String()); stmt != "" {
+				return stmt, nil
+			}
+			sb.Reset()
+		case '"', '\'', '`':
+			sb.WriteRune(c)
+			if err := s.copyQuoted(sb, c); err != nil {
+				return "", err
+			}
+		case '#':
+			if err := s.skipLine(); err != nil {
+				return "", err
+			}
+			sb.WriteRune('\n')
+		case '-', '/':
+			if b, _ := s.r.Peek(1); len(b) == 1 && c == '-' && b[0] == '-' {
+				if err := s.skipLine(); err != nil {
+					return "", err
+				}
+				sb.WriteRune('\n')
+			} else if len(b) == 1 && c == '/' && b[0] == '*' {
+				if err := s.skipBlockComment(); err != nil {
+					return "", err
+				}
+				sb.WriteRune(' ')
+			} else {
+				sb.WriteRune(c)
+			}
+		default:
+			sb.WriteRune(c)
+		}
+	}
+}
+
+func (s *statementScanner) copyQuoted(sb *strings.Builder, quote rune) error {
+	delimiter := string(quote)
+	if b, _ := s.r.Peek(2); quote != '`' && string(b) == delimiter+delimiter {
+		// Triple-quoted string
+		_, _ = s.r.Discard(2)
+		sb.WriteString(delimiter + delimiter)
+		delimiter = strings.Repeat(delimiter, 3)
+	}
+	for {
+		c, _, err := s.r.ReadRune()
+		if err == io.EOF {
+			return fmt.Errorf("unterminated quoted literal")
+		}
+		if err != nil {
+			return err
+		}
+		sb.WriteRune(c)
+		switch {
+		case c == '\\':
+			c, _, err := s.r.ReadRune()
+			if err != nil {
+				return fmt.Errorf("unterminated quoted literal")
+			}
+			sb.WriteRune(c)
+		case c == quote && len(delimiter) == 1:
+			return nil
+		case c == quote:
+			if b, _ := s.r.Peek(2); string(b) == delimiter[:2] {
+				_, _ = s.r.Discard(2)
+				sb.WriteString(delimiter[:2])
+				return nil
+			}
+		}
+	}
+}
+
+func (s *statementScanner) skipLine() error {
+	_, err := s.r.ReadString('\n')
+	if err == io.EOF {
+		return nil
+	}
+	return err
+}
+
+func (s *statementScanner) skipBlockComment() error {
+	_, _ = s.r.Discard(1) // '*'
+	prev := rune(0)
+	for {
+		c, _, err := s.r.ReadRune()
+		if err == io.EOF {
+			return fmt.Errorf("unterminated block comment")
+		}
+		if err != nil {
+			return err
+		}
+		if prev == '*' && c == '/' {
+			return nil
+		}
+		prev = c
+	}
+}
+
+type token = sqltoken.Token
+
+// tokenParser is a cursor on tokens of a single statement.
+type tokenParser struct {
+	tokens []token
+	pos    int
+}
+
+func newTokenParser(stmt string) (*tokenParser, error) {
+	tokens, err := sqltoken.Tokenize(stmt)
+	if err != nil {
+		return nil, err
+	}
+	return &tokenParser{tokens: tokens}, nil
+}
+
+func (p *tokenParser) peek() token {
+	if p.pos >= len(p.tokens) {
+		return token{Kind: sqltoken.EOF}
+	}
+	return p.tokens[p.pos]
+}
+
+func (p *tokenParser) next() token {
+	t := p.peek()
+	if p.pos < len(p.tokens) {
+		p.pos++
+	}
+	return t
+}
+
+// isKeyword reports whether the next token is the unquoted keyword.
+func (p *tokenParser) isKeyword(keyword string) bool {
+	t := p.peek()
+	return t.Kind == sqltoken.Ident && !t.Quoted && strings.EqualFold(t.Text, keyword)
+}
+
+// consumeKeywords consumes the keywords if all of them follow in order.
+func (p *tokenParser) consumeKeywords(keywords ...string) bool {
+	for i, keyword := range keywords {
+		if p.pos+i >= len(p.tokens) {
+			return false
+		}
+		t := p.tokens[p.pos+i]
+		if t.Kind != sqltoken.Ident || t.Quoted || !strings.EqualFold(t.Text, keyword) {
+			return false
+		}
+	}
+	p.pos += len(keywords)
+	return true
+}
+
+func (p *tokenParser) isSymbol(symbol string) bool {
+	t := p.peek()
+	return t.Kind == sqltoken.Symbol && t.Text == symbol
+}
+
+func (p *tokenParser) expectSymbol(symbol string) error {
+	if t := p.next(); t.Kind != sqltoken.Symbol || t.Text != symbol {
+		return fmt.Errorf("expected %q but got %q", symbol, t.Text)
+	}
+	return nil
+}
+
+func (p *tokenParser) expectIdent() (string, error) {
+	t := p.next()
+	if t.Kind != sqltoken.Ident {
+		return "", fmt.Errorf("expected identifier but got %q", t.Text)
+	}
+	return t.Text, nil
+}
+
+// skipUntil skips tokens until one of the symbols appears at the top level of parentheses.
+func (p *tokenParser) skipUntil(symbols ...string) {
+	depth := 0
+	for t := p.peek(); t.Kind != sqltoken.EOF; t = p.peek() {
+		if t.Kind == sqltoken.Symbol {
+			switch {
+			case depth == 0 && containsString(symbols, t.Text):
+				return
+			case t.Text == "(" || t.Text == "[":
+				depth++
+			case t.Text == ")" || t.Text == "]":
+				depth--
+			}
+		}
+		p.next()
+	}
+}
+
+func containsString(ss []string, s string) bool {
+	for _, v := range ss {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+var typeCodes = map[string]pb.TypeCode{
+	"BOOL":      pb.TypeCode_BOOL,
+	"INT64":     pb.TypeCode_INT64,
+	"FLOAT64":   pb.TypeCode_FLOAT64,
+	"STRING":    pb.TypeCode_STRING,
+	"BYTES":     pb.TypeCode_BYTES,
+	"DATE":      pb.TypeCode_DATE,
+	"TIMESTAMP": pb.TypeCode_TIMESTAMP,
+	"NUMERIC":   pb.TypeCode_NUMERIC,
+	"JSON":      pb.TypeCode_JSON,
+}
+
+// parseType parses a column type such as STRING(MAX) or ARRAY<INT64>.
+func (p *tokenParser) parseType() (*pb.Type, error) {
+	name, err := p.expectIdent()
+	if err != nil {
+		return nil, err
+	}
+	if strings.EqualFold(name, "ARRAY") {
+		if err := p.expectSymbol("<"); err != nil {
+			return nil, err
+		}
+		elem, err := p.parseType()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expectSymbol(">"); err != nil {
+			return nil, err
+		}
+		p.skipLength()
+		return &pb.Type{Code: pb.TypeCode_ARRAY, ArrayElementType: elem}, nil
+	}
+	code, ok := typeCodes[strings.ToUpper(name)]
+	if !ok {
+		return nil, fmt.Errorf("unsupported type: %s", name)
+	}
+	p.skipLength()
+	return &pb.Type{Code: code}, nil
+}
+
+// skipLength skips a length such as (MAX) or (16) of STRING, BYTES and ARRAY types.
+func (p *tokenParser) skipLength() {
+	if p.isSymbol("(") {
+		p.next()
+		p.skipUntil(")")
+		p.next()
+	}
+}
+
+// parseSpannerType parses a type name such as INFORMATION_SCHEMA.COLUMNS.SPANNER_TYPE.
+func parseSpannerType(s string) (*pb.Type, error) {
+	p, err := newTokenParser(s)
+	if err != nil {
+		return nil, err
+	}
+	typ, err := p.parseType()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.Kind != sqltoken.EOF {
+		return nil, fmt.Errorf("unexpected token after type: %q", t.Text)
+	}
+	return typ, nil
+}
+
+// columnDefinition is a column definition parsed from a CREATE TABLE statement.
+type columnDefinition struct {
+	name      string
+	typ       *pb.Type
+	notNull   bool
+	generated bool
+}
+
+// parseCreateTable parses a CREATE TABLE statement.
+// It returns ok = false if the statement is not a CREATE TABLE statement.
+func parseCreateTable(stmt string) (name string, columns []columnDefinition, ok bool, err error) {
+	p, err := newTokenParser(stmt)
+	if err != nil {
+		return "", nil, false, err
+	}
+	if !p.consumeKeywords("CREATE", "TABLE") {
+		return "", nil, false, nil
+	}
+	p.consumeKeywords("IF", "NOT", "EXISTS")
+	if name, err = p.expectIdent(); err != nil {
+		return "", nil, true, err
+	}
+	if err := p.expectSymbol("("); err != nil {
+		return "", nil, true, err
+	}
+	for !p.isSymbol(")") {
+		if p.peek().Kind == sqltoken.EOF {
+			return "", nil, true, fmt.Errorf("unexpected end of statement")
+		}
+		if p.isKeyword("CONSTRAINT") || p.isKeyword("FOREIGN") || p.isKeyword("CHECK") || p.isKeyword("PRIMARY") {
+			p.skipUntil(",", ")")
+		} else {
+			column, err := p.parseColumnDefinition()
+			if err != nil {
+				return "", nil, true, fmt.Errorf("failed to parse column definition of %s: %v", name, err)
+			}
+			columns = append(columns, column)
+		}
+		if p.isSymbol(",") {
+			p.next()
+		}
+	}
+	return name, columns, true, nil
+}
+
+func (p *tokenParser) parseColumnDefinition() (columnDefinition, error) {
+	name, err := p.expectIdent()
+	if err != nil {
+		return columnDefinition{}, err
+	}
+	typ, err := p.parseType()
+	if err != nil {
+		return columnDefinition{}, err
+	}
+	column := columnDefinition{name: name, typ: typ}
+	for t := p.peek(); t.Kind != sqltoken.EOF && !p.isSymbol(",") && !p.isSymbol(")"); t = p.peek() {
+		switch {
+		case p.consumeKeywords("NOT", "NULL"):
+			column.notNull = true
+		case p.isKeyword("AS"):
+			column.generated = true
+			p.next()
+		case p.isSymbol("("):
+			p.next()
+			p.skipUntil(")")
+			p.next()
+		default:
+			p.next()
+		}
+	}
+	return column, nil
+}
+
+// parsePrimaryKey parses the PRIMARY KEY clause following the column definitions of a CREATE TABLE statement.
+func parsePrimaryKey(stmt string) ([]KeyColumn, error) {
+	p, err := newTokenParser(stmt)
+	if err != nil {
+		return nil, err
+	}
+	p.skipUntil("(")
+	p.next()
+	p.skipUntil(")")
+	p.next()
+	if !p.consumeKeywords("PRIMARY", "KEY") {
+		return nil, fmt.Errorf("expected PRIMARY KEY but got %q", p.peek().Text)
+	}
+	if err := p.expectSymbol("("); err != nil {
+		return nil, err
+	}
+	primaryKey := []KeyColumn{}
+	for !p.isSymbol(")") {
+		name, err := p.expectIdent()
+		if err != nil {
+			return nil, err
+		}
+		key := KeyColumn{Name: name}
+		if p.consumeKeywords("DESC") {
+			key.Desc = true
+		} else {
+			p.consumeKeywords("ASC")
+		}
+		primaryKey = append(primaryKey, key)
+		if p.isSymbol(",") {
+			p.next()
+		}
+	}
+	return primaryKey, nil
+}
+
+// parseCreateIndex parses a CREATE INDEX statement.
+// It returns ok = false if the statement is not a CREATE INDEX statement.
+func parseCreateIndex(stmt string) (table string, index Index, ok bool, err error) {
+	p, err := newTokenParser(stmt)
+	if err != nil {
+		return "", Index{}, false, err
+	}
+	if !p.consumeKeywords("CREATE") {
+		return "", Index{}, false, nil
+	}
+	unique := p.consumeKeywords("UNIQUE")
+	p.consumeKeywords("NULL_FILTERED")
+	if !p.consumeKeywords("INDEX") {
+		return "", Index{}, false, nil
+	}
+	p.consumeKeywords("IF", "NOT", "EXISTS")
+	if index.Name, err = p.expectIdent(); err != nil {
+		return "", Index{}, true, err
+	}
+	if !p.consumeKeywords("ON") {
+		return "", Index{}, true, fmt.Errorf("expected ON but got %q", p.peek().Text)
+	}
+	if table, err = p.expectIdent(); err != nil {
+		return "", Index{}, true, err
+	}
+	if index.Columns, err = p.parseIndexColumns(); err != nil {
+		return "", Index{}, true, err
+	}
+	if unique {
+		index.UniqueKeys = append([]string(nil), index.Columns...)
+	}
+	if p.consumeKeywords("STORING") {
+		storing, err := p.parseIndexColumns()
+		if err != nil {
+			return "", Index{}, true, err
+		}
+		index.Columns = append(index.Columns, storing...)
+	}
+	return table, index, true, nil
+}
+
+// parseIndexColumns parses a parenthesized list of columns with optional orderings.
+func (p *tokenParser) parseIndexColumns() (columns []string, err error) {
+	if err := p.expectSymbol("("); err != nil {
+		return nil, err
+	}
+	for !p.isSymbol(")") {
+		column, err := p.expectIdent()
+		if err != nil {
+			return nil, err
+		}
+		columns = append(columns, column)
+		p.skipUntil(",", ")")
+		if p.isSymbol(",") {
+			p.next()
+		}
+	}
+	p.next()
+	return columns, nil
+}
+
+// insertStatement is an INSERT statement parsed from a dump.
+type insertStatement struct {
+	table   string
+	upsert  bool
+	columns []string
+	rows    [][]*structpb.Value
+}
+
+// parseInsert parses an INSERT statement emitted by BufferedWriter.
+// Types of values are resolved by columnTypes which maps column names to their types.
+// It returns ok = false if the statement is not an INSERT statement.
+func parseInsert(stmt string, columnTypes func(table string) (map[string]*pb.Type, error)) (insert *insertStatement, ok bool, err error) {
+	p, err := newTokenParser(stmt)
+	if err != nil {
+		return nil, false, err
+	}
+	if !p.consumeKeywords("INSERT") {
+		return nil, false, nil
+	}
+	insert = &insertStatement{}
+	insert.upsert = p.consumeKeywords("OR", "UPDATE")
+	p.consumeKeywords("INTO")
+	if insert.table, err = p.expectIdent(); err != nil {
+		return nil, true, err
+	}
+	types, err := columnTypes(insert.table)
+	if err != nil {
+		return nil, true, err
+	}
+
+	if err := p.expectSymbol("("); err != nil {
+		return nil, true, err
+	}
+	var columnTypeList []*pb.Type
+	for !p.isSymbol(")") {
+		column, err := p.expectIdent()
+		if err != nil {
+			return nil, true, err
+		}
+		typ, exists := types[column]
+		if !exists {
+			return nil, true, fmt.Errorf("unknown column: %s.%s", insert.table, column)
+		}
+		insert.columns = append(insert.columns, column)
+		columnTypeList = append(columnTypeList, typ)
+		if p.isSymbol(",") {
+			p.next()
+		}
+	}
+	p.next()
+
+	if !p.consumeKeywords("VALUES") {
+		return nil, true, fmt.Errorf("expected VALUES but got %q", p.peek().Text)
+	}
+	for {
+		if err := p.expectSymbol("("); err != nil {
+			return nil, true, err
+		}
+		row := make([]*structpb.Value, len(columnTypeList))
+		for i, typ := range columnTypeList {
+			if i > 0 {
+				if err := p.expectSymbol(","); err != nil {
+					return nil, true, err
+				}
+			}
+			if row[i], err = p.parseValue(typ); err != nil {
+				return nil, true, fmt.Errorf("failed to parse value of %s.%s: %v", insert.table, insert.columns[i], err)
+			}
+		}
+		if err := p.expectSymbol(")"); err != nil {
+			return nil, true, err
+		}
+		insert.rows = append(insert.rows, row)
+
+		if !p.isSymbol(",") {
+			break
+		}
+		p.next()
+	}
+	if t := p.peek(); t.Kind != sqltoken.EOF {
+		return nil, true, fmt.Errorf("unexpected token: %q", t.Text)
+	}
+	return insert, true, nil
+}
+
+// parseValue parses a literal as a value of typ in the encoding of Cloud Spanner API.
+// It accepts literals emitted by DecodeColumn.
+func (p *tokenParser) parseValue(typ *pb.Type) (*structpb.Value, error) {
+	if p.consumeKeywords("NULL") {
+		return structpb.NewNullValue(), nil
+	}
+
+	switch typ.Code {
+	case pb.TypeCode_ARRAY:
+		if p.consumeKeywords("ARRAY") && p.isSymbol("<") {
+			p.skipUntil("[")
+		}
+		if err := p.expectSymbol("["); err != nil {
+			return nil, err
+		}
+		var values []*structpb.Value
+		for !p.isSymbol("]") {
+			v, err := p.parseValue(typ.ArrayElementType)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, v)
+			if !p.isSymbol(",") {
+				break
+			}
+			p.next()
+		}
+		if err := p.expectSymbol("]"); err != nil {
+			return nil, err
+		}
+		return structpb.NewListValue(&structpb.ListValue{Values: values}), nil
+	case pb.TypeCode_BOOL:
+		switch {
+		case p.consumeKeywords("TRUE"):
+			return structpb.NewBoolValue(true), nil
+		case p.consumeKeywords("FALSE"):
+			return structpb.NewBoolValue(false), nil
+		default:
+			return nil, fmt.Errorf("invalid BOOL literal: %q", p.peek().Text)
+		}
+	case pb.TypeCode_INT64:
+		literal, err := p.parseNumber()
+		if err != nil {
+			return nil, err
+		}
+		v, err := strconv.ParseInt(literal, 0, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid INT64 literal: %q", literal)
+		}
+		return structpb.NewStringValue(strconv.FormatInt(v, 10)), nil
+	case pb.TypeCode_FLOAT64:
+		if p.consumeKeywords("CAST") {
+			if err := p.expectSymbol("("); err != nil {
+				return nil, err
+			}
+			t := p.next()
+			if t.Kind != sqltoken.String {
+				return nil, fmt.Errorf("invalid FLOAT64 literal: %q", t.Text)
+			}
+			p.skipUntil(")")
+			p.next()
+			switch strings.ToLower(t.Text) {
+			case "nan":
+				return structpb.NewStringValue("NaN"), nil
+			case "inf", "+inf":
+				return structpb.NewStringValue("Infinity"), nil
+			case "-inf":
+				return structpb.NewStringValue("-Infinity"), nil
+			default:
+				return nil, fmt.Errorf("invalid FLOAT64 literal: %q", t.Text)
+			}
+		}
+		literal, err := p.parseNumber()
+		if err != nil {
+			return nil, err
+		}
+		v, err := strconv.ParseFloat(literal, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid FLOAT64 literal: %q", literal)
+		}
+		if math.IsInf(v, 0) || math.IsNaN(v) {
+			return nil, fmt.Errorf("invalid FLOAT64 literal: %q", literal)
+		}
+		return structpb.NewNumberValue(v), nil
+	case pb.TypeCode_STRING:
+		t := p.next()
+		if t.Kind != sqltoken.String {
+			return nil, fmt.Errorf("invalid STRING literal: %q", t.Text)
+		}
+		return structpb.NewStringValue(t.Text), nil
+	case pb.TypeCode_BYTES:
+		t := p.next()
+		if t.Kind != sqltoken.Bytes {
+			return nil, fmt.Errorf("invalid BYTES literal: %q", t.Text)
+		}
+		return structpb.NewStringValue(base64.StdEncoding.EncodeToString([]byte(t.Text))), nil
+	case pb.TypeCode_TIMESTAMP:
+		literal, err := p.parseTypedString("TIMESTAMP")
+		if err != nil {
+			return nil, err
+		}
+		v, err := time.Parse(time.RFC3339Nano, literal)
+		if err != nil {
+			return nil, fmt.Errorf("invalid TIMESTAMP literal: %q", literal)
+		}
+		return structpb.NewStringValue(v.UTC().Format(time.RFC3339Nano)), nil
+	case pb.TypeCode_DATE:
+		literal, err := p.parseTypedString("DATE")
+		if err != nil {
+			return nil, err
+		}
+		if _, err := civil.ParseDate(literal); err != nil {
+			return nil, fmt.Errorf("invalid DATE literal: %q", literal)
+		}
+		return structpb.NewStringValue(literal), nil
+	case pb.TypeCode_NUMERIC:
+		if t := p.peek(); t.Kind == sqltoken.Number || t.Kind == sqltoken.Symbol {
+			literal, err := p.parseNumber()
+			if err != nil {
+				return nil, err
+			}
+			return structpb.NewStringValue(literal), nil
+		}
+		literal, err := p.parseTypedString("NUMERIC")
+		if err != nil {
+			return nil, err
+		}
+		return structpb.NewStringValue(literal), nil
+	case pb.TypeCode_JSON:
+		literal, err := p.parseTypedString("JSON")
+		if err != nil {
+			return nil, err
+		}
+		return structpb.NewStringValue(literal), nil
+	default:
+		return nil, fmt.Errorf("unsupported type: %s", typ.Code)
+	}
+}
+
+// parseNumber parses a number literal with an optional sign.
+func (p *tokenParser) parseNumber() (string, error) {
+	sign := ""
+	if p.isSymbol("-") || p.isSymbol("+") {
+		sign = p.next().Text
+	}
+	t := p.next()
+	if t.Kind != sqltoken.Number {
+		return "", fmt.Errorf("invalid number literal: %q", sign+t.Text)
+	}
+	if sign == "-" {
+		return sign + t.Text, nil
+	}
+	return t.Text, nil
+}
+
+// parseTypedString parses a string literal with an optional type prefix such as DATE "2020-01-23".
+func (p *tokenParser) parseTypedString(prefix string) (string, error) {
+	p.consumeKeywords(prefix)
+	t := p.next()
+	if t.Kind != sqltoken.String {
+		return "", fmt.Errorf("invalid %s literal: %q", prefix, t.Text)
+	}
+	return t.Text, nil
+}
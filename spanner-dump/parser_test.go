@@ -0,0 +1,254 @@
+package spanner_dump
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"cloud.google.com/go/spanner"
+	pb "google.golang.org/genproto/googleapis/spanner/v1"
+)
+
+func TestStatementScanner(t *testing.T) {
+	for _, tt := range []struct {
+		desc  string
+		input string
+		want  []string
+	}{
+		{
+			desc:  "Empty",
+			input: "",
+			want:  nil,
+		},
+		{
+			desc:  "Multiple statements",
+			input: "CREATE TABLE t1 (Id INT64) PRIMARY KEY(Id);\nINSERT INTO `t1` (`Id`) VALUES (1);\n",
+			want:  []string{"CREATE TABLE t1 (Id INT64) PRIMARY KEY(Id)", "INSERT INTO `t1` (`Id`) VALUES (1)"},
+		},
+		{
+			desc:  "Semicolons in literals",
+			input: "INSERT INTO `t;1` (`S`) VALUES (\"a;b\"), ('c;\\'d'), (\"\"\"e;\"f\"\"\");",
+			want:  []string{"INSERT INTO `t;1` (`S`) VALUES (\"a;b\"), ('c;\\'d'), (\"\"\"e;\"f\"\"\")"},
+		},
+		{
+			desc:  "Comments",
+			input: "-- header;\n# another;\nINSERT INTO `t1` /* ; */ (`Id`) VALUES (1); -- trailer",
+			want:  []string{"INSERT INTO `t1`   (`Id`) VALUES (1)"},
+		},
+		{
+			desc:  "Missing last semicolon",
+			input: "INSERT INTO `t1` (`Id`) VALUES (1)",
+			want:  []string{"INSERT INTO `t1` (`Id`) VALUES (1)"},
+		},
+	} {
+		t.Run(tt.desc, func(t *testing.T) {
+			scanner := newStatementScanner(strings.NewReader(tt.input))
+			var got []string
+			for {
+				stmt, err := scanner.next()
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					t.Fatalf("next() failed: %v", err)
+				}
+				got = append(got, stmt)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("next(): got = %q, want = %q", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("next(): got = %q, want = %q", got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestParseCreateTable(t *testing.T) {
+	ddl := "CREATE TABLE `t1` (\n" +
+		"  Id INT64 NOT NULL,\n" +
+		"  Name STRING(MAX) DEFAULT (\"a, b\"),\n" +
+		"  Tags ARRAY<STRING(16)>,\n" +
+		"  Upper STRING(MAX) AS (UPPER(Name)) STORED,\n" +
+		"  CONSTRAINT fk FOREIGN KEY (Id) REFERENCES t2 (Id),\n" +
+		") PRIMARY KEY(Id)"
+	name, columns, ok, err := parseCreateTable(ddl)
+	if err != nil || !ok {
+		t.Fatalf("parseCreateTable() failed: ok = %v, err = %v", ok, err)
+	}
+	if name != "t1" {
+		t.Errorf("parseCreateTable(): name = %q, want = %q", name, "t1")
+	}
+	want := []columnDefinition{
+		{name: "Id", typ: &pb.Type{Code: pb.TypeCode_INT64}, notNull: true},
+		{name: "Name", typ: &pb.Type{Code: pb.TypeCode_STRING}},
+		{name: "Tags", typ: &pb.Type{Code: pb.TypeCode_ARRAY, ArrayElementType: &pb.Type{Code: pb.TypeCode_STRING}}},
+		{name: "Upper", typ: &pb.Type{Code: pb.TypeCode_STRING}, generated: true},
+	}
+	if len(columns) != len(want) {
+		t.Fatalf("parseCreateTable(): columns = %v, want = %v", columns, want)
+	}
+	for i, c := range columns {
+		w := want[i]
+		if c.name != w.name || c.typ.String() != w.typ.String() || c.notNull != w.notNull || c.generated != w.generated {
+			t.Errorf("parseCreateTable(): columns[%d] = %+v, want = %+v", i, c, w)
+		}
+	}
+
+	if _, _, ok, _ := parseCreateTable("CREATE INDEX idx ON t1(Name)"); ok {
+		t.Errorf("parseCreateTable() of CREATE INDEX: ok = true, want = false")
+	}
+}
+
+func TestParseCreateIndex(t *testing.T) {
+	for _, tt := range []struct {
+		desc      string
+		ddl       string
+		wantTable string
+		wantIndex Index
+		wantOK    bool
+	}{
+		{
+			desc:      "Index",
+			ddl:       "CREATE INDEX idx ON t1(Name)",
+			wantTable: "t1",
+			wantIndex: Index{Name: "idx", Columns: []string{"Name"}},
+			wantOK:    true,
+		},
+		{
+			desc:      "Unique null-filtered index with storing columns",
+			ddl:       "CREATE UNIQUE NULL_FILTERED INDEX `idx` ON `t1` (A DESC, `B`) STORING (C), INTERLEAVE IN t0",
+			wantTable: "t1",
+			wantIndex: Index{Name: "idx", Columns: []string{"A", "B", "C"}, UniqueKeys: []string{"A", "B"}},
+			wantOK:    true,
+		},
+		{
+			desc:   "Not an index",
+			ddl:    "CREATE TABLE t1 (Id INT64) PRIMARY KEY(Id)",
+			wantOK: false,
+		},
+	} {
+		t.Run(tt.desc, func(t *testing.T) {
+			table, index, ok, err := parseCreateIndex(tt.ddl)
+			if err != nil {
+				t.Fatalf("parseCreateIndex() failed: %v", err)
+			}
+			if ok != tt.wantOK || table != tt.wantTable || index.Name != tt.wantIndex.Name || !equalColumns(index.Columns, tt.wantIndex.Columns) || !equalColumns(index.UniqueKeys, tt.wantIndex.UniqueKeys) {
+				t.Errorf("parseCreateIndex(): got = (%q, %v, %v), want = (%q, %v, %v)", table, index, ok, tt.wantTable, tt.wantIndex, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestParseInsert_roundtrip(t *testing.T) {
+	types := map[string]*pb.Type{
+		"Bool":      {Code: pb.TypeCode_BOOL},
+		"Bytes":     {Code: pb.TypeCode_BYTES},
+		"Float":     {Code: pb.TypeCode_FLOAT64},
+		"Int":       {Code: pb.TypeCode_INT64},
+		"String":    {Code: pb.TypeCode_STRING},
+		"Timestamp": {Code: pb.TypeCode_TIMESTAMP},
+		"Date":      {Code: pb.TypeCode_DATE},
+		"Numeric":   {Code: pb.TypeCode_NUMERIC},
+		"JSON":      {Code: pb.TypeCode_JSON},
+		"Array":     {Code: pb.TypeCode_ARRAY, ArrayElementType: &pb.Type{Code: pb.TypeCode_FLOAT64}},
+	}
+	columns := []string{"Bool", "Bytes", "Float", "Int", "String", "Timestamp", "Date", "Numeric", "JSON", "Array"}
+	rows := [][]string{
+		{`true`, `b"\x61\x00\xff"`, `-1.5`, `-42`, `"a\"b\ncあ"`, `TIMESTAMP "2020-01-23T03:00:00.123Z"`, `DATE "2020-01-23"`, `NUMERIC "-1.250000000"`, `JSON "{\"a\":1}"`, `[1, CAST('nan' AS FLOAT64), NULL, CAST('-inf' AS FLOAT64)]`},
+		{`NULL`, `NULL`, `NULL`, `NULL`, `NULL`, `NULL`, `NULL`, `NULL`, `NULL`, `NULL`},
+		{`false`, `b""`, `1e+100`, `9223372036854775807`, `""`, `TIMESTAMP "2020-01-23T03:00:00Z"`, `DATE "0001-01-01"`, `NUMERIC "0.000000000"`, `JSON "null"`, `[]`},
+	}
+
+	var tuples []string
+	for _, row := range rows {
+		tuples = append(tuples, "("+strings.Join(row, ", ")+")")
+	}
+	stmt := "INSERT OR UPDATE INTO `t1` (`" + strings.Join(columns, "`, `") + "`) VALUES " + strings.Join(tuples, ", ")
+
+	insert, ok, err := parseInsert(stmt, func(string) (map[string]*pb.Type, error) { return types, nil })
+	if err != nil || !ok {
+		t.Fatalf("parseInsert() failed: ok = %v, err = %v", ok, err)
+	}
+	if insert.table != "t1" || !insert.upsert || !equalStringSlice(insert.columns, columns) {
+		t.Fatalf("parseInsert(): got = %+v", insert)
+	}
+	if len(insert.rows) != len(rows) {
+		t.Fatalf("parseInsert(): rows = %d, want = %d", len(insert.rows), len(rows))
+	}
+	for i, row := range insert.rows {
+		for j, v := range row {
+			got, err := DecodeColumn(spanner.GenericColumnValue{Type: types[columns[j]], Value: v})
+			if err != nil {
+				t.Fatalf("DecodeColumn() failed: %v", err)
+			}
+			if got != rows[i][j] {
+				t.Errorf("rows[%d][%d]: got = %s, want = %s", i, j, got, rows[i][j])
+			}
+		}
+	}
+}
+
+func TestParseSpannerType(t *testing.T) {
+	for _, tt := range []struct {
+		input string
+		want  *pb.Type
+	}{
+		{input: "INT64", want: &pb.Type{Code: pb.TypeCode_INT64}},
+		{input: "STRING(MAX)", want: &pb.Type{Code: pb.TypeCode_STRING}},
+		{input: "ARRAY<BYTES(16)>", want: &pb.Type{Code: pb.TypeCode_ARRAY, ArrayElementType: &pb.Type{Code: pb.TypeCode_BYTES}}},
+	} {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := parseSpannerType(tt.input)
+			if err != nil {
+				t.Fatalf("parseSpannerType() failed: %v", err)
+			}
+			if got.String() != tt.want.String() {
+				t.Errorf("parseSpannerType(): got = %v, want = %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParsePrimaryKey(t *testing.T) {
+	for _, tt := range []struct {
+		desc    string
+		ddl     string
+		want    []KeyColumn
+		wantErr bool
+	}{
+		{
+			desc: "Single column",
+			ddl:  "CREATE TABLE t1 (Id INT64 NOT NULL) PRIMARY KEY(Id)",
+			want: []KeyColumn{{Name: "Id"}},
+		},
+		{
+			desc: "Multiple columns with orderings and interleaving",
+			ddl:  "CREATE TABLE `t2` (\n  A STRING(MAX) DEFAULT (\"(\"),\n  B INT64,\n) PRIMARY KEY (`A` ASC, B DESC),\n  INTERLEAVE IN PARENT t1 ON DELETE CASCADE",
+			want: []KeyColumn{{Name: "A"}, {Name: "B", Desc: true}},
+		},
+		{
+			desc: "Empty",
+			ddl:  "CREATE TABLE t3 (A INT64) PRIMARY KEY ()",
+			want: []KeyColumn{},
+		},
+		{
+			desc:    "Missing",
+			ddl:     "CREATE TABLE t4 (A INT64)",
+			wantErr: true,
+		},
+	} {
+		t.Run(tt.desc, func(t *testing.T) {
+			got, err := parsePrimaryKey(tt.ddl)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parsePrimaryKey(): err = %v, wantErr = %v", err, tt.wantErr)
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("parsePrimaryKey(): got = %v, want = %v", got, tt.want)
+			}
+		})
+	}
+}
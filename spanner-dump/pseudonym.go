@@ -0,0 +1,123 @@
+package spanner_dump
+
+import (
+	"context"
+	"fmt"
+	"path"
+
+	"cloud.google.com/go/spanner"
+)
+
+// tableColumn identifies a column of a table.
+type tableColumn struct {
+	table  string
+	column string
+}
+
+func (c tableColumn) String() string {
+	return c.table + "." + c.column
+}
+
+// columnReference is a column referencing another column by a foreign key or an interleaved primary key prefix.
+type columnReference struct {
+	from tableColumn
+	to   tableColumn
+}
+
+// fetchColumnReferences fetches columns referencing other columns by foreign keys and interleaving in the database.
+// Primary key columns of an interleaved table reference the primary key columns of its parent with the same names.
+func fetchColumnReferences(ctx context.Context, txn *spanner.ReadOnlyTransaction, options spanner.QueryOptions) ([]columnReference, error) {
+	var refs []columnReference
+	if err := txn.QueryWithOptions(ctx, spanner.NewStatement(`
+SELECT t.TABLE_NAME, t.PARENT_TABLE_NAME, k.COLUMN_NAME
+FROM INFORMATION_SCHEMA.TABLES AS t
+JOIN INFORMATION_SCHEMA.INDEX_COLUMNS AS k
+ON k.TABLE_CATALOG = t.TABLE_CATALOG AND k.TABLE_SCHEMA = t.TABLE_SCHEMA AND k.TABLE_NAME = t.PARENT_TABLE_NAME AND k.INDEX_TYPE = 'PRIMARY_KEY'
+WHERE t.TABLE_CATALOG = '' AND t.TABLE_SCHEMA = '' AND t.PARENT_TABLE_NAME IS NOT NULL
+`), options).Do(func(r *spanner.Row) error {
+		var table, parent, column string
+		if err := r.Columns(&table, &parent, &column); err != nil {
+			return err
+		}
+		refs = append(refs, columnReference{from: tableColumn{table, column}, to: tableColumn{parent, column}})
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("failed to fetch interleaved tables: %v", err)
+	}
+
+	foreignKeys, err := fetchSchemaForeignKeys(ctx, txn, options)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch foreign keys: %v", err)
+	}
+	for _, fk := range foreignKeys {
+		for i, column := range fk.columns {
+			refs = append(refs, columnReference{from: tableColumn{fk.table, column}, to: tableColumn{fk.refTable, fk.refColumns[i]}})
+		}
+	}
+	return refs, nil
+}
+
+// hasPseudonyms reports whether the policy has pseudonym rules, which require column references to bind.
+func (p *MaskPolicy) hasPseudonyms() bool {
+	if p == nil {
+		return false
+	}
+	for _, rule := range p.Rules {
+		if rule.Strategy == "pseudonym" {
+			return true
+		}
+	}
+	return false
+}
+
+// pseudonymGroups returns columns to pseudonymize mapped to their groups.
+// A group consists of columns matched by pseudonym rules and all columns connected to them by refs,
+// and values of columns in the same group are pseudonymized by the same mapping so that they remain joinable.
+func (p *MaskPolicy) pseudonymGroups(tables []*Table, refs []columnReference) map[tableColumn]tableColumn {
+	// Union-find of columns, where the root of each set represents the group.
+	parent := map[tableColumn]tableColumn{}
+	var find func(c tableColumn) tableColumn
+	find = func(c tableColumn) tableColumn {
+		p, ok := parent[c]
+		if !ok || p == c {
+			return c
+		}
+		root := find(p)
+		parent[c] = root
+		return root
+	}
+	for _, ref := range refs {
+		from, to := find(ref.from), find(ref.to)
+		if from != to {
+			parent[from] = to
+		}
+	}
+
+	var columns []tableColumn
+	for _, ref := range refs {
+		columns = append(columns, ref.from, ref.to)
+	}
+	for _, table := range tables {
+		for _, column := range table.Columns {
+			columns = append(columns, tableColumn{table.Name, column})
+		}
+	}
+	pseudonymized := map[tableColumn]bool{}
+	for _, c := range columns {
+		for _, rule := range p.Rules {
+			tableMatched, _ := path.Match(rule.Table, c.table)
+			columnMatched, _ := path.Match(rule.Column, c.column)
+			if rule.Strategy == "pseudonym" && tableMatched && columnMatched {
+				pseudonymized[find(c)] = true
+			}
+		}
+	}
+
+	groups := map[tableColumn]tableColumn{}
+	for _, c := range columns {
+		if root := find(c); pseudonymized[root] {
+			groups[c] = root
+		}
+	}
+	return groups
+}
@@ -0,0 +1,97 @@
+package spanner_dump
+
+import (
+	"sort"
+	"strings"
+	"testing"
+)
+
+// pseudonymTestRefs are references of User, Item interleaved in User, Order referencing Item by a foreign key,
+// and Review referencing User by a foreign key, where Review is not dumped.
+var pseudonymTestRefs = []columnReference{
+	{from: tableColumn{"Item", "UserId"}, to: tableColumn{"User", "UserId"}},
+	{from: tableColumn{"Order", "BuyerId"}, to: tableColumn{"Item", "UserId"}},
+	{from: tableColumn{"Order", "ItemId"}, to: tableColumn{"Item", "ItemId"}},
+	{from: tableColumn{"Review", "UserId"}, to: tableColumn{"User", "UserId"}},
+}
+
+func pseudonymTestTables() []*Table {
+	return []*Table{
+		{Name: "User", Columns: []string{"UserId", "Name"}, ColumnTypes: []string{"STRING(36)", "STRING(MAX)"}},
+		{Name: "Item", Columns: []string{"UserId", "ItemId"}, ColumnTypes: []string{"STRING(36)", "INT64"}},
+		{Name: "Order", Columns: []string{"OrderId", "BuyerId", "ItemId"}, ColumnTypes: []string{"INT64", "STRING(36)", "INT64"}},
+	}
+}
+
+func TestMaskPolicy_pseudonymGroups(t *testing.T) {
+	for _, tt := range []struct {
+		rule string
+		want []string
+	}{
+		{rule: "User.UserId:pseudonym", want: []string{"Item.UserId", "Order.BuyerId", "Review.UserId", "User.UserId"}},
+		{rule: "Review.UserId:pseudonym", want: []string{"Item.UserId", "Order.BuyerId", "Review.UserId", "User.UserId"}},
+		{rule: "*.ItemId:pseudonym", want: []string{"Item.ItemId", "Order.ItemId"}},
+		{rule: "Order.OrderId:pseudonym", want: []string{"Order.OrderId"}},
+		{rule: "User.UserId:hash"},
+	} {
+		t.Run(tt.rule, func(t *testing.T) {
+			rule, err := ParseMaskRule(tt.rule)
+			if err != nil {
+				t.Fatalf("ParseMaskRule() failed: %v", err)
+			}
+			p := &MaskPolicy{Rules: []MaskRule{rule}}
+			var got []string
+			for c := range p.pseudonymGroups(pseudonymTestTables(), pseudonymTestRefs) {
+				got = append(got, c.String())
+			}
+			sort.Strings(got)
+			if !equalColumns(got, tt.want) {
+				t.Errorf("pseudonymGroups(): got = %v, want = %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMaskPolicy_bind_pseudonym(t *testing.T) {
+	rules := []MaskRule{
+		{Table: "*", Column: "*Id", Strategy: "null"},
+		{Table: "User", Column: "UserId", Strategy: "pseudonym"},
+	}
+	p := &MaskPolicy{Rules: rules, Key: []byte("key")}
+	maskers, err := p.bind(pseudonymTestTables(), pseudonymTestRefs)
+	if err != nil {
+		t.Fatalf("bind() failed: %v", err)
+	}
+
+	tables := pseudonymTestTables()
+	apply := func(table *Table, values ...string) []string {
+		masked, err := maskers[table.Name].apply(literalValues(t, table, values...))
+		if err != nil {
+			t.Fatalf("apply() failed: %v", err)
+		}
+		decoded, err := decodeValues(masked)
+		if err != nil {
+			t.Fatalf("decodeValues() failed: %v", err)
+		}
+		return decoded
+	}
+	user := apply(tables[0], `"u1"`, `"Alice"`)
+	item := apply(tables[1], `"u1"`, "1")
+	order := apply(tables[2], "10", `"u1"`, "1")
+	// Pseudonyms take precedence over other rules and are the same among connected columns.
+	if user[0] == `"u1"` || len(user[0]) != 36+2 || item[0] != user[0] || order[1] != user[0] {
+		t.Errorf("apply(): got User = %v, Item = %v, Order = %v, want the same pseudonym of u1", user, item, order)
+	}
+	// Other columns are masked by other rules.
+	if item[1] != "NULL" || order[0] != "NULL" || user[1] != `"Alice"` {
+		t.Errorf("apply(): got User = %v, Item = %v, Order = %v", user, item, order)
+	}
+
+	// Columns connected to each other must have the same length.
+	tables = pseudonymTestTables()
+	tables[2].ColumnTypes[1] = "STRING(MAX)"
+	_, err = (&MaskPolicy{Rules: rules[1:], Key: []byte("key")}).bind(tables, pseudonymTestRefs)
+	if err == nil || !strings.Contains(err.Error(), "the length of STRING(MAX) differs from STRING(36)") {
+		t.Errorf("bind(): err = %v, want an error of different lengths", err)
+	}
+}
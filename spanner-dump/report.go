@@ -0,0 +1,140 @@
+package spanner_dump
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync/atomic"
+	"text/tabwriter"
+	"time"
+)
+
+// Report summarizes a dump of tables.
+type Report struct {
+	// ReadTimestamp is the timestamp of the snapshot which the dump read.
+	ReadTimestamp time.Time `json:"readTimestamp"`
+	// Duration is the time spent to dump all tables.
+	Duration time.Duration `json:"-"`
+	// Tables are reports of dumped tables in the dump order.
+	Tables []*TableReport `json:"tables"`
+}
+
+// TableReport summarizes a dump of a single table.
+type TableReport struct {
+	Name string `json:"name"`
+	// Rows is the number of written records.
+	Rows int64 `json:"rows"`
+	// Bytes is the number of bytes of written statements.
+	Bytes int64 `json:"bytes"`
+	// QueryDuration is the time spent to query and write records of the table.
+	QueryDuration time.Duration `json:"-"`
+}
+
+// MarshalJSON encodes durations in strings such as "1.5s".
+func (r *Report) MarshalJSON() ([]byte, error) {
+	type report Report
+	return json.Marshal(struct {
+		*report
+		Duration string `json:"duration"`
+	}{report: (*report)(r), Duration: r.Duration.String()})
+}
+
+// MarshalJSON encodes durations in strings such as "1.5s".
+func (r *TableReport) MarshalJSON() ([]byte, error) {
+	type tableReport TableReport
+	return json.Marshal(struct {
+		*tableReport
+		QueryDuration string `json:"queryDuration"`
+	}{tableReport: (*tableReport)(r), QueryDuration: r.QueryDuration.String()})
+}
+
+// WriteSummary writes a table of rows, bytes, durations, and throughputs of dumped tables.
+func (r *Report) WriteSummary(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "TABLE\tROWS\tBYTES\tDURATION\tROWS/SEC\n")
+	var rows, bytes int64
+	for _, t := range r.Tables {
+		fmt.Fprintf(tw, "%s\t%d\t%s\t%s\t%.1f\n", t.Name, t.Rows, formatBytes(t.Bytes), t.QueryDuration.Round(time.Millisecond), rowsPerSecond(t.Rows, t.QueryDuration))
+		rows += t.Rows
+		bytes += t.Bytes
+	}
+	fmt.Fprintf(tw, "TOTAL\t%d\t%s\t%s\t%.1f\n", rows, formatBytes(bytes), r.Duration.Round(time.Millisecond), rowsPerSecond(rows, r.Duration))
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(w, "Read timestamp: %s\n", r.ReadTimestamp.Format(time.RFC3339Nano))
+	return err
+}
+
+// tableStats counts records of a table being dumped, which are updated concurrently.
+type tableStats struct {
+	name    string
+	rows    atomic.Int64
+	bytes   atomic.Int64
+	started atomic.Int64 // Unix time in nanoseconds, or 0 if the dump has not started.
+	elapsed atomic.Int64 // Duration in nanoseconds, or 0 if the dump has not finished.
+}
+
+func (s *tableStats) start(now time.Time) {
+	s.started.Store(now.UnixNano())
+}
+
+func (s *tableStats) finish(now time.Time) {
+	s.elapsed.Store(max(now.UnixNano()-s.started.Load(), 1))
+}
+
+func (s *tableStats) running() bool {
+	return s.started.Load() != 0 && s.elapsed.Load() == 0
+}
+
+func (s *tableStats) report() *TableReport {
+	return &TableReport{
+		Name:          s.name,
+		Rows:          s.rows.Load(),
+		Bytes:         s.bytes.Load(),
+		QueryDuration: time.Duration(s.elapsed.Load()),
+	}
+}
+
+// writeProgress writes a line of progress for each table being dumped.
+func writeProgress(w io.Writer, stats []*tableStats, started, now time.Time) {
+	for _, s := range stats {
+		if !s.running() {
+			continue
+		}
+		rows := s.rows.Load()
+		fmt.Fprintf(w, "Dumping %s: %d rows, %s, %.1f rows/sec, elapsed %s\n",
+			s.name, rows, formatBytes(s.bytes.Load()), rowsPerSecond(rows, now.Sub(time.Unix(0, s.started.Load()))), now.Sub(started).Round(time.Second))
+	}
+}
+
+func rowsPerSecond(rows int64, d time.Duration) float64 {
+	if d <= 0 {
+		return 0
+	}
+	return float64(rows) / d.Seconds()
+}
+
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	f, i := float64(n)/unit, 0
+	for ; f >= unit && i < 4; i++ {
+		f /= unit
+	}
+	return fmt.Sprintf("%.1f %ciB", f, "KMGTP"[i])
+}
+
+// countingWriter counts bytes written to w.
+type countingWriter struct {
+	w     io.Writer
+	count *atomic.Int64
+}
+
+func (w countingWriter) Write(p []byte) (int, error) {
+	n, err := w.w.Write(p)
+	w.count.Add(int64(n))
+	return n, err
+}
@@ -0,0 +1,63 @@
+package spanner_dump
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+var testReport = &Report{
+	ReadTimestamp: time.Date(2020, 1, 23, 3, 0, 0, 123, time.UTC),
+	Duration:      3 * time.Second,
+	Tables: []*TableReport{
+		{Name: "Users", Rows: 300, Bytes: 2048, QueryDuration: 2 * time.Second},
+		{Name: "Items", Rows: 0, Bytes: 0, QueryDuration: time.Second},
+	},
+}
+
+func TestReport_WriteSummary(t *testing.T) {
+	out := &bytes.Buffer{}
+	if err := testReport.WriteSummary(out); err != nil {
+		t.Fatalf("WriteSummary() failed: %v", err)
+	}
+	want := "" +
+		"TABLE  ROWS  BYTES    DURATION  ROWS/SEC\n" +
+		"Users  300   2.0 KiB  2s        150.0\n" +
+		"Items  0     0 B      1s        0.0\n" +
+		"TOTAL  300   2.0 KiB  3s        100.0\n" +
+		"Read timestamp: 2020-01-23T03:00:00.000000123Z\n"
+	if got := out.String(); got != want {
+		t.Errorf("WriteSummary(): got = %q, want = %q", got, want)
+	}
+}
+
+func TestReport_MarshalJSON(t *testing.T) {
+	got, err := json.Marshal(testReport)
+	if err != nil {
+		t.Fatalf("json.Marshal() failed: %v", err)
+	}
+	want := `{"readTimestamp":"2020-01-23T03:00:00.000000123Z","tables":[` +
+		`{"name":"Users","rows":300,"bytes":2048,"queryDuration":"2s"},` +
+		`{"name":"Items","rows":0,"bytes":0,"queryDuration":"1s"}],"duration":"3s"}`
+	if string(got) != want {
+		t.Errorf("json.Marshal(): got = %s, want = %s", got, want)
+	}
+}
+
+func TestWriteProgress(t *testing.T) {
+	started := time.Date(2020, 1, 23, 3, 0, 0, 0, time.UTC)
+	running, done, pending := &tableStats{name: "Users"}, &tableStats{name: "Items"}, &tableStats{name: "Orders"}
+	running.start(started.Add(10 * time.Second))
+	running.rows.Store(500)
+	running.bytes.Store(3 << 20)
+	done.start(started)
+	done.finish(started.Add(10 * time.Second))
+
+	out := &bytes.Buffer{}
+	writeProgress(out, []*tableStats{done, running, pending}, started, started.Add(15*time.Second))
+	want := "Dumping Users: 500 rows, 3.0 MiB, 100.0 rows/sec, elapsed 15s\n"
+	if got := out.String(); got != want {
+		t.Errorf("writeProgress(): got = %q, want = %q", got, want)
+	}
+}
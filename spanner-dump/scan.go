@@ -0,0 +1,203 @@
+package spanner_dump
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"reflect"
+
+	"cloud.google.com/go/spanner"
+	pb "google.golang.org/genproto/googleapis/spanner/v1"
+)
+
+// Row is a record of a table yielded by Scan.
+type Row struct {
+	table  *Table
+	values []spanner.GenericColumnValue
+}
+
+// Size returns the number of columns.
+func (r Row) Size() int {
+	return len(r.values)
+}
+
+// ColumnNames returns the names of the columns.
+func (r Row) ColumnNames() []string {
+	return r.table.Columns
+}
+
+// ColumnType returns the Spanner type of the i-th column.
+func (r Row) ColumnType(i int) *pb.Type {
+	return r.values[i].Type
+}
+
+// ColumnValue returns the i-th column value in the encoding of Cloud Spanner API.
+func (r Row) ColumnValue(i int) spanner.GenericColumnValue {
+	return r.values[i]
+}
+
+// Column decodes the i-th column value into ptr as spanner.Row.Column does.
+func (r Row) Column(i int, ptr any) error {
+	if i < 0 || i >= len(r.values) {
+		return fmt.Errorf("column index %d out of range", i)
+	}
+	if err := r.values[i].Decode(ptr); err != nil {
+		return fmt.Errorf("failed to decode column %s: %v", r.table.Columns[i], err)
+	}
+	return nil
+}
+
+// ColumnByName decodes the value of the column into ptr as spanner.Row.ColumnByName does.
+func (r Row) ColumnByName(name string, ptr any) error {
+	i := indexOfString(r.table.Columns, name)
+	if i < 0 {
+		return fmt.Errorf("column %s not found", name)
+	}
+	return r.Column(i, ptr)
+}
+
+// GoValue returns the i-th column value as a Go value, which is one of
+// spanner.NullBool, spanner.NullInt64, spanner.NullFloat64, spanner.NullString, []byte,
+// spanner.NullNumeric, spanner.NullDate, spanner.NullTime, spanner.NullJSON, and slices of them for ARRAY,
+// or spanner.GenericColumnValue for other types.
+func (r Row) GoValue(i int) (any, error) {
+	v, err := goValue(r.values[i])
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode column %s: %v", r.table.Columns[i], err)
+	}
+	return v, nil
+}
+
+// GoValues returns all column values as Go values (see GoValue).
+func (r Row) GoValues() ([]any, error) {
+	values := make([]any, len(r.values))
+	for i := range r.values {
+		v, err := r.GoValue(i)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = v
+	}
+	return values, nil
+}
+
+// goValue decodes a column value into the Go value of its type.
+func goValue(v spanner.GenericColumnValue) (any, error) {
+	var ptr any
+	switch v.Type.GetCode() {
+	case pb.TypeCode_ARRAY:
+		switch v.Type.GetArrayElementType().GetCode() {
+		case pb.TypeCode_BOOL:
+			ptr = &[]spanner.NullBool{}
+		case pb.TypeCode_INT64:
+			ptr = &[]spanner.NullInt64{}
+		case pb.TypeCode_FLOAT64:
+			ptr = &[]spanner.NullFloat64{}
+		case pb.TypeCode_STRING:
+			ptr = &[]spanner.NullString{}
+		case pb.TypeCode_BYTES:
+			ptr = &[][]byte{}
+		case pb.TypeCode_NUMERIC:
+			ptr = &[]spanner.NullNumeric{}
+		case pb.TypeCode_DATE:
+			ptr = &[]spanner.NullDate{}
+		case pb.TypeCode_TIMESTAMP:
+			ptr = &[]spanner.NullTime{}
+		case pb.TypeCode_JSON:
+			ptr = &[]spanner.NullJSON{}
+		default:
+			return v, nil
+		}
+	case pb.TypeCode_BOOL:
+		ptr = &spanner.NullBool{}
+	case pb.TypeCode_INT64:
+		ptr = &spanner.NullInt64{}
+	case pb.TypeCode_FLOAT64:
+		ptr = &spanner.NullFloat64{}
+	case pb.TypeCode_STRING:
+		ptr = &spanner.NullString{}
+	case pb.TypeCode_BYTES:
+		ptr = &[]byte{}
+	case pb.TypeCode_NUMERIC:
+		ptr = &spanner.NullNumeric{}
+	case pb.TypeCode_DATE:
+		ptr = &spanner.NullDate{}
+	case pb.TypeCode_TIMESTAMP:
+		ptr = &spanner.NullTime{}
+	case pb.TypeCode_JSON:
+		ptr = &spanner.NullJSON{}
+	default:
+		return v, nil
+	}
+	if err := v.Decode(ptr); err != nil {
+		return nil, err
+	}
+	return reflect.ValueOf(ptr).Elem().Interface(), nil
+}
+
+// Scan calls fn with records of the tables to dump, which are read at the same read timestamp as DumpTables.
+// Tables are scanned one by one in the dependency order, i.e. parents of interleaving and tables referenced by foreign keys first,
+// and records are transformed and masked as DumpTables does. Scan stops and returns the error if fn returns an error.
+func (d *Dumper) Scan(ctx context.Context, fn func(table *Table, row Row) error) error {
+	if err := d.beginSnapshot(ctx); err != nil {
+		return err
+	}
+	txn, tables := d.snapshot, d.snapshotTables
+
+	refs, err := fetchColumnReferences(ctx, txn, d.dataQueryOptions())
+	if err != nil {
+		return fmt.Errorf("failed to fetch dependencies of tables: %v", err)
+	}
+	tableMap := map[string]*Table{}
+	var names []string
+	for _, table := range tables {
+		tableMap[table.Name] = table
+		names = append(names, table.Name)
+	}
+	names, err = sortTablesByDependency(names, refs)
+	if err != nil {
+		return err
+	}
+	if d.maskers, err = d.mask.bind(tables, refs); err != nil {
+		return err
+	}
+
+	encoder := &scanEncoder{fn: fn}
+	for _, name := range names {
+		table := tableMap[name]
+		if err := d.dumpTable(ctx, encoder, table, txn, nil, nil, io.Discard, nil, nil); err != nil {
+			return fmt.Errorf("failed to scan table %s: %v", table.Name, err)
+		}
+	}
+	return d.endSchemaCheck(ctx)
+}
+
+// scanEncoder is an Encoder passing records to fn.
+type scanEncoder struct {
+	fn func(table *Table, row Row) error
+}
+
+func (e *scanEncoder) BeginDump(out io.Writer, dump *DumpInfo) error {
+	return nil
+}
+
+func (e *scanEncoder) BeginTable(out io.Writer, table *Table) (TableEncoder, error) {
+	return &scanTableEncoder{fn: e.fn, table: table}, nil
+}
+
+func (e *scanEncoder) EndDump(out io.Writer) error {
+	return nil
+}
+
+type scanTableEncoder struct {
+	fn    func(table *Table, row Row) error
+	table *Table
+}
+
+func (e *scanTableEncoder) WriteRow(values []spanner.GenericColumnValue) error {
+	return e.fn(e.table, Row{table: e.table, values: values})
+}
+
+func (e *scanTableEncoder) EndTable() error {
+	return nil
+}
@@ -0,0 +1,62 @@
+package spanner_dump
+
+import (
+	"math/big"
+	"reflect"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/civil"
+	"cloud.google.com/go/spanner"
+)
+
+func TestRow(t *testing.T) {
+	table := &Table{
+		Name:        "t",
+		Columns:     []string{"Id", "Name", "Score", "Price", "Birthday", "UpdatedAt", "Data", "Attrs", "Tags", "Flag"},
+		ColumnTypes: []string{"INT64", "STRING(MAX)", "FLOAT64", "NUMERIC", "DATE", "TIMESTAMP", "BYTES(MAX)", "JSON", "ARRAY<INT64>", "BOOL"},
+	}
+	row := Row{table: table, values: literalValues(t, table,
+		"1", `"foo"`, "1.5", `NUMERIC "1.25"`, `DATE "2020-01-23"`, `TIMESTAMP "2020-01-23T03:00:00Z"`, `b"ab"`, `JSON "{\"a\":1}"`, "[1, NULL]", "NULL",
+	)}
+
+	got, err := row.GoValues()
+	if err != nil {
+		t.Fatalf("GoValues() failed: %v", err)
+	}
+	want := []any{
+		spanner.NullInt64{Int64: 1, Valid: true},
+		spanner.NullString{StringVal: "foo", Valid: true},
+		spanner.NullFloat64{Float64: 1.5, Valid: true},
+		spanner.NullNumeric{Numeric: *big.NewRat(5, 4), Valid: true},
+		spanner.NullDate{Date: civil.Date{Year: 2020, Month: 1, Day: 23}, Valid: true},
+		spanner.NullTime{Time: time.Date(2020, 1, 23, 3, 0, 0, 0, time.UTC), Valid: true},
+		[]byte("ab"),
+		spanner.NullJSON{Value: map[string]any{"a": float64(1)}, Valid: true},
+		[]spanner.NullInt64{{Int64: 1, Valid: true}, {}},
+		spanner.NullBool{},
+	}
+	for i := range want {
+		if !reflect.DeepEqual(got[i], want[i]) {
+			t.Errorf("GoValue(%d): got = %#v, want = %#v", i, got[i], want[i])
+		}
+	}
+
+	if got := row.ColumnType(3).GetCode().String(); got != "NUMERIC" {
+		t.Errorf("ColumnType(3): got = %s, want = NUMERIC", got)
+	}
+	var name string
+	if err := row.ColumnByName("Name", &name); err != nil || name != "foo" {
+		t.Errorf("ColumnByName(Name) = %q, %v, want %q", name, err, "foo")
+	}
+	var flag *bool
+	if err := row.ColumnByName("Flag", &flag); err != nil || flag != nil {
+		t.Errorf("ColumnByName(Flag) = %v, %v, want nil", flag, err)
+	}
+	if err := row.ColumnByName("Unknown", &name); err == nil {
+		t.Errorf("ColumnByName(Unknown) succeeded, want error")
+	}
+	if err := row.Column(0, &name); err == nil {
+		t.Errorf("Column(0) into string succeeded, want error")
+	}
+}
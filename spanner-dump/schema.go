@@ -0,0 +1,443 @@
+package spanner_dump
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"cloud.google.com/go/spanner"
+	pb "google.golang.org/genproto/googleapis/spanner/v1"
+)
+
+// schemaTable is a table definition read from INFORMATION_SCHEMA.
+type schemaTable struct {
+	name              string
+	parent            string
+	onDelete          string
+	rowDeletionPolicy string
+	columns           []schemaColumn
+	primaryKey        []KeyColumn
+	checks            []schemaCheck
+}
+
+type schemaColumn struct {
+	name       string
+	typ        string
+	notNull    bool
+	def        string
+	generation string
+	stored     bool
+	options    []string
+}
+
+type schemaCheck struct {
+	name   string
+	clause string
+}
+
+type schemaIndex struct {
+	name         string
+	table        string
+	parent       string
+	unique       bool
+	nullFiltered bool
+	keys         []KeyColumn
+	storing      []string
+}
+
+type schemaForeignKey struct {
+	name       string
+	table      string
+	columns    []string
+	refTable   string
+	refColumns []string
+	onDelete   string
+}
+
+// FetchDDLs reconstructs DDL statements of the tables from INFORMATION_SCHEMA in the transaction,
+// so that they are consistent with records read in the same transaction.
+// DDL statements include tables, columns, types, defaults, generated columns, primary keys, interleaving,
+// row deletion policies, check constraints, secondary indexes, and foreign keys of the tables.
+// Other schema objects such as views, change streams, and sequences are not included.
+// If tableNames is empty, DDL statements of all tables are returned.
+func FetchDDLs(ctx context.Context, txn *spanner.ReadOnlyTransaction, tableNames []string) ([]string, error) {
+	return FetchDDLsWithOptions(ctx, txn, tableNames, spanner.QueryOptions{})
+}
+
+// FetchDDLsWithOptions is FetchDDLs running queries with options, e.g. the priority and the request tag.
+func FetchDDLsWithOptions(ctx context.Context, txn *spanner.ReadOnlyTransaction, tableNames []string, options spanner.QueryOptions) ([]string, error) {
+	tables, err := fetchSchemaTables(ctx, txn, options)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch tables: %v", err)
+	}
+	indexes, err := fetchSchemaIndexes(ctx, txn, tables, options)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch indexes: %v", err)
+	}
+	foreignKeys, err := fetchSchemaForeignKeys(ctx, txn, options)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch foreign keys: %v", err)
+	}
+
+	selected := func(table string) bool { return len(tableNames) == 0 || containsString(tableNames, table) }
+	for _, name := range tableNames {
+		if _, ok := tables[name]; !ok {
+			return nil, fmt.Errorf("unknown table: %s", name)
+		}
+	}
+
+	var ddls []string
+	for _, t := range sortTablesByParent(tables) {
+		if selected(t.name) {
+			ddls = append(ddls, t.ddl())
+		}
+	}
+	for _, index := range indexes {
+		if selected(index.table) {
+			ddls = append(ddls, index.ddl())
+		}
+	}
+	for _, fk := range foreignKeys {
+		if selected(fk.table) {
+			ddls = append(ddls, fk.ddl())
+		}
+	}
+	return ddls, nil
+}
+
+// DumpSnapshotDDLs dumps DDL statements reconstructed from INFORMATION_SCHEMA at the read timestamp of DumpTables.
+// Unlike DumpDDLs, the DDL statements are consistent with the dumped records (see FetchDDLs).
+func (d *Dumper) DumpSnapshotDDLs(ctx context.Context) error {
+	if err := d.beginSnapshot(ctx); err != nil {
+		return err
+	}
+	ddls, err := FetchDDLsWithOptions(ctx, d.snapshot, d.tables, d.dataQueryOptions())
+	if err != nil {
+		return err
+	}
+	for _, ddl := range ddls {
+		fmt.Fprintf(d.out, "%s;\n", ddl)
+	}
+	return nil
+}
+
+func fetchSchemaTables(ctx context.Context, txn *spanner.ReadOnlyTransaction, options spanner.QueryOptions) (map[string]*schemaTable, error) {
+	tables := map[string]*schemaTable{}
+	if err := txn.QueryWithOptions(ctx, spanner.NewStatement(`
+SELECT t.TABLE_NAME, IFNULL(t.PARENT_TABLE_NAME, ''), IFNULL(t.ON_DELETE_ACTION, ''), IFNULL(t.ROW_DELETION_POLICY_EXPRESSION, '')
+FROM INFORMATION_SCHEMA.TABLES AS t
+WHERE t.TABLE_CATALOG = '' AND t.TABLE_SCHEMA = '' AND t.TABLE_TYPE = 'BASE TABLE'
+`), options).Do(func(r *spanner.Row) error {
+		t := &schemaTable{}
+		if err := r.Columns(&t.name, &t.parent, &t.onDelete, &t.rowDeletionPolicy); err != nil {
+			return err
+		}
+		tables[t.name] = t
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	if err := txn.QueryWithOptions(ctx, spanner.NewStatement(`
+SELECT c.TABLE_NAME, c.COLUMN_NAME, c.SPANNER_TYPE, c.IS_NULLABLE, c.COLUMN_DEFAULT,
+    c.IS_GENERATED, IFNULL(c.GENERATION_EXPRESSION, ''), IFNULL(c.IS_STORED, '')
+FROM INFORMATION_SCHEMA.COLUMNS AS c
+WHERE c.TABLE_CATALOG = '' AND c.TABLE_SCHEMA = ''
+ORDER BY c.TABLE_NAME, c.ORDINAL_POSITION
+`), options).Do(func(r *spanner.Row) error {
+		var table, nullable, generated, stored string
+		var def spanner.GenericColumnValue
+		var c schemaColumn
+		if err := r.Columns(&table, &c.name, &c.typ, &nullable, &def, &generated, &c.generation, &stored); err != nil {
+			return err
+		}
+		t, ok := tables[table]
+		if !ok {
+			return nil
+		}
+		defText, err := expressionText(def)
+		if err != nil {
+			return fmt.Errorf("invalid default of %s.%s: %v", table, c.name, err)
+		}
+		c.notNull = nullable == "NO"
+		c.def = defText
+		if generated != "ALWAYS" {
+			c.generation = ""
+		}
+		c.stored = stored == "YES"
+		t.columns = append(t.columns, c)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	if err := txn.QueryWithOptions(ctx, spanner.NewStatement(`
+SELECT o.TABLE_NAME, o.COLUMN_NAME, o.OPTION_NAME, o.OPTION_VALUE
+FROM INFORMATION_SCHEMA.COLUMN_OPTIONS AS o
+WHERE o.TABLE_CATALOG = '' AND o.TABLE_SCHEMA = ''
+ORDER BY o.TABLE_NAME, o.COLUMN_NAME, o.OPTION_NAME
+`), options).Do(func(r *spanner.Row) error {
+		var table, column, name, value string
+		if err := r.Columns(&table, &column, &name, &value); err != nil {
+			return err
+		}
+		t, ok := tables[table]
+		if !ok {
+			return nil
+		}
+		for i := range t.columns {
+			if t.columns[i].name == column {
+				t.columns[i].options = append(t.columns[i].options, fmt.Sprintf("%s = %s", name, strings.ToLower(value)))
+			}
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	// NOT NULL columns are also listed as check constraints named CK_IS_NOT_NULL_<table>_<column>.
+	if err := txn.QueryWithOptions(ctx, spanner.NewStatement(`
+SELECT tc.TABLE_NAME, cc.CONSTRAINT_NAME, cc.CHECK_CLAUSE
+FROM INFORMATION_SCHEMA.CHECK_CONSTRAINTS AS cc
+JOIN INFORMATION_SCHEMA.TABLE_CONSTRAINTS AS tc
+ON tc.CONSTRAINT_CATALOG = cc.CONSTRAINT_CATALOG AND tc.CONSTRAINT_SCHEMA = cc.CONSTRAINT_SCHEMA AND tc.CONSTRAINT_NAME = cc.CONSTRAINT_NAME
+WHERE tc.TABLE_CATALOG = '' AND tc.TABLE_SCHEMA = '' AND tc.CONSTRAINT_TYPE = 'CHECK'
+    AND NOT STARTS_WITH(cc.CONSTRAINT_NAME, 'CK_IS_NOT_NULL_')
+ORDER BY tc.TABLE_NAME, cc.CONSTRAINT_NAME
+`), options).Do(func(r *spanner.Row) error {
+		var table string
+		var c schemaCheck
+		if err := r.Columns(&table, &c.name, &c.clause); err != nil {
+			return err
+		}
+		if t, ok := tables[table]; ok {
+			t.checks = append(t.checks, c)
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return tables, nil
+}
+
+// fetchSchemaIndexes fetches secondary indexes and also sets primary keys of the tables.
+func fetchSchemaIndexes(ctx context.Context, txn *spanner.ReadOnlyTransaction, tables map[string]*schemaTable, options spanner.QueryOptions) ([]*schemaIndex, error) {
+	var indexes []*schemaIndex
+	indexMap := map[string]*schemaIndex{}
+	// Indexes managed by Spanner, e.g. backing indexes of foreign keys, are created implicitly.
+	if err := txn.QueryWithOptions(ctx, spanner.NewStatement(`
+SELECT i.TABLE_NAME, i.INDEX_NAME, IFNULL(i.PARENT_TABLE_NAME, ''), i.IS_UNIQUE, i.IS_NULL_FILTERED
+FROM INFORMATION_SCHEMA.INDEXES AS i
+WHERE i.TABLE_CATALOG = '' AND i.TABLE_SCHEMA = '' AND i.INDEX_TYPE = 'INDEX' AND NOT i.SPANNER_IS_MANAGED
+ORDER BY i.TABLE_NAME, i.INDEX_NAME
+`), options).Do(func(r *spanner.Row) error {
+		index := &schemaIndex{}
+		if err := r.Columns(&index.table, &index.name, &index.parent, &index.unique, &index.nullFiltered); err != nil {
+			return err
+		}
+		indexes = append(indexes, index)
+		indexMap[index.table+"."+index.name] = index
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	// Storing columns have NULL ordinal positions.
+	if err := txn.QueryWithOptions(ctx, spanner.NewStatement(`
+SELECT k.TABLE_NAME, k.INDEX_NAME, k.INDEX_TYPE, k.COLUMN_NAME, k.ORDINAL_POSITION IS NULL, IFNULL(k.COLUMN_ORDERING, '')
+FROM INFORMATION_SCHEMA.INDEX_COLUMNS AS k
+WHERE k.TABLE_CATALOG = '' AND k.TABLE_SCHEMA = '' AND k.INDEX_TYPE IN ('PRIMARY_KEY', 'INDEX')
+ORDER BY k.TABLE_NAME, k.INDEX_NAME, k.ORDINAL_POSITION, k.COLUMN_NAME
+`), options).Do(func(r *spanner.Row) error {
+		var table, indexName, indexType, column, ordering string
+		var storing bool
+		if err := r.Columns(&table, &indexName, &indexType, &column, &storing, &ordering); err != nil {
+			return err
+		}
+		key := KeyColumn{Name: column, Desc: ordering == "DESC"}
+		if indexType == "PRIMARY_KEY" {
+			if t, ok := tables[table]; ok {
+				t.primaryKey = append(t.primaryKey, key)
+			}
+			return nil
+		}
+		index, ok := indexMap[table+"."+indexName]
+		switch {
+		case !ok:
+		case storing:
+			index.storing = append(index.storing, column)
+		default:
+			index.keys = append(index.keys, key)
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return indexes, nil
+}
+
+func fetchSchemaForeignKeys(ctx context.Context, txn *spanner.ReadOnlyTransaction, options spanner.QueryOptions) ([]*schemaForeignKey, error) {
+	var foreignKeys []*schemaForeignKey
+	if err := txn.QueryWithOptions(ctx, spanner.NewStatement(`
+SELECT rc.CONSTRAINT_NAME, kcu.TABLE_NAME, kcu.COLUMN_NAME, ukcu.TABLE_NAME, ukcu.COLUMN_NAME, rc.DELETE_RULE
+FROM INFORMATION_SCHEMA.REFERENTIAL_CONSTRAINTS AS rc
+JOIN INFORMATION_SCHEMA.KEY_COLUMN_USAGE AS kcu
+ON kcu.CONSTRAINT_CATALOG = rc.CONSTRAINT_CATALOG AND kcu.CONSTRAINT_SCHEMA = rc.CONSTRAINT_SCHEMA AND kcu.CONSTRAINT_NAME = rc.CONSTRAINT_NAME
+JOIN INFORMATION_SCHEMA.KEY_COLUMN_USAGE AS ukcu
+ON ukcu.CONSTRAINT_CATALOG = rc.UNIQUE_CONSTRAINT_CATALOG AND ukcu.CONSTRAINT_SCHEMA = rc.UNIQUE_CONSTRAINT_SCHEMA AND ukcu.CONSTRAINT_NAME = rc.UNIQUE_CONSTRAINT_NAME
+    AND ukcu.ORDINAL_POSITION = kcu.POSITION_IN_UNIQUE_CONSTRAINT
+WHERE rc.CONSTRAINT_CATALOG = '' AND rc.CONSTRAINT_SCHEMA = ''
+ORDER BY kcu.TABLE_NAME, rc.CONSTRAINT_NAME, kcu.ORDINAL_POSITION
+`), options).Do(func(r *spanner.Row) error {
+		var name, table, column, refTable, refColumn, onDelete string
+		if err := r.Columns(&name, &table, &column, &refTable, &refColumn, &onDelete); err != nil {
+			return err
+		}
+		if n := len(foreignKeys); n == 0 || foreignKeys[n-1].name != name {
+			foreignKeys = append(foreignKeys, &schemaForeignKey{name: name, table: table, refTable: refTable, onDelete: onDelete})
+		}
+		fk := foreignKeys[len(foreignKeys)-1]
+		fk.columns = append(fk.columns, column)
+		fk.refColumns = append(fk.refColumns, refColumn)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return foreignKeys, nil
+}
+
+// expressionText returns the text of an expression column, which is STRING or BYTES depending on the version of Spanner.
+func expressionText(v spanner.GenericColumnValue) (string, error) {
+	switch v.Type.GetCode() {
+	case pb.TypeCode_BYTES:
+		var b []byte
+		if err := v.Decode(&b); err != nil {
+			return "", err
+		}
+		return string(b), nil
+	default:
+		var s spanner.NullString
+		if err := v.Decode(&s); err != nil {
+			return "", err
+		}
+		return s.StringVal, nil
+	}
+}
+
+// sortTablesByParent sorts tables by name so that parent tables precede their interleaved tables.
+func sortTablesByParent(tables map[string]*schemaTable) []*schemaTable {
+	var names []string
+	for name := range tables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var sorted []*schemaTable
+	visited := map[string]bool{}
+	var visit func(name string)
+	visit = func(name string) {
+		t, ok := tables[name]
+		if !ok || visited[name] {
+			return
+		}
+		visited[name] = true
+		visit(t.parent)
+		sorted = append(sorted, t)
+	}
+	for _, name := range names {
+		visit(name)
+	}
+	return sorted
+}
+
+func (t *schemaTable) ddl() string {
+	sb := &strings.Builder{}
+	fmt.Fprintf(sb, "CREATE TABLE `%s` (\n", t.name)
+	for _, c := range t.columns {
+		fmt.Fprintf(sb, "  %s,\n", c.definition())
+	}
+	for _, c := range t.checks {
+		fmt.Fprintf(sb, "  CONSTRAINT `%s` CHECK (%s),\n", c.name, c.clause)
+	}
+	fmt.Fprintf(sb, ") PRIMARY KEY(%s)", keyColumnList(t.primaryKey))
+	if t.parent != "" {
+		fmt.Fprintf(sb, ",\n  INTERLEAVE IN PARENT `%s`", t.parent)
+		if t.onDelete != "" {
+			fmt.Fprintf(sb, " ON DELETE %s", t.onDelete)
+		}
+	}
+	if t.rowDeletionPolicy != "" {
+		fmt.Fprintf(sb, ",\n  ROW DELETION POLICY (%s)", t.rowDeletionPolicy)
+	}
+	return sb.String()
+}
+
+func (c *schemaColumn) definition() string {
+	sb := &strings.Builder{}
+	fmt.Fprintf(sb, "`%s` %s", c.name, c.typ)
+	if c.notNull {
+		sb.WriteString(" NOT NULL")
+	}
+	switch {
+	case c.generation != "":
+		fmt.Fprintf(sb, " AS (%s)", c.generation)
+		if c.stored {
+			sb.WriteString(" STORED")
+		}
+	case c.def != "":
+		fmt.Fprintf(sb, " DEFAULT (%s)", c.def)
+	}
+	if len(c.options) > 0 {
+		fmt.Fprintf(sb, " OPTIONS (%s)", strings.Join(c.options, ", "))
+	}
+	return sb.String()
+}
+
+func (i *schemaIndex) ddl() string {
+	sb := &strings.Builder{}
+	sb.WriteString("CREATE ")
+	if i.unique {
+		sb.WriteString("UNIQUE ")
+	}
+	if i.nullFiltered {
+		sb.WriteString("NULL_FILTERED ")
+	}
+	fmt.Fprintf(sb, "INDEX `%s` ON `%s`(%s)", i.name, i.table, keyColumnList(i.keys))
+	if len(i.storing) > 0 {
+		fmt.Fprintf(sb, " STORING (%s)", quotedList(i.storing))
+	}
+	if i.parent != "" {
+		fmt.Fprintf(sb, ", INTERLEAVE IN `%s`", i.parent)
+	}
+	return sb.String()
+}
+
+func (fk *schemaForeignKey) ddl() string {
+	ddl := fmt.Sprintf("ALTER TABLE `%s` ADD CONSTRAINT `%s` FOREIGN KEY(%s) REFERENCES `%s`(%s)",
+		fk.table, fk.name, quotedList(fk.columns), fk.refTable, quotedList(fk.refColumns))
+	if fk.onDelete != "" && fk.onDelete != "NO ACTION" {
+		ddl += " ON DELETE " + fk.onDelete
+	}
+	return ddl
+}
+
+func keyColumnList(keys []KeyColumn) string {
+	var columns []string
+	for _, k := range keys {
+		if k.Desc {
+			columns = append(columns, fmt.Sprintf("`%s` DESC", k.Name))
+		} else {
+			columns = append(columns, fmt.Sprintf("`%s`", k.Name))
+		}
+	}
+	return strings.Join(columns, ", ")
+}
+
+func quotedList(names []string) string {
+	var quoted []string
+	for _, name := range names {
+		quoted = append(quoted, fmt.Sprintf("`%s`", name))
+	}
+	return strings.Join(quoted, ", ")
+}
@@ -0,0 +1,166 @@
+package spanner_dump
+
+import (
+	"testing"
+
+	"cloud.google.com/go/spanner"
+	pb "google.golang.org/genproto/googleapis/spanner/v1"
+	structpb "google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestSchemaTable_ddl(t *testing.T) {
+	for _, tt := range []struct {
+		desc  string
+		table *schemaTable
+		want  string
+	}{
+		{
+			desc: "Columns and constraints",
+			table: &schemaTable{
+				name: "Users",
+				columns: []schemaColumn{
+					{name: "Id", typ: "INT64", notNull: true},
+					{name: "Name", typ: "STRING(MAX)", def: `"anonymous"`},
+					{name: "UpperName", typ: "STRING(MAX)", generation: "UPPER(Name)", stored: true},
+					{name: "NameLength", typ: "INT64", generation: "CHAR_LENGTH(Name)"},
+					{name: "UpdatedAt", typ: "TIMESTAMP", notNull: true, options: []string{"allow_commit_timestamp = true"}},
+				},
+				checks:     []schemaCheck{{name: "CK_Name", clause: "Name != ''"}},
+				primaryKey: []KeyColumn{{Name: "Id", Desc: true}},
+			},
+			want: "CREATE TABLE `Users` (\n" +
+				"  `Id` INT64 NOT NULL,\n" +
+				"  `Name` STRING(MAX) DEFAULT (\"anonymous\"),\n" +
+				"  `UpperName` STRING(MAX) AS (UPPER(Name)) STORED,\n" +
+				"  `NameLength` INT64 AS (CHAR_LENGTH(Name)),\n" +
+				"  `UpdatedAt` TIMESTAMP NOT NULL OPTIONS (allow_commit_timestamp = true),\n" +
+				"  CONSTRAINT `CK_Name` CHECK (Name != ''),\n" +
+				") PRIMARY KEY(`Id` DESC)",
+		},
+		{
+			desc: "Interleaved table with row deletion policy",
+			table: &schemaTable{
+				name:              "Items",
+				parent:            "Users",
+				onDelete:          "CASCADE",
+				rowDeletionPolicy: "OLDER_THAN(CreatedAt, INTERVAL 30 DAY)",
+				columns: []schemaColumn{
+					{name: "Id", typ: "INT64", notNull: true},
+					{name: "ItemId", typ: "STRING(36)", notNull: true},
+					{name: "CreatedAt", typ: "TIMESTAMP"},
+				},
+				primaryKey: []KeyColumn{{Name: "Id"}, {Name: "ItemId"}},
+			},
+			want: "CREATE TABLE `Items` (\n" +
+				"  `Id` INT64 NOT NULL,\n" +
+				"  `ItemId` STRING(36) NOT NULL,\n" +
+				"  `CreatedAt` TIMESTAMP,\n" +
+				") PRIMARY KEY(`Id`, `ItemId`),\n" +
+				"  INTERLEAVE IN PARENT `Users` ON DELETE CASCADE,\n" +
+				"  ROW DELETION POLICY (OLDER_THAN(CreatedAt, INTERVAL 30 DAY))",
+		},
+	} {
+		t.Run(tt.desc, func(t *testing.T) {
+			got := tt.table.ddl()
+			if got != tt.want {
+				t.Errorf("ddl(): got = %q, want = %q", got, tt.want)
+			}
+			// The DDL can be loaded by Converter.
+			name, columns, ok, err := parseCreateTable(got)
+			if err != nil || !ok || name != tt.table.name || len(columns) != len(tt.table.columns) {
+				t.Errorf("parseCreateTable(): got = (%q, %v, %v, %v)", name, columns, ok, err)
+			}
+		})
+	}
+}
+
+func TestSchemaIndex_ddl(t *testing.T) {
+	for _, tt := range []struct {
+		desc  string
+		index *schemaIndex
+		want  string
+	}{
+		{
+			desc:  "Index",
+			index: &schemaIndex{name: "UsersByName", table: "Users", keys: []KeyColumn{{Name: "Name"}}},
+			want:  "CREATE INDEX `UsersByName` ON `Users`(`Name`)",
+		},
+		{
+			desc: "Unique null-filtered interleaved index with storing columns",
+			index: &schemaIndex{
+				name: "ItemsByName", table: "Items", parent: "Users", unique: true, nullFiltered: true,
+				keys: []KeyColumn{{Name: "Id"}, {Name: "Name", Desc: true}}, storing: []string{"Price"},
+			},
+			want: "CREATE UNIQUE NULL_FILTERED INDEX `ItemsByName` ON `Items`(`Id`, `Name` DESC) STORING (`Price`), INTERLEAVE IN `Users`",
+		},
+	} {
+		t.Run(tt.desc, func(t *testing.T) {
+			if got := tt.index.ddl(); got != tt.want {
+				t.Errorf("ddl(): got = %q, want = %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSchemaForeignKey_ddl(t *testing.T) {
+	fk := &schemaForeignKey{name: "FK_Orders_Users", table: "Orders", columns: []string{"UserId"}, refTable: "Users", refColumns: []string{"Id"}, onDelete: "NO ACTION"}
+	want := "ALTER TABLE `Orders` ADD CONSTRAINT `FK_Orders_Users` FOREIGN KEY(`UserId`) REFERENCES `Users`(`Id`)"
+	if got := fk.ddl(); got != want {
+		t.Errorf("ddl(): got = %q, want = %q", got, want)
+	}
+	fk.onDelete = "CASCADE"
+	if got := fk.ddl(); got != want+" ON DELETE CASCADE" {
+		t.Errorf("ddl(): got = %q, want = %q", got, want+" ON DELETE CASCADE")
+	}
+}
+
+func TestSortTablesByParent(t *testing.T) {
+	tables := map[string]*schemaTable{
+		"A":  {name: "A", parent: "C"},
+		"B":  {name: "B"},
+		"C":  {name: "C", parent: "D"},
+		"D":  {name: "D"},
+		"AA": {name: "AA", parent: "A"},
+	}
+	var got []string
+	for _, t := range sortTablesByParent(tables) {
+		got = append(got, t.name)
+	}
+	if want := []string{"D", "C", "A", "AA", "B"}; !equalColumns(got, want) {
+		t.Errorf("sortTablesByParent(): got = %v, want = %v", got, want)
+	}
+}
+
+func TestExpressionText(t *testing.T) {
+	for _, tt := range []struct {
+		desc  string
+		value spanner.GenericColumnValue
+		want  string
+	}{
+		{
+			desc:  "STRING",
+			value: spanner.GenericColumnValue{Type: &pb.Type{Code: pb.TypeCode_STRING}, Value: structpb.NewStringValue("1 + 1")},
+			want:  "1 + 1",
+		},
+		{
+			desc:  "BYTES",
+			value: spanner.GenericColumnValue{Type: &pb.Type{Code: pb.TypeCode_BYTES}, Value: structpb.NewStringValue("MSArIDE=")},
+			want:  "1 + 1",
+		},
+		{
+			desc:  "NULL",
+			value: spanner.GenericColumnValue{Type: &pb.Type{Code: pb.TypeCode_STRING}, Value: structpb.NewNullValue()},
+			want:  "",
+		},
+	} {
+		t.Run(tt.desc, func(t *testing.T) {
+			got, err := expressionText(tt.value)
+			if err != nil {
+				t.Fatalf("expressionText() failed: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("expressionText(): got = %q, want = %q", got, tt.want)
+			}
+		})
+	}
+}
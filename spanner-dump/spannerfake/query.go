@@ -0,0 +1,485 @@
+package spannerfake
+
+import (
+	"encoding/base64"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	spanner_dump "github.com/Jumpaku/spanner-dump-whare/spanner-dump"
+	"github.com/Jumpaku/spanner-dump-whare/spanner-dump/internal/sqltoken"
+	pb "google.golang.org/genproto/googleapis/spanner/v1"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// resultSet is a result of a read or a query.
+type resultSet struct {
+	fields []*pb.StructType_Field
+	rows   [][]*structpb.Value
+}
+
+// query is a parsed SELECT statement in the subset supported by the fake:
+//
+//	SELECT { * | expr [[AS] alias] [, ...] } [FROM table [[AS] alias]]
+//	[WHERE condition [AND ...]] [ORDER BY column [ASC | DESC] [, ...]] [LIMIT count [OFFSET skip]]
+//
+// where expr is a column, a literal, or a query parameter,
+// and condition is a comparison of exprs, expr [NOT] IN (expr, ...), or expr IS [NOT] NULL.
+type query struct {
+	star    bool
+	items   []selectItem
+	table   string
+	alias   string
+	where   []condition
+	orderBy []orderItem
+	limit   *expr
+	offset  *expr
+}
+
+type selectItem struct {
+	expr  expr
+	alias string
+}
+
+type condition struct {
+	op    string
+	left  expr
+	right []expr
+}
+
+type orderItem struct {
+	expr expr
+	desc bool
+}
+
+type exprKind int
+
+const (
+	exprColumn exprKind = iota
+	exprLiteral
+	exprParam
+)
+
+type expr struct {
+	kind exprKind
+	// name is a column name or a parameter name.
+	name string
+	// qualifier is a table name or an alias qualifying a column.
+	qualifier string
+	value     *structpb.Value
+	typ       *pb.Type
+}
+
+// operand is an expr bound to a row, where column is the index of the column or -1.
+type operand struct {
+	column int
+	value  *structpb.Value
+	typ    *pb.Type
+}
+
+func (o operand) eval(row []*structpb.Value) *structpb.Value {
+	if o.column >= 0 {
+		return row[o.column]
+	}
+	return o.value
+}
+
+// parseQuery parses a SELECT statement.
+func parseQuery(sql string) (*query, error) {
+	p, err := newQueryParser(sql)
+	if err != nil {
+		return nil, err
+	}
+	if !p.consumeKeyword("SELECT") {
+		return nil, fmt.Errorf("only SELECT statements are supported")
+	}
+	q := &query{}
+	if p.consumeSymbol("*") {
+		q.star = true
+	} else {
+		for {
+			e, err := p.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			item := selectItem{expr: e, alias: e.name}
+			if p.consumeKeyword("AS") || p.peek().Kind == sqltoken.Ident && !p.isReserved() {
+				if item.alias, err = p.expectIdent(); err != nil {
+					return nil, err
+				}
+			}
+			q.items = append(q.items, item)
+			if !p.consumeSymbol(",") {
+				break
+			}
+		}
+	}
+
+	if p.consumeKeyword("FROM") {
+		if q.table, err = p.expectIdent(); err != nil {
+			return nil, err
+		}
+		if p.consumeKeyword("AS") || p.peek().Kind == sqltoken.Ident && !p.isReserved() {
+			if q.alias, err = p.expectIdent(); err != nil {
+				return nil, err
+			}
+		}
+	} else if q.star {
+		return nil, fmt.Errorf("SELECT * requires FROM clause")
+	}
+
+	if p.consumeKeyword("WHERE") {
+		for {
+			c, err := p.parseCondition()
+			if err != nil {
+				return nil, err
+			}
+			q.where = append(q.where, c)
+			if !p.consumeKeyword("AND") {
+				break
+			}
+		}
+	}
+	if p.consumeKeyword("ORDER") {
+		if !p.consumeKeyword("BY") {
+			return nil, fmt.Errorf("expected BY but got %q", p.peek().Text)
+		}
+		for {
+			e, err := p.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			item := orderItem{expr: e}
+			if p.consumeKeyword("DESC") {
+				item.desc = true
+			} else {
+				p.consumeKeyword("ASC")
+			}
+			q.orderBy = append(q.orderBy, item)
+			if !p.consumeSymbol(",") {
+				break
+			}
+		}
+	}
+	if p.consumeKeyword("LIMIT") {
+		e, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		q.limit = &e
+		if p.consumeKeyword("OFFSET") {
+			e, err := p.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			q.offset = &e
+		}
+	}
+	p.consumeSymbol(";")
+	if t := p.peek(); t.Kind != sqltoken.EOF {
+		return nil, fmt.Errorf("unsupported syntax at %q", t.Text)
+	}
+	return q, nil
+}
+
+func (p *queryParser) parseCondition() (condition, error) {
+	left, err := p.parseExpr()
+	if err != nil {
+		return condition{}, err
+	}
+	switch {
+	case p.consumeKeyword("IS"):
+		op := "IS"
+		if p.consumeKeyword("NOT") {
+			op = "IS NOT"
+		}
+		if !p.consumeKeyword("NULL") {
+			return condition{}, fmt.Errorf("expected NULL but got %q", p.peek().Text)
+		}
+		return condition{op: op, left: left}, nil
+	case p.isKeyword("IN") || p.isKeyword("NOT"):
+		op := "IN"
+		if p.consumeKeyword("NOT") {
+			op = "NOT IN"
+		}
+		if !p.consumeKeyword("IN") {
+			return condition{}, fmt.Errorf("expected IN but got %q", p.peek().Text)
+		}
+		if !p.consumeSymbol("(") {
+			return condition{}, fmt.Errorf("expected ( but got %q", p.peek().Text)
+		}
+		c := condition{op: op, left: left}
+		for {
+			e, err := p.parseExpr()
+			if err != nil {
+				return condition{}, err
+			}
+			c.right = append(c.right, e)
+			if !p.consumeSymbol(",") {
+				break
+			}
+		}
+		if !p.consumeSymbol(")") {
+			return condition{}, fmt.Errorf("expected ) but got %q", p.peek().Text)
+		}
+		return c, nil
+	}
+
+	t := p.next()
+	switch op := t.Text; {
+	case t.Kind == sqltoken.Symbol && (op == "=" || op == "!=" || op == "<>" || op == "<" || op == "<=" || op == ">" || op == ">="):
+		right, err := p.parseExpr()
+		if err != nil {
+			return condition{}, err
+		}
+		return condition{op: op, left: left, right: []expr{right}}, nil
+	default:
+		return condition{}, fmt.Errorf("unsupported operator %q", op)
+	}
+}
+
+func (p *queryParser) parseExpr() (expr, error) {
+	t := p.next()
+	switch t.Kind {
+	case sqltoken.Param:
+		return expr{kind: exprParam, name: t.Text}, nil
+	case sqltoken.String:
+		return expr{kind: exprLiteral, value: structpb.NewStringValue(t.Text), typ: &pb.Type{Code: pb.TypeCode_STRING}}, nil
+	case sqltoken.Bytes:
+		return expr{kind: exprLiteral, value: structpb.NewStringValue(base64.StdEncoding.EncodeToString([]byte(t.Text))), typ: &pb.Type{Code: pb.TypeCode_BYTES}}, nil
+	case sqltoken.Number:
+		return numberLiteral(t.Text)
+	case sqltoken.Symbol:
+		if t.Text == "-" && p.peek().Kind == sqltoken.Number {
+			return numberLiteral("-" + p.next().Text)
+		}
+	case sqltoken.Ident:
+		if !t.Quoted {
+			switch strings.ToUpper(t.Text) {
+			case "NULL":
+				return expr{kind: exprLiteral, value: structpb.NewNullValue(), typ: &pb.Type{Code: pb.TypeCode_INT64}}, nil
+			case "TRUE", "FALSE":
+				return expr{kind: exprLiteral, value: structpb.NewBoolValue(strings.EqualFold(t.Text, "TRUE")), typ: &pb.Type{Code: pb.TypeCode_BOOL}}, nil
+			case "DATE", "TIMESTAMP", "NUMERIC", "JSON":
+				if p.peek().Kind == sqltoken.String {
+					code := pb.TypeCode(pb.TypeCode_value[strings.ToUpper(t.Text)])
+					return expr{kind: exprLiteral, value: structpb.NewStringValue(p.next().Text), typ: &pb.Type{Code: code}}, nil
+				}
+			}
+		}
+		e := expr{kind: exprColumn, name: t.Text}
+		if p.consumeSymbol(".") {
+			name, err := p.expectIdent()
+			if err != nil {
+				return expr{}, err
+			}
+			e.qualifier, e.name = e.name, name
+		}
+		return e, nil
+	}
+	return expr{}, fmt.Errorf("unsupported expression at %q", t.Text)
+}
+
+func numberLiteral(text string) (expr, error) {
+	base := 10
+	if strings.HasPrefix(strings.ToLower(strings.TrimPrefix(text, "-")), "0x") {
+		base = 0
+	}
+	if n, err := strconv.ParseInt(text, base, 64); err == nil {
+		return expr{kind: exprLiteral, value: structpb.NewStringValue(strconv.FormatInt(n, 10)), typ: &pb.Type{Code: pb.TypeCode_INT64}}, nil
+	}
+	f, err := strconv.ParseFloat(text, 64)
+	if err != nil {
+		return expr{}, fmt.Errorf("invalid number %q", text)
+	}
+	return expr{kind: exprLiteral, value: structpb.NewNumberValue(f), typ: &pb.Type{Code: pb.TypeCode_FLOAT64}}, nil
+}
+
+// execute runs the query on the table, which is nil if the query has no FROM clause.
+func (q *query) execute(t *table, params *structpb.Struct, paramTypes map[string]*pb.Type) (*resultSet, error) {
+	bind := func(e expr) (operand, error) {
+		switch e.kind {
+		case exprColumn:
+			if t == nil || e.qualifier != "" && !strings.EqualFold(e.qualifier, t.name) && !strings.EqualFold(e.qualifier, q.alias) {
+				return operand{}, fmt.Errorf("unrecognized name: %s", e.name)
+			}
+			i := t.columnIndex(e.name)
+			if i < 0 {
+				return operand{}, fmt.Errorf("unrecognized name: %s", e.name)
+			}
+			return operand{column: i, typ: t.types[i]}, nil
+		case exprParam:
+			v, ok := params.GetFields()[e.name]
+			if !ok {
+				return operand{}, fmt.Errorf("no parameter found for binding: %s", e.name)
+			}
+			return operand{column: -1, value: v, typ: paramTypes[e.name]}, nil
+		default:
+			return operand{column: -1, value: e.value, typ: e.typ}, nil
+		}
+	}
+
+	rows := [][]*structpb.Value{nil}
+	if t != nil {
+		rows = t.rows
+	}
+
+	for _, c := range q.where {
+		left, err := bind(c.left)
+		if err != nil {
+			return nil, err
+		}
+		var right []operand
+		for _, e := range c.right {
+			o, err := bind(e)
+			if err != nil {
+				return nil, err
+			}
+			right = append(right, o)
+		}
+		var filtered [][]*structpb.Value
+		for _, row := range rows {
+			if c.holds(row, left, right) {
+				filtered = append(filtered, row)
+			}
+		}
+		rows = filtered
+	}
+
+	if len(q.orderBy) > 0 {
+		var keys []operand
+		for _, item := range q.orderBy {
+			o, err := bind(item.expr)
+			if err != nil {
+				return nil, err
+			}
+			keys = append(keys, o)
+		}
+		rows = append([][]*structpb.Value{}, rows...)
+		sort.SliceStable(rows, func(i, j int) bool {
+			for k, key := range keys {
+				c := spanner_dump.CompareValues(key.typ, key.eval(rows[i]), key.eval(rows[j]))
+				if q.orderBy[k].desc {
+					c = -c
+				}
+				if c != 0 {
+					return c < 0
+				}
+			}
+			return false
+		})
+	}
+
+	if q.offset != nil {
+		n, err := q.count(*q.offset, bind)
+		if err != nil {
+			return nil, err
+		}
+		rows = rows[min(n, len(rows)):]
+	}
+	if q.limit != nil {
+		n, err := q.count(*q.limit, bind)
+		if err != nil {
+			return nil, err
+		}
+		rows = rows[:min(n, len(rows))]
+	}
+
+	var columns []operand
+	rs := &resultSet{}
+	if q.star {
+		for i, name := range t.columns {
+			columns = append(columns, operand{column: i, typ: t.types[i]})
+			rs.fields = append(rs.fields, &pb.StructType_Field{Name: name, Type: t.types[i]})
+		}
+	}
+	for _, item := range q.items {
+		o, err := bind(item.expr)
+		if err != nil {
+			return nil, err
+		}
+		if o.column >= 0 && item.alias == item.expr.name {
+			item.alias = t.columns[o.column]
+		}
+		columns = append(columns, o)
+		rs.fields = append(rs.fields, &pb.StructType_Field{Name: item.alias, Type: o.typ})
+	}
+	for _, row := range rows {
+		values := make([]*structpb.Value, len(columns))
+		for i, o := range columns {
+			values[i] = o.eval(row)
+		}
+		rs.rows = append(rs.rows, values)
+	}
+	return rs, nil
+}
+
+func (q *query) count(e expr, bind func(e expr) (operand, error)) (int, error) {
+	o, err := bind(e)
+	if err != nil {
+		return 0, err
+	}
+	n, err := strconv.Atoi(o.value.GetStringValue())
+	if o.column >= 0 || err != nil || n < 0 {
+		return 0, fmt.Errorf("LIMIT and OFFSET require a non-negative integer literal or parameter")
+	}
+	return n, nil
+}
+
+// holds reports whether the condition holds for the row, where comparisons with NULL never hold.
+func (c condition) holds(row []*structpb.Value, left operand, right []operand) bool {
+	l := left.eval(row)
+	switch c.op {
+	case "IS":
+		return isNull(l)
+	case "IS NOT":
+		return !isNull(l)
+	}
+	if isNull(l) {
+		return false
+	}
+
+	typ := left.typ
+	if left.column < 0 && right[0].column >= 0 {
+		typ = right[0].typ
+	}
+	compare := func(o operand) (int, bool) {
+		r := o.eval(row)
+		if isNull(r) {
+			return 0, false
+		}
+		return spanner_dump.CompareValues(typ, l, r), true
+	}
+	switch c.op {
+	case "IN", "NOT IN":
+		hasNull := false
+		for _, o := range right {
+			r, ok := compare(o)
+			if ok && r == 0 {
+				return c.op == "IN"
+			}
+			hasNull = hasNull || !ok
+		}
+		return c.op == "NOT IN" && !hasNull
+	}
+
+	r, ok := compare(right[0])
+	if !ok {
+		return false
+	}
+	switch c.op {
+	case "=":
+		return r == 0
+	case "!=", "<>":
+		return r != 0
+	case "<":
+		return r < 0
+	case "<=":
+		return r <= 0
+	case ">":
+		return r > 0
+	default:
+		return r >= 0
+	}
+}
@@ -0,0 +1,389 @@
+// Package spannerfake provides an in-process fake of the Cloud Spanner API serving records of dump files,
+// which enables tests to read them through spanner.Client without the emulator or any other external services.
+//
+// The fake supports sessions, read-only transactions, reads by primary keys, key ranges, or all keys,
+// and queries in the subset of GoogleSQL described below. Writes, DML, and reads by secondary indexes are not supported.
+//
+//	SELECT { * | expr [[AS] alias] [, ...] } [FROM table [[AS] alias]]
+//	[WHERE condition [AND ...]] [ORDER BY column [ASC | DESC] [, ...]] [LIMIT count [OFFSET skip]]
+//
+// where expr is a column, a literal, or a query parameter,
+// and condition is a comparison of exprs, expr [NOT] IN (expr, ...), or expr IS [NOT] NULL.
+package spannerfake
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"cloud.google.com/go/spanner"
+	spanner_dump "github.com/Jumpaku/spanner-dump-whare/spanner-dump"
+	"google.golang.org/api/option"
+	"google.golang.org/api/option/internaloption"
+	pb "google.golang.org/genproto/googleapis/spanner/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// rowsPerPartialResultSet is the number of rows sent in a single PartialResultSet of a streaming read or query.
+const rowsPerPartialResultSet = 100
+
+// Server is an in-process gRPC server implementing spanner.v1.Spanner on tables loaded from dump files.
+// It serves the same tables for any database name.
+type Server struct {
+	pb.UnimplementedSpannerServer
+
+	// Addr is the address the server listens on.
+	Addr string
+
+	grpcServer *grpc.Server
+
+	mu            sync.RWMutex
+	tables        map[string]*table
+	sessions      map[string]bool
+	transactions  map[string]bool
+	nextID        int
+	readTimestamp time.Time
+}
+
+// NewServer starts a server listening on a local port with tables loaded from dump files produced by Dumper,
+// which must include DDL statements of the tables.
+func NewServer(dumps ...io.Reader) (*Server, error) {
+	s := &Server{
+		tables:        map[string]*table{},
+		sessions:      map[string]bool{},
+		transactions:  map[string]bool{},
+		readTimestamp: time.Now(),
+	}
+	for _, dump := range dumps {
+		if err := s.Load(dump); err != nil {
+			return nil, err
+		}
+	}
+
+	lis, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen: %v", err)
+	}
+	s.Addr = lis.Addr().String()
+	s.grpcServer = grpc.NewServer()
+	pb.RegisterSpannerServer(s.grpcServer, s)
+	go s.grpcServer.Serve(lis)
+	return s, nil
+}
+
+// NewServerFromFiles starts a server with tables loaded from dump files at the paths.
+func NewServerFromFiles(paths ...string) (*Server, error) {
+	var dumps []io.Reader
+	for _, path := range paths {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open dump file: %v", err)
+		}
+		defer f.Close()
+		dumps = append(dumps, f)
+	}
+	return NewServer(dumps...)
+}
+
+// Load loads tables and records from a dump file.
+// Tables already loaded are kept, and a record replaces the loaded one with the same primary key.
+// If the dump fails to load, nothing in it is loaded.
+func (s *Server) Load(r io.Reader) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// The dump is loaded into copies of the tables, which replace the tables only if the whole dump is loaded.
+	tables := map[string]*table{}
+	for name, tbl := range s.tables {
+		tables[name] = tbl.clone()
+	}
+	err := spanner_dump.ReadDump(r,
+		func(t *spanner_dump.Table, columnTypes []*pb.Type) error {
+			if _, exists := tables[strings.ToLower(t.Name)]; exists {
+				return nil
+			}
+			tbl, err := newTable(t, columnTypes)
+			if err != nil {
+				return err
+			}
+			tables[strings.ToLower(t.Name)] = tbl
+			return nil
+		},
+		func(t *spanner_dump.Table, row spanner_dump.Row) error {
+			return tables[strings.ToLower(t.Name)].add(row)
+		})
+	if err != nil {
+		return fmt.Errorf("failed to load dump: %v", err)
+	}
+	for _, tbl := range tables {
+		tbl.sort()
+	}
+	s.tables = tables
+	return nil
+}
+
+// ClientOptions returns options to connect spanner.Client to the server.
+func (s *Server) ClientOptions() []option.ClientOption {
+	return []option.ClientOption{
+		option.WithEndpoint("passthrough:///" + s.Addr),
+		option.WithGRPCDialOption(grpc.WithTransportCredentials(insecure.NewCredentials())),
+		option.WithoutAuthentication(),
+		internaloption.SkipDialSettingsValidation(),
+	}
+}
+
+// NewClient creates spanner.Client connected to the server.
+// database is a database path in the form of projects/<project>/instances/<instance>/databases/<database>.
+func (s *Server) NewClient(ctx context.Context, database string) (*spanner.Client, error) {
+	return spanner.NewClientWithConfig(ctx, database, spanner.ClientConfig{DisableNativeMetrics: true}, s.ClientOptions()...)
+}
+
+// Close stops the server.
+func (s *Server) Close() {
+	s.grpcServer.Stop()
+}
+
+func (s *Server) newSession(database string) *pb.Session {
+	s.nextID++
+	name := fmt.Sprintf("%s/sessions/%d", database, s.nextID)
+	s.sessions[name] = true
+	return &pb.Session{Name: name, CreateTime: timestamppb.Now()}
+}
+
+func (s *Server) CreateSession(ctx context.Context, req *pb.CreateSessionRequest) (*pb.Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.newSession(req.Database), nil
+}
+
+func (s *Server) BatchCreateSessions(ctx context.Context, req *pb.BatchCreateSessionsRequest) (*pb.BatchCreateSessionsResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	resp := &pb.BatchCreateSessionsResponse{}
+	for i := int32(0); i < req.SessionCount; i++ {
+		resp.Session = append(resp.Session, s.newSession(req.Database))
+	}
+	return resp, nil
+}
+
+func (s *Server) GetSession(ctx context.Context, req *pb.GetSessionRequest) (*pb.Session, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if err := s.checkSession(req.Name); err != nil {
+		return nil, err
+	}
+	return &pb.Session{Name: req.Name}, nil
+}
+
+func (s *Server) DeleteSession(ctx context.Context, req *pb.DeleteSessionRequest) (*emptypb.Empty, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.checkSession(req.Name); err != nil {
+		return nil, err
+	}
+	delete(s.sessions, req.Name)
+	return &emptypb.Empty{}, nil
+}
+
+func (s *Server) checkSession(name string) error {
+	if !s.sessions[name] {
+		return status.Errorf(codes.NotFound, "Session not found: %s", name)
+	}
+	return nil
+}
+
+func (s *Server) BeginTransaction(ctx context.Context, req *pb.BeginTransactionRequest) (*pb.Transaction, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.checkSession(req.Session); err != nil {
+		return nil, err
+	}
+	return s.beginTransaction(req.Options)
+}
+
+func (s *Server) beginTransaction(options *pb.TransactionOptions) (*pb.Transaction, error) {
+	if options.GetReadOnly() == nil {
+		return nil, status.Errorf(codes.Unimplemented, "only read-only transactions are supported")
+	}
+	s.nextID++
+	id := strconv.Itoa(s.nextID)
+	s.transactions[id] = true
+	return &pb.Transaction{Id: []byte(id), ReadTimestamp: timestamppb.New(s.readTimestamp)}, nil
+}
+
+// transaction returns a transaction to be returned in the metadata of a result for the selector.
+func (s *Server) transaction(selector *pb.TransactionSelector) (*pb.Transaction, error) {
+	switch {
+	case selector.GetBegin() != nil:
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		return s.beginTransaction(selector.GetBegin())
+	case len(selector.GetId()) > 0:
+		s.mu.RLock()
+		defer s.mu.RUnlock()
+		if !s.transactions[string(selector.GetId())] {
+			return nil, status.Errorf(codes.FailedPrecondition, "transaction %s not found", selector.GetId())
+		}
+		return nil, nil
+	case selector.GetSingleUse() != nil && selector.GetSingleUse().GetReadOnly() == nil:
+		return nil, status.Errorf(codes.Unimplemented, "only read-only transactions are supported")
+	case selector.GetSingleUse().GetReadOnly().GetReturnReadTimestamp():
+		return &pb.Transaction{ReadTimestamp: timestamppb.New(s.readTimestamp)}, nil
+	default:
+		return nil, nil
+	}
+}
+
+func (s *Server) Read(ctx context.Context, req *pb.ReadRequest) (*pb.ResultSet, error) {
+	rs, tx, err := s.read(req)
+	if err != nil {
+		return nil, err
+	}
+	return resultSetProto(rs, tx), nil
+}
+
+func (s *Server) StreamingRead(req *pb.ReadRequest, stream pb.Spanner_StreamingReadServer) error {
+	rs, tx, err := s.read(req)
+	if err != nil {
+		return err
+	}
+	return sendPartialResultSets(stream.Send, rs, tx, req.ResumeToken)
+}
+
+func (s *Server) read(req *pb.ReadRequest) (*resultSet, *pb.Transaction, error) {
+	tx, err := s.transaction(req.Transaction)
+	if err != nil {
+		return nil, nil, err
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if err := s.checkSession(req.Session); err != nil {
+		return nil, nil, err
+	}
+	if req.Index != "" {
+		return nil, nil, status.Errorf(codes.Unimplemented, "reads by secondary indexes are not supported")
+	}
+	t, ok := s.tables[strings.ToLower(req.Table)]
+	if !ok {
+		return nil, nil, status.Errorf(codes.NotFound, "Table not found: %s", req.Table)
+	}
+
+	rows, err := t.read(req.KeySet)
+	if err != nil {
+		return nil, nil, status.Errorf(codes.InvalidArgument, "%v", err)
+	}
+	if req.Limit > 0 && int64(len(rows)) > req.Limit {
+		rows = rows[:req.Limit]
+	}
+	rs := &resultSet{}
+	var columns []int
+	for _, name := range req.Columns {
+		i := t.columnIndex(name)
+		if i < 0 {
+			return nil, nil, status.Errorf(codes.NotFound, "Column not found in table %s: %s", t.name, name)
+		}
+		columns = append(columns, i)
+		rs.fields = append(rs.fields, &pb.StructType_Field{Name: t.columns[i], Type: t.types[i]})
+	}
+	for _, row := range rows {
+		values := make([]*structpb.Value, len(columns))
+		for i, c := range columns {
+			values[i] = row[c]
+		}
+		rs.rows = append(rs.rows, values)
+	}
+	return rs, tx, nil
+}
+
+func (s *Server) ExecuteSql(ctx context.Context, req *pb.ExecuteSqlRequest) (*pb.ResultSet, error) {
+	rs, tx, err := s.executeSQL(req)
+	if err != nil {
+		return nil, err
+	}
+	return resultSetProto(rs, tx), nil
+}
+
+func (s *Server) ExecuteStreamingSql(req *pb.ExecuteSqlRequest, stream pb.Spanner_ExecuteStreamingSqlServer) error {
+	rs, tx, err := s.executeSQL(req)
+	if err != nil {
+		return err
+	}
+	return sendPartialResultSets(stream.Send, rs, tx, req.ResumeToken)
+}
+
+func (s *Server) executeSQL(req *pb.ExecuteSqlRequest) (*resultSet, *pb.Transaction, error) {
+	tx, err := s.transaction(req.Transaction)
+	if err != nil {
+		return nil, nil, err
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if err := s.checkSession(req.Session); err != nil {
+		return nil, nil, err
+	}
+	q, err := parseQuery(req.Sql)
+	if err != nil {
+		return nil, nil, status.Errorf(codes.InvalidArgument, "failed to parse query: %v", err)
+	}
+	var t *table
+	if q.table != "" {
+		var ok bool
+		if t, ok = s.tables[strings.ToLower(q.table)]; !ok {
+			return nil, nil, status.Errorf(codes.InvalidArgument, "Table not found: %s", q.table)
+		}
+	}
+	rs, err := q.execute(t, req.Params, req.ParamTypes)
+	if err != nil {
+		return nil, nil, status.Errorf(codes.InvalidArgument, "%v", err)
+	}
+	return rs, tx, nil
+}
+
+func resultSetProto(rs *resultSet, tx *pb.Transaction) *pb.ResultSet {
+	result := &pb.ResultSet{Metadata: &pb.ResultSetMetadata{RowType: &pb.StructType{Fields: rs.fields}, Transaction: tx}}
+	for _, row := range rs.rows {
+		result.Rows = append(result.Rows, &structpb.ListValue{Values: row})
+	}
+	return result
+}
+
+// sendPartialResultSets sends rows in chunks with resume tokens, which are the numbers of rows sent so far.
+func sendPartialResultSets(send func(*pb.PartialResultSet) error, rs *resultSet, tx *pb.Transaction, resumeToken []byte) error {
+	start := 0
+	if len(resumeToken) > 0 {
+		n, err := strconv.Atoi(string(resumeToken))
+		if err != nil || n < 0 || n > len(rs.rows) {
+			return status.Errorf(codes.InvalidArgument, "invalid resume token: %q", resumeToken)
+		}
+		start = n
+	}
+	prs := &pb.PartialResultSet{Metadata: &pb.ResultSetMetadata{RowType: &pb.StructType{Fields: rs.fields}, Transaction: tx}}
+	for {
+		end := min(start+rowsPerPartialResultSet, len(rs.rows))
+		for _, row := range rs.rows[start:end] {
+			prs.Values = append(prs.Values, row...)
+		}
+		if end < len(rs.rows) {
+			prs.ResumeToken = []byte(strconv.Itoa(end))
+		}
+		if err := send(prs); err != nil {
+			return err
+		}
+		if end == len(rs.rows) {
+			return nil
+		}
+		start = end
+		prs = &pb.PartialResultSet{}
+	}
+}
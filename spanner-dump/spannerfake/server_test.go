@@ -0,0 +1,348 @@
+package spannerfake
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"cloud.google.com/go/spanner"
+	spanner_dump "github.com/Jumpaku/spanner-dump-whare/spanner-dump"
+	"google.golang.org/api/iterator"
+	pb "google.golang.org/genproto/googleapis/spanner/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+const testDatabase = "projects/p/instances/i/databases/d"
+
+const testDump = "CREATE TABLE Singers (\n" +
+	"  SingerId INT64 NOT NULL,\n" +
+	"  Name STRING(MAX),\n" +
+	"  Score FLOAT64,\n" +
+	"  Birth DATE,\n" +
+	") PRIMARY KEY(SingerId);\n" +
+	"CREATE TABLE Albums (\n" +
+	"  SingerId INT64 NOT NULL,\n" +
+	"  AlbumId INT64 NOT NULL,\n" +
+	"  Title STRING(MAX),\n" +
+	") PRIMARY KEY(SingerId, AlbumId DESC),\n" +
+	"  INTERLEAVE IN PARENT Singers ON DELETE CASCADE;\n" +
+	"CREATE INDEX AlbumsByTitle ON Albums(Title);\n" +
+	"INSERT INTO `Singers` (`SingerId`, `Name`, `Score`, `Birth`) VALUES (2, \"Bob\", 1.5, DATE \"1990-02-01\"), (1, \"Alice\", NULL, NULL);\n" +
+	"INSERT INTO `Singers` (`SingerId`, `Name`) VALUES (3, \"Carol\");\n" +
+	"INSERT INTO `Albums` (`SingerId`, `AlbumId`, `Title`) VALUES (1, 1, \"A\"), (1, 2, \"B\"), (2, 1, \"C\"), (3, 1, \"A\");\n"
+
+func newTestClient(t *testing.T, dumps ...string) *spanner.Client {
+	t.Helper()
+	var readers []io.Reader
+	for _, dump := range dumps {
+		readers = append(readers, strings.NewReader(dump))
+	}
+	s, err := NewServer(readers...)
+	if err != nil {
+		t.Fatalf("NewServer() failed: %v", err)
+	}
+	t.Cleanup(s.Close)
+	client, err := s.NewClient(context.Background(), testDatabase)
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+	t.Cleanup(client.Close)
+	return client
+}
+
+// rowStrings formats rows as strings of their column values.
+func rowStrings(iter *spanner.RowIterator) ([]string, error) {
+	defer iter.Stop()
+	var rows []string
+	for {
+		row, err := iter.Next()
+		if err == iterator.Done {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		var values []string
+		for i := 0; i < row.Size(); i++ {
+			var v spanner.GenericColumnValue
+			if err := row.Column(i, &v); err != nil {
+				return nil, err
+			}
+			values = append(values, fmt.Sprint(v.Value.AsInterface()))
+		}
+		rows = append(rows, strings.Join(values, ","))
+	}
+}
+
+func TestServer_Read(t *testing.T) {
+	client := newTestClient(t, testDump)
+	ctx := context.Background()
+
+	for _, tt := range []struct {
+		desc    string
+		table   string
+		keys    spanner.KeySet
+		columns []string
+		want    []string
+	}{
+		{
+			desc:    "All keys",
+			table:   "Singers",
+			keys:    spanner.AllKeys(),
+			columns: []string{"SingerId", "Name", "Score", "Birth"},
+			want:    []string{"1,Alice,<nil>,<nil>", "2,Bob,1.5,1990-02-01", "3,Carol,<nil>,<nil>"},
+		},
+		{
+			desc:    "Descending key column",
+			table:   "Albums",
+			keys:    spanner.AllKeys(),
+			columns: []string{"SingerId", "AlbumId", "Title"},
+			want:    []string{"1,2,B", "1,1,A", "2,1,C", "3,1,A"},
+		},
+		{
+			desc:    "Keys",
+			table:   "Singers",
+			keys:    spanner.KeySets(spanner.Key{3}, spanner.Key{1}, spanner.Key{4}),
+			columns: []string{"Name"},
+			want:    []string{"Alice", "Carol"},
+		},
+		{
+			desc:    "Key prefix",
+			table:   "Albums",
+			keys:    spanner.Key{1}.AsPrefix(),
+			columns: []string{"Title"},
+			want:    []string{"B", "A"},
+		},
+		{
+			desc:    "Key range",
+			table:   "singers",
+			keys:    spanner.KeyRange{Start: spanner.Key{1}, End: spanner.Key{3}, Kind: spanner.OpenClosed},
+			columns: []string{"singerid"},
+			want:    []string{"2", "3"},
+		},
+	} {
+		t.Run(tt.desc, func(t *testing.T) {
+			got, err := rowStrings(client.Single().Read(ctx, tt.table, tt.keys, tt.columns))
+			if err != nil {
+				t.Fatalf("Read() failed: %v", err)
+			}
+			if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+				t.Errorf("Read(): got = %q, want = %q", got, tt.want)
+			}
+		})
+	}
+
+	row, err := client.Single().ReadRow(ctx, "Singers", spanner.Key{2}, []string{"Name"})
+	if err != nil {
+		t.Fatalf("ReadRow() failed: %v", err)
+	}
+	var name string
+	if err := row.Column(0, &name); err != nil || name != "Bob" {
+		t.Errorf("ReadRow(): name = %q, err = %v, want = %q", name, err, "Bob")
+	}
+	if _, err := client.Single().ReadRow(ctx, "Singers", spanner.Key{4}, []string{"Name"}); spanner.ErrCode(err) != codes.NotFound {
+		t.Errorf("ReadRow() of missing row: err = %v, want NotFound", err)
+	}
+}
+
+func TestServer_Query(t *testing.T) {
+	client := newTestClient(t, testDump)
+	ctx := context.Background()
+
+	for _, tt := range []struct {
+		desc    string
+		sql     string
+		params  map[string]any
+		want    []string
+		wantErr codes.Code
+	}{
+		{
+			desc: "Full scan",
+			sql:  "SELECT * FROM Singers",
+			want: []string{"1,Alice,<nil>,<nil>", "2,Bob,1.5,1990-02-01", "3,Carol,<nil>,<nil>"},
+		},
+		{
+			desc: "Equality filters",
+			sql:  "SELECT a.AlbumId, Title AS t FROM `Albums` a WHERE a.SingerId = 1 AND Title = 'B'",
+			want: []string{"2,B"},
+		},
+		{
+			desc:   "Parameters",
+			sql:    "SELECT Name FROM Singers WHERE SingerId = @id AND Birth = @birth",
+			params: map[string]any{"id": 2, "birth": "1990-02-01"},
+			want:   []string{"Bob"},
+		},
+		{
+			desc: "Comparisons, IN and IS NULL",
+			sql:  "SELECT SingerId FROM Singers WHERE SingerId >= 1 AND SingerId IN (1, 3, NULL) AND Score IS NULL",
+			want: []string{"1", "3"},
+		},
+		{
+			desc: "Comparison with NULL",
+			sql:  "SELECT SingerId FROM Singers WHERE Score < 2.0",
+			want: []string{"2"},
+		},
+		{
+			desc: "Order and limit",
+			sql:  "SELECT Title, SingerId FROM Albums ORDER BY Title DESC, SingerId LIMIT 2 OFFSET 1",
+			want: []string{"B,1", "A,1"},
+		},
+		{
+			desc: "Without FROM",
+			sql:  "SELECT 1",
+			want: []string{"1"},
+		},
+		{
+			desc:    "Unknown table",
+			sql:     "SELECT * FROM Unknown",
+			wantErr: codes.InvalidArgument,
+		},
+		{
+			desc:    "Unknown column",
+			sql:     "SELECT Unknown FROM Singers",
+			wantErr: codes.InvalidArgument,
+		},
+		{
+			desc:    "Unsupported function",
+			sql:     "SELECT COUNT(*) FROM Singers",
+			wantErr: codes.InvalidArgument,
+		},
+		{
+			desc:    "Unsupported operator",
+			sql:     "SELECT Name FROM Singers WHERE SingerId = 1 OR SingerId = 2",
+			wantErr: codes.InvalidArgument,
+		},
+		{
+			desc:    "DML",
+			sql:     "DELETE FROM Singers WHERE TRUE",
+			wantErr: codes.InvalidArgument,
+		},
+	} {
+		t.Run(tt.desc, func(t *testing.T) {
+			got, err := rowStrings(client.Single().Query(ctx, spanner.Statement{SQL: tt.sql, Params: tt.params}))
+			if tt.wantErr != codes.OK {
+				if spanner.ErrCode(err) != tt.wantErr {
+					t.Errorf("Query(): err = %v, want = %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Query() failed: %v", err)
+			}
+			if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+				t.Errorf("Query(): got = %q, want = %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestServer_ReadOnlyTransaction(t *testing.T) {
+	var sb strings.Builder
+	sb.WriteString("CREATE TABLE Numbers (N INT64 NOT NULL) PRIMARY KEY (N);\n")
+	for i := 0; i < 250; i++ {
+		fmt.Fprintf(&sb, "INSERT INTO `Numbers` (`N`) VALUES (%d);\n", 249-i)
+	}
+	// Records of the later dump replace ones with the same primary key.
+	client := newTestClient(t, sb.String(), "CREATE TABLE Numbers (N INT64 NOT NULL) PRIMARY KEY (N);\nINSERT INTO `Numbers` (`N`) VALUES (0), (250);\n")
+	ctx := context.Background()
+
+	txn := client.ReadOnlyTransaction()
+	defer txn.Close()
+	got, err := rowStrings(txn.Query(ctx, spanner.NewStatement("SELECT N FROM Numbers")))
+	if err != nil {
+		t.Fatalf("Query() failed: %v", err)
+	}
+	if len(got) != 251 || got[0] != "0" || got[250] != "250" {
+		t.Errorf("Query(): got %d rows from %s to %s, want 251 rows from 0 to 250", len(got), got[0], got[len(got)-1])
+	}
+	got, err = rowStrings(txn.Read(ctx, "Numbers", spanner.KeyRange{Start: spanner.Key{100}, End: spanner.Key{199}, Kind: spanner.ClosedClosed}, []string{"N"}))
+	if err != nil {
+		t.Fatalf("Read() failed: %v", err)
+	}
+	if len(got) != 100 {
+		t.Errorf("Read(): got %d rows, want 100 rows", len(got))
+	}
+	if _, err := txn.Timestamp(); err != nil {
+		t.Errorf("Timestamp() failed: %v", err)
+	}
+
+	_, err = client.Apply(ctx, []*spanner.Mutation{spanner.Insert("Numbers", []string{"N"}, []any{300})})
+	if spanner.ErrCode(err) != codes.Unimplemented {
+		t.Errorf("Apply(): err = %v, want Unimplemented", err)
+	}
+}
+
+func TestServer_Load(t *testing.T) {
+	s, err := NewServer(strings.NewReader(testDump))
+	if err != nil {
+		t.Fatalf("NewServer() failed: %v", err)
+	}
+	defer s.Close()
+	// The dump fails at the record of the unknown column after adding a record and a table.
+	err = s.Load(strings.NewReader("CREATE TABLE Songs (SongId INT64 NOT NULL) PRIMARY KEY (SongId);\n" +
+		"INSERT INTO `Singers` (`SingerId`, `Name`) VALUES (4, \"Dave\");\n" +
+		"INSERT INTO `Songs` (`SongId`) VALUES (1);\n" +
+		"INSERT INTO `Singers` (`SingerId`, `Unknown`) VALUES (5, \"Eve\");\n"))
+	if err == nil {
+		t.Fatalf("Load() succeeded, want error")
+	}
+	client, err := s.NewClient(context.Background(), testDatabase)
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+	defer client.Close()
+	got, err := rowStrings(client.Single().Query(context.Background(), spanner.NewStatement("SELECT SingerId FROM Singers")))
+	if err != nil {
+		t.Fatalf("Query() failed: %v", err)
+	}
+	if strings.Join(got, ";") != "1;2;3" {
+		t.Errorf("Query() after failed Load(): got = %v, want = [1 2 3]", got)
+	}
+	_, err = rowStrings(client.Single().Query(context.Background(), spanner.NewStatement("SELECT SongId FROM Songs")))
+	if err == nil {
+		t.Errorf("Query() of the table of failed Load() succeeded, want error")
+	}
+}
+
+func TestServer_escapes(t *testing.T) {
+	// Literals are written by the encoder of dumps, which escapes control and non-printable characters.
+	name := "\a\b\f\v\x01\t\n\"'\\é\u2028\U0001F600"
+	data := []byte{0, 0x7f, 0xff, 'a'}
+	nameLiteral, err := spanner_dump.DecodeColumn(spanner.GenericColumnValue{Type: &pb.Type{Code: pb.TypeCode_STRING}, Value: structpb.NewStringValue(name)})
+	if err != nil {
+		t.Fatalf("DecodeColumn() failed: %v", err)
+	}
+	dataLiteral, err := spanner_dump.DecodeColumn(spanner.GenericColumnValue{Type: &pb.Type{Code: pb.TypeCode_BYTES}, Value: structpb.NewStringValue(base64.StdEncoding.EncodeToString(data))})
+	if err != nil {
+		t.Fatalf("DecodeColumn() failed: %v", err)
+	}
+	client := newTestClient(t, "CREATE TABLE T (Id INT64 NOT NULL, Name STRING(MAX), Data BYTES(MAX)) PRIMARY KEY (Id);\n"+
+		fmt.Sprintf("INSERT INTO `T` (`Id`, `Name`, `Data`) VALUES (1, %s, %s), (2, \"x41\", b\"x41\");\n", nameLiteral, dataLiteral))
+
+	iter := client.Single().Query(context.Background(), spanner.NewStatement(fmt.Sprintf("SELECT Id, Name, Data FROM T WHERE Name = %s AND Data = %s", nameLiteral, dataLiteral)))
+	defer iter.Stop()
+	var rows int
+	if err := iter.Do(func(row *spanner.Row) error {
+		rows++
+		var id int64
+		var gotName string
+		var gotData []byte
+		if err := row.Columns(&id, &gotName, &gotData); err != nil {
+			return err
+		}
+		if id != 1 || gotName != name || !bytes.Equal(gotData, data) {
+			t.Errorf("Query(): got = (%d, %q, %q), want = (1, %q, %q)", id, gotName, gotData, name, data)
+		}
+		return nil
+	}); err != nil {
+		t.Fatalf("Query() failed: %v", err)
+	}
+	if rows != 1 {
+		t.Errorf("Query(): got %d rows, want 1 row", rows)
+	}
+}
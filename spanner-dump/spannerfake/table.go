@@ -0,0 +1,200 @@
+package spannerfake
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	spanner_dump "github.com/Jumpaku/spanner-dump-whare/spanner-dump"
+	pb "google.golang.org/genproto/googleapis/spanner/v1"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// table is a table of the fake database whose rows are sorted in the primary key order.
+type table struct {
+	name       string
+	columns    []string
+	types      []*pb.Type
+	primaryKey []keyColumn
+	rows       [][]*structpb.Value
+	sorted     bool
+}
+
+// keyColumn is a primary key column specified by the index in columns.
+type keyColumn struct {
+	index int
+	desc  bool
+}
+
+func newTable(t *spanner_dump.Table, types []*pb.Type) (*table, error) {
+	tbl := &table{name: t.Name, columns: t.Columns, types: types, sorted: true}
+	for _, k := range t.PrimaryKey {
+		i := tbl.columnIndex(k.Name)
+		if i < 0 {
+			return nil, fmt.Errorf("primary key column %s not found in table %s", k.Name, t.Name)
+		}
+		tbl.primaryKey = append(tbl.primaryKey, keyColumn{index: i, desc: k.Desc})
+	}
+	return tbl, nil
+}
+
+// clone returns a copy of the table, to which rows can be added without changing the table.
+func (t *table) clone() *table {
+	c := *t
+	c.rows = append([][]*structpb.Value(nil), t.rows...)
+	return &c
+}
+
+// columnIndex returns the index of the column, whose name is case-insensitive, or -1 if not found.
+func (t *table) columnIndex(name string) int {
+	for i, c := range t.columns {
+		if strings.EqualFold(c, name) {
+			return i
+		}
+	}
+	return -1
+}
+
+// add adds a record loaded from a dump, where columns not in the record are NULL.
+// The record replaces the previously added one with the same primary key when the table is sorted.
+func (t *table) add(row spanner_dump.Row) error {
+	values := make([]*structpb.Value, len(t.columns))
+	for i := range values {
+		values[i] = structpb.NewNullValue()
+	}
+	for i, name := range row.ColumnNames() {
+		j := t.columnIndex(name)
+		if j < 0 {
+			return fmt.Errorf("column %s not found in table %s", name, t.name)
+		}
+		values[j] = row.ColumnValue(i).Value
+	}
+	t.rows = append(t.rows, values)
+	t.sorted = false
+	return nil
+}
+
+// sort sorts rows in the primary key order and removes all but the last added one of rows with the same primary key.
+func (t *table) sort() {
+	if t.sorted {
+		return
+	}
+	sort.SliceStable(t.rows, func(i, j int) bool {
+		return t.compareRows(t.rows[i], t.rows[j]) < 0
+	})
+	var rows [][]*structpb.Value
+	for i, row := range t.rows {
+		if i+1 < len(t.rows) && t.compareRows(row, t.rows[i+1]) == 0 {
+			continue
+		}
+		rows = append(rows, row)
+	}
+	t.rows = rows
+	t.sorted = true
+}
+
+func (t *table) compareRows(a, b []*structpb.Value) int {
+	for _, k := range t.primaryKey {
+		c := spanner_dump.CompareValues(t.types[k.index], a[k.index], b[k.index])
+		if k.desc {
+			c = -c
+		}
+		if c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+// compareKey compares the primary key of the row with the key, which may be a prefix of the primary key.
+func (t *table) compareKey(row []*structpb.Value, key []*structpb.Value) int {
+	for i, v := range key {
+		k := t.primaryKey[i]
+		c := spanner_dump.CompareValues(t.types[k.index], row[k.index], v)
+		if k.desc {
+			c = -c
+		}
+		if c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+// search returns the index of the first row whose primary key is after the key if after is true,
+// or is not before the key otherwise.
+func (t *table) search(key []*structpb.Value, after bool) int {
+	return sort.Search(len(t.rows), func(i int) bool {
+		c := t.compareKey(t.rows[i], key)
+		if after {
+			return c > 0
+		}
+		return c >= 0
+	})
+}
+
+// read returns rows in the key set in the primary key order.
+func (t *table) read(keySet *pb.KeySet) ([][]*structpb.Value, error) {
+	if keySet.GetAll() {
+		return t.rows, nil
+	}
+
+	type interval struct{ start, end int }
+	var intervals []interval
+	for _, key := range keySet.GetKeys() {
+		if err := t.checkKey(key); err != nil {
+			return nil, err
+		}
+		intervals = append(intervals, interval{t.search(key.Values, false), t.search(key.Values, true)})
+	}
+	for _, r := range keySet.GetRanges() {
+		var start, end *structpb.ListValue
+		var startAfter, endAfter bool
+		switch {
+		case r.GetStartClosed() != nil:
+			start = r.GetStartClosed()
+		case r.GetStartOpen() != nil:
+			start, startAfter = r.GetStartOpen(), true
+		}
+		switch {
+		case r.GetEndClosed() != nil:
+			end, endAfter = r.GetEndClosed(), true
+		case r.GetEndOpen() != nil:
+			end = r.GetEndOpen()
+		}
+		if err := t.checkKey(start); err != nil {
+			return nil, err
+		}
+		if err := t.checkKey(end); err != nil {
+			return nil, err
+		}
+		in := interval{start: t.search(start.GetValues(), startAfter), end: len(t.rows)}
+		if end != nil {
+			in.end = t.search(end.GetValues(), endAfter)
+		}
+		intervals = append(intervals, in)
+	}
+
+	sort.Slice(intervals, func(i, j int) bool { return intervals[i].start < intervals[j].start })
+	var rows [][]*structpb.Value
+	next := 0
+	for _, in := range intervals {
+		for i := max(in.start, next); i < in.end; i++ {
+			rows = append(rows, t.rows[i])
+		}
+		next = max(next, in.end)
+	}
+	return rows, nil
+}
+
+func (t *table) checkKey(key *structpb.ListValue) error {
+	if len(key.GetValues()) > len(t.primaryKey) {
+		return fmt.Errorf("key %v has more values than the primary key of table %s", key.GetValues(), t.name)
+	}
+	return nil
+}
+
+func isNull(v *structpb.Value) bool {
+	_, ok := v.GetKind().(*structpb.Value_NullValue)
+	return v == nil || ok
+}
@@ -0,0 +1,81 @@
+package spannerfake
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Jumpaku/spanner-dump-whare/spanner-dump/internal/sqltoken"
+)
+
+type token = sqltoken.Token
+
+// reservedKeywords are keywords which cannot be an implicit alias.
+var reservedKeywords = map[string]bool{
+	"AND": true, "AS": true, "ASC": true, "BY": true, "DESC": true, "FROM": true, "IN": true, "IS": true,
+	"LIMIT": true, "NOT": true, "NULL": true, "OFFSET": true, "ORDER": true, "SELECT": true, "WHERE": true,
+}
+
+// queryParser is a cursor on tokens of a query.
+type queryParser struct {
+	tokens []token
+	pos    int
+}
+
+func newQueryParser(sql string) (*queryParser, error) {
+	tokens, err := sqltoken.Tokenize(sql)
+	if err != nil {
+		return nil, err
+	}
+	return &queryParser{tokens: tokens}, nil
+}
+
+func (p *queryParser) peek() token {
+	if p.pos >= len(p.tokens) {
+		return token{Kind: sqltoken.EOF}
+	}
+	return p.tokens[p.pos]
+}
+
+func (p *queryParser) next() token {
+	t := p.peek()
+	if p.pos < len(p.tokens) {
+		p.pos++
+	}
+	return t
+}
+
+// isKeyword reports whether the next token is the unquoted keyword.
+func (p *queryParser) isKeyword(keyword string) bool {
+	t := p.peek()
+	return t.Kind == sqltoken.Ident && !t.Quoted && strings.EqualFold(t.Text, keyword)
+}
+
+// isReserved reports whether the next token is an unquoted reserved keyword.
+func (p *queryParser) isReserved() bool {
+	t := p.peek()
+	return t.Kind == sqltoken.Ident && !t.Quoted && reservedKeywords[strings.ToUpper(t.Text)]
+}
+
+func (p *queryParser) consumeKeyword(keyword string) bool {
+	if !p.isKeyword(keyword) {
+		return false
+	}
+	p.pos++
+	return true
+}
+
+func (p *queryParser) consumeSymbol(symbol string) bool {
+	if t := p.peek(); t.Kind != sqltoken.Symbol || t.Text != symbol {
+		return false
+	}
+	p.pos++
+	return true
+}
+
+func (p *queryParser) expectIdent() (string, error) {
+	t := p.next()
+	if t.Kind != sqltoken.Ident {
+		return "", fmt.Errorf("expected identifier but got %q", t.Text)
+	}
+	return t.Text, nil
+}
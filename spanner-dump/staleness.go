@@ -0,0 +1,148 @@
+package spanner_dump
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/spanner"
+	adminpb "cloud.google.com/go/spanner/admin/database/apiv1/databasepb"
+)
+
+type readBoundKind int
+
+const (
+	boundStrong readBoundKind = iota
+	boundReadTimestamp
+	boundExactStaleness
+	boundMaxStaleness
+	boundMinReadTimestamp
+)
+
+// ReadBound specifies the timestamp of the snapshot which the dump reads.
+// The zero value is a strong read, which reads the latest data.
+type ReadBound struct {
+	kind      readBoundKind
+	timestamp time.Time
+	staleness time.Duration
+}
+
+// StrongRead reads the latest data.
+func StrongRead() ReadBound {
+	return ReadBound{kind: boundStrong}
+}
+
+// ReadTimestamp reads data at the timestamp.
+func ReadTimestamp(t time.Time) ReadBound {
+	return ReadBound{kind: boundReadTimestamp, timestamp: t}
+}
+
+// ExactStaleness reads data at the timestamp exactly d before the dump begins.
+func ExactStaleness(d time.Duration) ReadBound {
+	return ReadBound{kind: boundExactStaleness, staleness: d}
+}
+
+// MaxStaleness reads data at a timestamp chosen by Spanner, which is no more than d stale.
+func MaxStaleness(d time.Duration) ReadBound {
+	return ReadBound{kind: boundMaxStaleness, staleness: d}
+}
+
+// MinReadTimestamp reads data at a timestamp chosen by Spanner, which is no earlier than t.
+func MinReadTimestamp(t time.Time) ReadBound {
+	return ReadBound{kind: boundMinReadTimestamp, timestamp: t}
+}
+
+func (b ReadBound) String() string {
+	switch b.kind {
+	case boundReadTimestamp:
+		return fmt.Sprintf("read timestamp %s", b.timestamp.Format(time.RFC3339Nano))
+	case boundExactStaleness:
+		return fmt.Sprintf("exact staleness %s", b.staleness)
+	case boundMaxStaleness:
+		return fmt.Sprintf("max staleness %s", b.staleness)
+	case boundMinReadTimestamp:
+		return fmt.Sprintf("min read timestamp %s", b.timestamp.Format(time.RFC3339Nano))
+	default:
+		return "strong read"
+	}
+}
+
+// exactReadTime returns the timestamp which the bound reads if the dump begins at now.
+// It returns ok = false if the timestamp is chosen by Spanner.
+func (b ReadBound) exactReadTime(now time.Time) (t time.Time, ok bool) {
+	switch b.kind {
+	case boundReadTimestamp:
+		return b.timestamp, true
+	case boundExactStaleness:
+		return now.Add(-b.staleness), true
+	default:
+		return time.Time{}, false
+	}
+}
+
+// resolveReadTimestamp resolves the bound to a read timestamp to begin a multi-use read-only transaction.
+// Bounded staleness is available only for single-use transactions, so it is resolved by a single-use query.
+func (d *Dumper) resolveReadTimestamp(ctx context.Context, bound ReadBound) (spanner.TimestampBound, error) {
+	switch bound.kind {
+	case boundReadTimestamp:
+		return spanner.ReadTimestamp(bound.timestamp), nil
+	case boundExactStaleness:
+		return spanner.ExactStaleness(bound.staleness), nil
+	case boundMaxStaleness, boundMinReadTimestamp:
+		tb := spanner.MaxStaleness(bound.staleness)
+		if bound.kind == boundMinReadTimestamp {
+			tb = spanner.MinReadTimestamp(bound.timestamp)
+		}
+		txn := d.client.Single().WithTimestampBound(tb)
+		defer txn.Close()
+		if err := txn.QueryWithOptions(ctx, spanner.NewStatement("SELECT 1"), d.dataQueryOptions()).Do(func(*spanner.Row) error { return nil }); err != nil {
+			return spanner.TimestampBound{}, fmt.Errorf("failed to resolve %s: %v", bound, err)
+		}
+		t, err := txn.Timestamp()
+		if err != nil {
+			return spanner.TimestampBound{}, fmt.Errorf("failed to resolve %s: %v", bound, err)
+		}
+		return spanner.ReadTimestamp(t), nil
+	default:
+		return spanner.StrongRead(), nil
+	}
+}
+
+// checkVersionRetention checks that the bound reads data within the version retention period of the database.
+func (d *Dumper) checkVersionRetention(ctx context.Context, bound ReadBound) error {
+	t, ok := bound.exactReadTime(time.Now())
+	if !ok {
+		return nil
+	}
+	dbPath := fmt.Sprintf("projects/%s/instances/%s/databases/%s", d.project, d.instance, d.database)
+	db, err := d.adminClient.GetDatabase(ctx, &adminpb.GetDatabaseRequest{Name: dbPath})
+	if err != nil {
+		return fmt.Errorf("failed to get database: %v", err)
+	}
+	return checkReadTime(bound, t, db.GetEarliestVersionTime().AsTime(), db.GetVersionRetentionPeriod())
+}
+
+func checkReadTime(bound ReadBound, t, earliestVersionTime time.Time, versionRetentionPeriod string) error {
+	if t.Before(earliestVersionTime) {
+		return fmt.Errorf("cannot read data at %s by %s, which is earlier than the earliest version time %s of the database (version_retention_period = %s)",
+			t.Format(time.RFC3339Nano), bound, earliestVersionTime.Format(time.RFC3339Nano), versionRetentionPeriod)
+	}
+	return nil
+}
+
+// ParseTimestamp parses a timestamp in RFC3339 format or a negative duration relative to now such as -1h.
+func ParseTimestamp(s string, now time.Time) (time.Time, error) {
+	if strings.HasPrefix(s, "-") {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid relative timestamp %q: %v", s, err)
+		}
+		return now.Add(d), nil
+	}
+	t, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid timestamp %q: %v", s, err)
+	}
+	return t, nil
+}
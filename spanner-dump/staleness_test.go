@@ -0,0 +1,83 @@
+package spanner_dump
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseTimestamp(t *testing.T) {
+	now := time.Date(2020, 1, 23, 3, 0, 0, 0, time.UTC)
+	for _, tt := range []struct {
+		desc    string
+		s       string
+		want    time.Time
+		wantErr bool
+	}{
+		{
+			desc: "RFC3339",
+			s:    "2020-01-02T03:04:05.123+09:00",
+			want: time.Date(2020, 1, 1, 18, 4, 5, 123000000, time.UTC),
+		},
+		{
+			desc: "Relative",
+			s:    "-1h30m",
+			want: time.Date(2020, 1, 23, 1, 30, 0, 0, time.UTC),
+		},
+		{
+			desc:    "Positive duration",
+			s:       "1h",
+			wantErr: true,
+		},
+		{
+			desc:    "Invalid",
+			s:       "-1x",
+			wantErr: true,
+		},
+	} {
+		t.Run(tt.desc, func(t *testing.T) {
+			got, err := ParseTimestamp(tt.s, now)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseTimestamp(%q): err = %v, wantErr = %v", tt.s, err, tt.wantErr)
+			}
+			if !tt.wantErr && !got.Equal(tt.want) {
+				t.Errorf("ParseTimestamp(%q): got = %v, want = %v", tt.s, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadBound_exactReadTime(t *testing.T) {
+	now := time.Date(2020, 1, 23, 3, 0, 0, 0, time.UTC)
+	for _, tt := range []struct {
+		bound  ReadBound
+		want   time.Time
+		wantOK bool
+	}{
+		{bound: StrongRead()},
+		{bound: ReadBound{}},
+		{bound: ReadTimestamp(now.Add(-time.Hour)), want: now.Add(-time.Hour), wantOK: true},
+		{bound: ExactStaleness(15 * time.Second), want: now.Add(-15 * time.Second), wantOK: true},
+		{bound: MaxStaleness(15 * time.Second)},
+		{bound: MinReadTimestamp(now.Add(-time.Hour))},
+	} {
+		t.Run(tt.bound.String(), func(t *testing.T) {
+			got, ok := tt.bound.exactReadTime(now)
+			if ok != tt.wantOK || !got.Equal(tt.want) {
+				t.Errorf("exactReadTime(): got = (%v, %v), want = (%v, %v)", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestCheckReadTime(t *testing.T) {
+	earliest := time.Date(2020, 1, 23, 2, 0, 0, 0, time.UTC)
+	bound := ReadTimestamp(earliest.Add(-time.Minute))
+	err := checkReadTime(bound, earliest.Add(-time.Minute), earliest, "1h")
+	want := "cannot read data at 2020-01-23T01:59:00Z by read timestamp 2020-01-23T01:59:00Z, which is earlier than the earliest version time 2020-01-23T02:00:00Z of the database (version_retention_period = 1h)"
+	if err == nil || err.Error() != want {
+		t.Errorf("checkReadTime(): err = %v, want = %v", err, want)
+	}
+	if err := checkReadTime(bound, earliest, earliest, "1h"); err != nil {
+		t.Errorf("checkReadTime() at the earliest version time: err = %v, want = nil", err)
+	}
+}
@@ -20,25 +20,67 @@ import (
 	"cloud.google.com/go/spanner"
 	"context"
 	"fmt"
-	"strings"
 )
 
 // Table represents a Spanner table.
 type Table struct {
 	Name    string
 	Columns []string
+	// ColumnTypes are Spanner types of Columns such as STRING(MAX), which are empty if unknown.
+	ColumnTypes []string
+	// NotNull reports whether each of Columns has a NOT NULL constraint.
+	NotNull    []bool
+	PrimaryKey []KeyColumn
+	Indexes    []Index
+}
+
+// Index represents a secondary index of a table.
+type Index struct {
+	Name string
+	// Columns are key columns and storing columns of the index.
+	Columns []string
+	// UniqueKeys are the key columns of the index if it is UNIQUE, which must be unique together, or nil otherwise.
+	UniqueKeys []string
+}
+
+// KeyColumn represents a column of a primary key.
+type KeyColumn struct {
+	Name string
+	Desc bool
+}
+
+// isUniqueColumn reports whether the column is a column of the primary key or a key column of a UNIQUE index.
+func (t *Table) isUniqueColumn(column string) bool {
+	for _, key := range t.PrimaryKey {
+		if key.Name == column {
+			return true
+		}
+	}
+	for _, index := range t.Indexes {
+		if containsString(index.UniqueKeys, column) {
+			return true
+		}
+	}
+	return false
 }
 
 func (t *Table) String() string {
-	return fmt.Sprintf("{Name: %q, Columns: %v}", t.Name, t.Columns)
+	return fmt.Sprintf("{Name: %q, Columns: %v, PrimaryKey: %v, Indexes: %v}", t.Name, t.Columns, t.PrimaryKey, t.Indexes)
 }
 
-func (t *Table) quotedColumnList() string {
-	var quoted []string
-	for _, c := range t.Columns {
-		quoted = append(quoted, fmt.Sprintf("`%s`", c))
+// mutationsPerRow estimates the number of mutations to insert a record of the table,
+// which counts each inserted column and each column of secondary indexes.
+// https://cloud.google.com/spanner/quotas#note2
+func (t *Table) mutationsPerRow() uint {
+	n := uint(len(t.Columns))
+	for _, index := range t.Indexes {
+		n += uint(len(index.Columns))
 	}
-	return strings.Join(quoted, ", ")
+	return n
+}
+
+func (t *Table) quotedColumnList() string {
+	return quotedList(t.Columns)
 }
 
 // TableIterator is an iterator to get tables in the database one by one.
@@ -53,7 +95,12 @@ type tableRow struct {
 }
 
 // FetchTables fetches all table information in the database from Spanner.
-func FetchTables(ctx context.Context, txn *spanner.ReadOnlyTransaction, tableNames []string) (tables []*Table, err error) {
+func FetchTables(ctx context.Context, txn *spanner.ReadOnlyTransaction, tableNames []string) ([]*Table, error) {
+	return FetchTablesWithOptions(ctx, txn, tableNames, spanner.QueryOptions{})
+}
+
+// FetchTablesWithOptions is FetchTables running queries with options, e.g. the priority and the request tag.
+func FetchTablesWithOptions(ctx context.Context, txn *spanner.ReadOnlyTransaction, tableNames []string, options spanner.QueryOptions) (tables []*Table, err error) {
 	// SQL for fetching table name, parent, and columns
 	stmt := spanner.NewStatement(`
 SELECT t.TABLE_NAME as table, t.PARENT_TABLE_NAME as parent, c.columns
@@ -69,7 +116,7 @@ WHERE t.TABLE_CATALOG = '' AND t.TABLE_SCHEMA = '' AND t.TABLE_TYPE = 'BASE TABL
 ORDER BY t.TABLE_NAME ASC
 `)
 	var rows []tableRow
-	if err := txn.Query(ctx, stmt).Do(func(r *spanner.Row) error {
+	if err := txn.QueryWithOptions(ctx, stmt, options).Do(func(r *spanner.Row) error {
 		var tableName, parentTableName string
 		var columns []string
 		var parentTableNamePtr *string // nullable
@@ -106,6 +153,12 @@ ORDER BY t.TABLE_NAME ASC
 			Columns: row.columns,
 		}
 	}
+	if err := fetchColumnTypes(ctx, txn, tableMap, options); err != nil {
+		return nil, err
+	}
+	if err := fetchIndexColumns(ctx, txn, tableMap, options); err != nil {
+		return nil, err
+	}
 
 	for _, tableName := range tableNames {
 		if table, exists := tableMap[tableName]; exists {
@@ -117,3 +170,79 @@ ORDER BY t.TABLE_NAME ASC
 
 	return tables, nil
 }
+
+// fetchColumnTypes fetches Spanner types and NOT NULL constraints of columns of the tables.
+func fetchColumnTypes(ctx context.Context, txn *spanner.ReadOnlyTransaction, tableMap map[string]*Table, options spanner.QueryOptions) error {
+	type columnType struct {
+		typ     string
+		notNull bool
+	}
+	types := map[string]map[string]columnType{}
+	stmt := spanner.NewStatement(`
+SELECT c.TABLE_NAME as table, c.COLUMN_NAME as column, IFNULL(c.SPANNER_TYPE, '') as type, c.IS_NULLABLE = 'NO' as not_null
+FROM INFORMATION_SCHEMA.COLUMNS AS c
+WHERE c.TABLE_CATALOG = '' AND c.TABLE_SCHEMA = '' AND c.IS_GENERATED = 'NEVER'
+`)
+	if err := txn.QueryWithOptions(ctx, stmt, options).Do(func(r *spanner.Row) error {
+		var tableName, column, typ string
+		var notNull bool
+		if err := r.Columns(&tableName, &column, &typ, &notNull); err != nil {
+			return err
+		}
+		if types[tableName] == nil {
+			types[tableName] = map[string]columnType{}
+		}
+		types[tableName][column] = columnType{typ: typ, notNull: notNull}
+		return nil
+	}); err != nil {
+		return err
+	}
+	for name, table := range tableMap {
+		table.ColumnTypes = make([]string, len(table.Columns))
+		table.NotNull = make([]bool, len(table.Columns))
+		for i, column := range table.Columns {
+			t := types[name][column]
+			table.ColumnTypes[i], table.NotNull[i] = t.typ, t.notNull
+		}
+	}
+	return nil
+}
+
+// fetchIndexColumns fetches columns of primary keys and secondary indexes of the tables.
+func fetchIndexColumns(ctx context.Context, txn *spanner.ReadOnlyTransaction, tableMap map[string]*Table, options spanner.QueryOptions) error {
+	// Storing columns of secondary indexes have NULL ordinal positions.
+	stmt := spanner.NewStatement(`
+SELECT k.TABLE_NAME as table, k.INDEX_NAME as index, k.INDEX_TYPE as type, k.COLUMN_NAME as column, k.COLUMN_ORDERING as ordering,
+    i.IS_UNIQUE AND k.ORDINAL_POSITION IS NOT NULL as unique_key
+FROM INFORMATION_SCHEMA.INDEX_COLUMNS AS k
+JOIN INFORMATION_SCHEMA.INDEXES AS i
+ON i.TABLE_CATALOG = k.TABLE_CATALOG AND i.TABLE_SCHEMA = k.TABLE_SCHEMA AND i.TABLE_NAME = k.TABLE_NAME AND i.INDEX_NAME = k.INDEX_NAME
+WHERE k.TABLE_CATALOG = '' AND k.TABLE_SCHEMA = '' AND k.INDEX_TYPE IN ('PRIMARY_KEY', 'INDEX')
+ORDER BY k.TABLE_NAME, k.INDEX_NAME, k.ORDINAL_POSITION
+`)
+	return txn.QueryWithOptions(ctx, stmt, options).Do(func(r *spanner.Row) error {
+		var tableName, indexName, indexType, column string
+		var ordering spanner.NullString
+		var uniqueKey bool
+		if err := r.Columns(&tableName, &indexName, &indexType, &column, &ordering, &uniqueKey); err != nil {
+			return err
+		}
+		table, ok := tableMap[tableName]
+		if !ok {
+			return nil
+		}
+		if indexType == "PRIMARY_KEY" {
+			table.PrimaryKey = append(table.PrimaryKey, KeyColumn{Name: column, Desc: ordering.StringVal == "DESC"})
+			return nil
+		}
+		if n := len(table.Indexes); n == 0 || table.Indexes[n-1].Name != indexName {
+			table.Indexes = append(table.Indexes, Index{Name: indexName})
+		}
+		index := &table.Indexes[len(table.Indexes)-1]
+		index.Columns = append(index.Columns, column)
+		if uniqueKey {
+			index.UniqueKeys = append(index.UniqueKeys, column)
+		}
+		return nil
+	})
+}
@@ -50,3 +50,13 @@ func TestQuotedColumnList(t *testing.T) {
 	}
 
 }
+
+func TestMutationsPerRow(t *testing.T) {
+	table := &Table{
+		Columns: []string{"Id", "Name", "Email"},
+		Indexes: []Index{{Name: "idx1", Columns: []string{"Name"}}, {Name: "idx2", Columns: []string{"Email", "Name"}}},
+	}
+	if got := table.mutationsPerRow(); got != 6 {
+		t.Errorf("mutationsPerRow() of %v: got = %d, want = %d", table, got, 6)
+	}
+}
@@ -0,0 +1,64 @@
+package spanner_dump
+
+import (
+	"fmt"
+
+	"cloud.google.com/go/spanner"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// RowTransformer transforms records of tables before they are written, e.g. to rewrite tenant IDs or clear tokens.
+type RowTransformer interface {
+	// Transform returns records to write instead of a record of the table, whose values correspond to table.Columns.
+	// It can return the record modified, no records to drop it, or multiple records to fan it out,
+	// where each record must have a value for each of table.Columns.
+	// The values may be modified in place.
+	Transform(table *Table, values []spanner.GenericColumnValue) ([][]spanner.GenericColumnValue, error)
+}
+
+// RowTransformerFunc is a function implementing RowTransformer.
+type RowTransformerFunc func(table *Table, values []spanner.GenericColumnValue) ([][]spanner.GenericColumnValue, error)
+
+func (f RowTransformerFunc) Transform(table *Table, values []spanner.GenericColumnValue) ([][]spanner.GenericColumnValue, error) {
+	return f(table, values)
+}
+
+// AddRowTransformer registers a transformer applied to every record dumped by DumpTables in any format.
+// Transformers are applied in the order of registration, and masking is applied to the transformed records.
+// Transformers must be safe for concurrent use if tables are dumped concurrently.
+// Note that records fanned out from a record may be written again if the dump is resumed from a checkpoint.
+func (d *Dumper) AddRowTransformer(t RowTransformer) {
+	d.transformers = append(d.transformers, t)
+}
+
+// transformRow applies the transformers to a record of the table in order.
+func transformRow(transformers []RowTransformer, table *Table, values []spanner.GenericColumnValue) ([][]spanner.GenericColumnValue, error) {
+	rows := [][]spanner.GenericColumnValue{values}
+	for _, t := range transformers {
+		var transformed [][]spanner.GenericColumnValue
+		for _, row := range rows {
+			out, err := t.Transform(table, row)
+			if err != nil {
+				return nil, fmt.Errorf("failed to transform record: %v", err)
+			}
+			for _, r := range out {
+				if len(r) != len(table.Columns) {
+					return nil, fmt.Errorf("failed to transform record: transformed record has %d values for %d columns", len(r), len(table.Columns))
+				}
+			}
+			transformed = append(transformed, out...)
+		}
+		rows = transformed
+	}
+	return rows, nil
+}
+
+// copyValues copies values of a record deeply, so that modifying the copy in place does not affect the record.
+func copyValues(values []spanner.GenericColumnValue) []spanner.GenericColumnValue {
+	copied := make([]spanner.GenericColumnValue, len(values))
+	for i, v := range values {
+		copied[i] = spanner.GenericColumnValue{Type: v.Type, Value: proto.Clone(v.Value).(*structpb.Value)}
+	}
+	return copied
+}
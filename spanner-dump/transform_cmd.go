@@ -0,0 +1,340 @@
+package spanner_dump
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"cloud.google.com/go/civil"
+	"cloud.google.com/go/spanner"
+	pb "google.golang.org/genproto/googleapis/spanner/v1"
+	structpb "google.golang.org/protobuf/types/known/structpb"
+)
+
+// commandExitTimeout is the time to wait for the command to exit after its stdout is closed before killing it.
+const commandExitTimeout = 5 * time.Second
+
+// CommandTransformer is a RowTransformer which transforms records by an external command speaking JSON Lines.
+//
+// For each record, the transformer writes a request line to the stdin of the command in the following format,
+// which is the format of convert -format=jsonl with types of the columns:
+//
+//	{"table":"User","values":{"Id":"1","Email":"alice@example.com"},"types":{"Id":"INT64","Email":"STRING"}}
+//
+// Values are in the JSON encoding of Cloud Spanner API, i.e. INT64, NUMERIC, DATE, TIMESTAMP, and JSON as strings,
+// BYTES as base64 strings, FLOAT64 and BOOL as JSON numbers and booleans, ARRAY as JSON arrays, and NULL as null.
+// Types are ARRAY<T> or type names such as STRING without lengths.
+//
+// The command must write exactly one response line to its stdout for each request line, and flush it before reading the next request:
+//
+//	{"rows":[{"Id":"1","Email":"user@example.com"}]}
+//
+// rows are records to write instead of the requested record, which are empty to drop it, or multiple to fan it out.
+// Columns omitted in a row keep the values of the requested record,
+// and numbers are also accepted for INT64 and NUMERIC, and JSON values for JSON.
+// Values are checked against the types of the columns, and values of other types abort the dump.
+// The command can also respond {"error":"message"} to abort the dump.
+//
+// Requests are sent one by one, waiting for the response to each request,
+// so the dump never reads ahead of the command, and records of tables dumped concurrently are serialized.
+// The stdin of the command is closed by Close, and then the command should exit with status 0.
+type CommandTransformer struct {
+	command string
+	cmd     *exec.Cmd
+	stdin   io.WriteCloser
+	stdout  *bufio.Reader
+	exited  chan struct{}
+	exitErr error
+
+	mu sync.Mutex
+	// err is the error which the command has failed with, after which all transformations fail.
+	err error
+}
+
+// StartCommandTransformer starts the command by sh -c, whose stderr is connected to stderr.
+func StartCommandTransformer(ctx context.Context, command string, stderr io.Writer) (*CommandTransformer, error) {
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	cmd.Stderr = stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to start transform command: %v", err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to start transform command: %v", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start transform command: %v", err)
+	}
+	t := &CommandTransformer{command: command, cmd: cmd, stdin: stdin, stdout: bufio.NewReader(stdout), exited: make(chan struct{})}
+	go func() {
+		t.exitErr = cmd.Wait()
+		close(t.exited)
+	}()
+	return t, nil
+}
+
+func (t *CommandTransformer) Transform(table *Table, values []spanner.GenericColumnValue) ([][]spanner.GenericColumnValue, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.err != nil {
+		return nil, t.err
+	}
+	rows, err := t.transform(table, values)
+	if err != nil {
+		t.err = err
+		return nil, err
+	}
+	return rows, nil
+}
+
+func (t *CommandTransformer) transform(table *Table, values []spanner.GenericColumnValue) ([][]spanner.GenericColumnValue, error) {
+	if _, err := io.WriteString(t.stdin, encodeTransformRequest(table, values)); err != nil {
+		return nil, t.exitError(table, err)
+	}
+	line, err := t.stdout.ReadBytes('\n')
+	if err != nil && !(errors.Is(err, io.EOF) && len(bytes.TrimSpace(line)) > 0) {
+		return nil, t.exitError(table, err)
+	}
+	rows, err := decodeTransformResponse(table, values, line)
+	if err != nil {
+		return nil, fmt.Errorf("invalid response of transform command %q to a record of table %s: %v", t.command, table.Name, err)
+	}
+	return rows, nil
+}
+
+// exitError returns an error describing why the command stopped communicating, waiting for the command to exit.
+func (t *CommandTransformer) exitError(table *Table, err error) error {
+	select {
+	case <-t.exited:
+	case <-time.After(commandExitTimeout):
+		_ = t.cmd.Process.Kill()
+		<-t.exited
+	}
+	if t.exitErr != nil {
+		err = t.exitErr
+	}
+	return fmt.Errorf("transform command %q exited before responding to a record of table %s: %v", t.command, table.Name, err)
+}
+
+// Close closes the stdin of the command and waits for it to exit.
+func (t *CommandTransformer) Close() error {
+	_ = t.stdin.Close()
+	select {
+	case <-t.exited:
+	case <-time.After(commandExitTimeout):
+		_ = t.cmd.Process.Kill()
+		<-t.exited
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.err == nil && t.exitErr != nil {
+		return fmt.Errorf("transform command %q failed: %v", t.command, t.exitErr)
+	}
+	return nil
+}
+
+func encodeTransformRequest(table *Table, values []spanner.GenericColumnValue) string {
+	sb := &strings.Builder{}
+	sb.WriteString(`{"table":`)
+	writeJSONString(sb, table.Name)
+	sb.WriteString(`,"values":{`)
+	for i, v := range values {
+		if i > 0 {
+			sb.WriteString(",")
+		}
+		writeJSONString(sb, table.Columns[i])
+		sb.WriteString(":")
+		writeValueJSON(sb, v.Value)
+	}
+	sb.WriteString(`},"types":{`)
+	for i, v := range values {
+		if i > 0 {
+			sb.WriteString(",")
+		}
+		writeJSONString(sb, table.Columns[i])
+		sb.WriteString(":")
+		writeJSONString(sb, typeName(v.Type))
+	}
+	sb.WriteString("}}\n")
+	return sb.String()
+}
+
+// typeName returns the name of the type such as STRING or ARRAY<INT64>.
+func typeName(typ *pb.Type) string {
+	if typ.GetCode() == pb.TypeCode_ARRAY {
+		return "ARRAY<" + typeName(typ.GetArrayElementType()) + ">"
+	}
+	return typ.GetCode().String()
+}
+
+func decodeTransformResponse(table *Table, values []spanner.GenericColumnValue, line []byte) ([][]spanner.GenericColumnValue, error) {
+	var response struct {
+		Rows  []map[string]json.RawMessage `json:"rows"`
+		Error *string                      `json:"error"`
+	}
+	if err := json.Unmarshal(line, &response); err != nil {
+		return nil, err
+	}
+	if response.Error != nil {
+		return nil, fmt.Errorf("%s", *response.Error)
+	}
+	rows := make([][]spanner.GenericColumnValue, len(response.Rows))
+	for i, r := range response.Rows {
+		row := append([]spanner.GenericColumnValue(nil), values...)
+		for column, raw := range r {
+			j := indexOfString(table.Columns, column)
+			if j < 0 {
+				return nil, fmt.Errorf("unknown column %s", column)
+			}
+			d := json.NewDecoder(bytes.NewReader(raw))
+			d.UseNumber()
+			var v any
+			if err := d.Decode(&v); err != nil {
+				return nil, err
+			}
+			value, err := jsonToValue(v, values[j].Type)
+			if err != nil {
+				return nil, fmt.Errorf("invalid value of column %s: %v", column, err)
+			}
+			row[j] = spanner.GenericColumnValue{Type: values[j].Type, Value: value}
+		}
+		rows[i] = row
+	}
+	return rows, nil
+}
+
+// jsonToValue converts a decoded JSON value to a value of typ in the encoding of Cloud Spanner API,
+// checking that the value is valid for typ.
+func jsonToValue(v any, typ *pb.Type) (*structpb.Value, error) {
+	if v == nil {
+		return structpb.NewNullValue(), nil
+	}
+	mismatch := fmt.Errorf("%s for %s", jsonKind(v), typeName(typ))
+	switch typ.GetCode() {
+	case pb.TypeCode_ARRAY:
+		elements, ok := v.([]any)
+		if !ok {
+			return nil, mismatch
+		}
+		list := &structpb.ListValue{}
+		for _, e := range elements {
+			value, err := jsonToValue(e, typ.GetArrayElementType())
+			if err != nil {
+				return nil, err
+			}
+			list.Values = append(list.Values, value)
+		}
+		return structpb.NewListValue(list), nil
+	case pb.TypeCode_JSON:
+		if s, ok := v.(string); ok {
+			if !json.Valid([]byte(s)) {
+				return nil, fmt.Errorf("invalid JSON %q", s)
+			}
+			return structpb.NewStringValue(s), nil
+		}
+		// Other JSON values are the documents themselves.
+		b, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		return structpb.NewStringValue(string(b)), nil
+	case pb.TypeCode_BOOL:
+		b, ok := v.(bool)
+		if !ok {
+			return nil, mismatch
+		}
+		return structpb.NewBoolValue(b), nil
+	case pb.TypeCode_FLOAT64:
+		switch v := v.(type) {
+		case json.Number:
+			f, err := strconv.ParseFloat(v.String(), 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid FLOAT64 %s", v)
+			}
+			return structpb.NewNumberValue(f), nil
+		case string:
+			// Non-finite values are strings in the encoding of Cloud Spanner API.
+			if v != "NaN" && v != "Infinity" && v != "-Infinity" {
+				return nil, fmt.Errorf("invalid FLOAT64 %q", v)
+			}
+			return structpb.NewStringValue(v), nil
+		}
+		return nil, mismatch
+	}
+
+	var s string
+	switch v := v.(type) {
+	case string:
+		s = v
+	case json.Number:
+		if typ.GetCode() != pb.TypeCode_INT64 && typ.GetCode() != pb.TypeCode_NUMERIC {
+			return nil, mismatch
+		}
+		s = v.String()
+	default:
+		return nil, mismatch
+	}
+	switch typ.GetCode() {
+	case pb.TypeCode_INT64:
+		if _, err := strconv.ParseInt(s, 10, 64); err != nil {
+			return nil, fmt.Errorf("invalid INT64 %q", s)
+		}
+	case pb.TypeCode_NUMERIC:
+		r, ok := new(big.Rat).SetString(s)
+		if !ok {
+			return nil, fmt.Errorf("invalid NUMERIC %q", s)
+		}
+		if strings.ContainsAny(s, "eE/") {
+			// Spanner accepts only decimal notations.
+			s = spanner.NumericString(r)
+		}
+	case pb.TypeCode_DATE:
+		if _, err := civil.ParseDate(s); err != nil {
+			return nil, fmt.Errorf("invalid DATE %q", s)
+		}
+	case pb.TypeCode_TIMESTAMP:
+		t, err := time.Parse(time.RFC3339Nano, s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid TIMESTAMP %q", s)
+		}
+		s = t.UTC().Format(time.RFC3339Nano)
+	case pb.TypeCode_BYTES:
+		if _, err := base64.StdEncoding.DecodeString(s); err != nil {
+			return nil, fmt.Errorf("invalid base64 %q for BYTES", s)
+		}
+	case pb.TypeCode_STRING:
+	default:
+		return nil, fmt.Errorf("unsupported type: %s", typeName(typ))
+	}
+	return structpb.NewStringValue(s), nil
+}
+
+// jsonKind returns the kind of a decoded JSON value such as string, which is used in error messages.
+func jsonKind(v any) string {
+	switch v.(type) {
+	case bool:
+		return "boolean"
+	case json.Number:
+		return "number"
+	case string:
+		return "string"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	default:
+		return fmt.Sprintf("%T", v)
+	}
+}
@@ -0,0 +1,172 @@
+package spanner_dump
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+)
+
+// TestCommandTransformer_helper is not a test but the transform command run by tests of CommandTransformer.
+func TestCommandTransformer_helper(t *testing.T) {
+	mode := os.Getenv("TRANSFORM_HELPER_MODE")
+	if mode == "" {
+		t.Skip("helper process for CommandTransformer")
+	}
+	scanner := bufio.NewScanner(os.Stdin)
+	for n := 1; scanner.Scan(); n++ {
+		var request struct {
+			Table  string            `json:"table"`
+			Values map[string]any    `json:"values"`
+			Types  map[string]string `json:"types"`
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &request); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+		switch {
+		case mode == "exit" && n == 2:
+			os.Exit(3)
+		case mode == "error":
+			fmt.Println(`{"error":"unexpected record"}`)
+		case request.Values["Id"] == "1":
+			// Drop
+			fmt.Println(`{"rows":[]}`)
+		case request.Values["Id"] == "2":
+			// Fan out with a number for INT64.
+			fmt.Println(`{"rows":[{"Email":null},{"Id":20,"Tags":["x"],"Doc":{"a":1}}]}`)
+		default:
+			fmt.Printf(`{"rows":[{"Email":%q}]}`+"\n", request.Types["Email"]+","+request.Types["Tags"])
+		}
+	}
+	os.Exit(0)
+}
+
+func startHelperTransformer(t *testing.T, mode string) *CommandTransformer {
+	t.Helper()
+	t.Setenv("TRANSFORM_HELPER_MODE", mode)
+	tr, err := StartCommandTransformer(context.Background(), fmt.Sprintf("'%s' -test.run=TestCommandTransformer_helper", os.Args[0]), os.Stderr)
+	if err != nil {
+		t.Fatalf("StartCommandTransformer() failed: %v", err)
+	}
+	return tr
+}
+
+func TestCommandTransformer(t *testing.T) {
+	table := &Table{Name: "User", Columns: []string{"Id", "Email", "Tags", "Doc"}, ColumnTypes: []string{"INT64", "STRING(MAX)", "ARRAY<STRING(MAX)>", "JSON"}}
+	tr := startHelperTransformer(t, "transform")
+	var got []string
+	for _, id := range []string{"1", "2", "3"} {
+		rows, err := tr.Transform(table, literalValues(t, table, id, `"a@example.com"`, "NULL", "NULL"))
+		if err != nil {
+			t.Fatalf("Transform() failed: %v", err)
+		}
+		for _, row := range rows {
+			decoded, err := decodeValues(row)
+			if err != nil {
+				t.Fatalf("decodeValues() failed: %v", err)
+			}
+			got = append(got, strings.Join(decoded, ", "))
+		}
+	}
+	if err := tr.Close(); err != nil {
+		t.Errorf("Close() failed: %v", err)
+	}
+	want := []string{
+		`2, NULL, NULL, NULL`,
+		`20, "a@example.com", ["x"], JSON "{\"a\":1}"`,
+		`3, "STRING,ARRAY<STRING>", NULL, NULL`,
+	}
+	if !equalColumns(got, want) {
+		t.Errorf("Transform(): got = %q, want = %q", got, want)
+	}
+}
+
+func TestCommandTransformer_errors(t *testing.T) {
+	table := &Table{Name: "User", Columns: []string{"Id", "Email"}, ColumnTypes: []string{"INT64", "STRING(MAX)"}}
+	for _, tt := range []struct {
+		mode    string
+		wantErr string
+	}{
+		{mode: "exit", wantErr: "exited before responding to a record of table User: exit status 3"},
+		{mode: "error", wantErr: "unexpected record"},
+	} {
+		t.Run(tt.mode, func(t *testing.T) {
+			tr := startHelperTransformer(t, tt.mode)
+			defer tr.Close()
+			var err error
+			for i := 0; i < 3 && err == nil; i++ {
+				_, err = tr.Transform(table, literalValues(t, table, "5", `"e"`))
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Transform(): err = %v, want = %q", err, tt.wantErr)
+			}
+			// The transformer keeps failing after the error.
+			if _, err2 := tr.Transform(table, literalValues(t, table, "5", `"e"`)); err2 != err {
+				t.Errorf("Transform(): err = %v, want = %v", err2, err)
+			}
+		})
+	}
+}
+
+func TestDecodeTransformResponse(t *testing.T) {
+	table := &Table{
+		Name:        "User",
+		Columns:     []string{"Id", "Name", "Active", "Score", "Amount", "Birth", "Updated", "Avatar", "Profile", "Tags"},
+		ColumnTypes: []string{"INT64", "STRING(MAX)", "BOOL", "FLOAT64", "NUMERIC", "DATE", "TIMESTAMP", "BYTES(MAX)", "JSON", "ARRAY<INT64>"},
+	}
+	values := literalValues(t, table, "1", `"a"`, "true", "1.5", `NUMERIC "1"`, `DATE "2020-01-23"`, `TIMESTAMP "2020-01-23T00:00:00Z"`, `b"a"`, `JSON "{}"`, "[1]")
+	for _, tt := range []struct {
+		desc    string
+		row     string
+		want    string
+		wantErr string
+	}{
+		{desc: "number for INT64", row: `{"Id":2}`, want: `2`},
+		{desc: "number for NUMERIC", row: `{"Amount":1e2}`, want: `NUMERIC "100.000000000"`},
+		{desc: "JSON object", row: `{"Profile":{"a":[1]}}`, want: `JSON "{\"a\":[1]}"`},
+		{desc: "JSON array", row: `{"Profile":[1,"a"]}`, want: `JSON "[1,\"a\"]"`},
+		{desc: "JSON boolean", row: `{"Profile":true}`, want: `JSON "true"`},
+		{desc: "JSON text", row: `{"Profile":"{\"a\":1}"}`, want: `JSON "{\"a\":1}"`},
+		{desc: "NaN for FLOAT64", row: `{"Score":"NaN"}`, want: `CAST('nan' AS FLOAT64)`},
+		{desc: "NULL", row: `{"Name":null}`, want: `NULL`},
+		{desc: "boolean for STRING", row: `{"Name":true}`, wantErr: "invalid value of column Name: boolean for STRING"},
+		{desc: "boolean for INT64", row: `{"Id":true}`, wantErr: "invalid value of column Id: boolean for INT64"},
+		{desc: "string for INT64", row: `{"Id":"abc"}`, wantErr: `invalid value of column Id: invalid INT64 "abc"`},
+		{desc: "string for BOOL", row: `{"Active":"true"}`, wantErr: "invalid value of column Active: string for BOOL"},
+		{desc: "string for FLOAT64", row: `{"Score":"1.5"}`, wantErr: `invalid value of column Score: invalid FLOAT64 "1.5"`},
+		{desc: "string for NUMERIC", row: `{"Amount":"abc"}`, wantErr: `invalid value of column Amount: invalid NUMERIC "abc"`},
+		{desc: "string for DATE", row: `{"Birth":"abc"}`, wantErr: `invalid value of column Birth: invalid DATE "abc"`},
+		{desc: "string for TIMESTAMP", row: `{"Updated":"abc"}`, wantErr: `invalid value of column Updated: invalid TIMESTAMP "abc"`},
+		{desc: "string for BYTES", row: `{"Avatar":"!"}`, wantErr: `invalid value of column Avatar: invalid base64 "!" for BYTES`},
+		{desc: "number for STRING", row: `{"Name":1}`, wantErr: "invalid value of column Name: number for STRING"},
+		{desc: "number for DATE", row: `{"Birth":20200123}`, wantErr: "invalid value of column Birth: number for DATE"},
+		{desc: "object for STRING", row: `{"Name":{}}`, wantErr: "invalid value of column Name: object for STRING"},
+		{desc: "invalid JSON text", row: `{"Profile":"{"}`, wantErr: `invalid value of column Profile: invalid JSON "{"`},
+		{desc: "string for ARRAY", row: `{"Tags":"1"}`, wantErr: "invalid value of column Tags: string for ARRAY<INT64>"},
+		{desc: "element of ARRAY", row: `{"Tags":[1,"x"]}`, wantErr: `invalid value of column Tags: invalid INT64 "x"`},
+	} {
+		t.Run(tt.desc, func(t *testing.T) {
+			rows, err := decodeTransformResponse(table, values, []byte(`{"rows":[`+tt.row+`]}`))
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Errorf("decodeTransformResponse(): err = %v, want = %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("decodeTransformResponse() failed: %v", err)
+			}
+			got, err := decodeValues(rows[0])
+			if err != nil {
+				t.Fatalf("decodeValues() failed: %v", err)
+			}
+			if !containsString(got, tt.want) {
+				t.Errorf("decodeTransformResponse(): got = %q, want a value %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,145 @@
+package spanner_dump
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"cloud.google.com/go/spanner"
+	structpb "google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestTransformRow(t *testing.T) {
+	table := &Table{Name: "t", Columns: []string{"Tenant", "Id"}, ColumnTypes: []string{"STRING(MAX)", "INT64"}}
+	rehome := RowTransformerFunc(func(table *Table, values []spanner.GenericColumnValue) ([][]spanner.GenericColumnValue, error) {
+		values[0].Value = structpb.NewStringValue("dev")
+		return [][]spanner.GenericColumnValue{values}, nil
+	})
+	dropOdd := RowTransformerFunc(func(table *Table, values []spanner.GenericColumnValue) ([][]spanner.GenericColumnValue, error) {
+		if strings.HasSuffix(values[1].Value.GetStringValue(), "1") {
+			return nil, nil
+		}
+		return [][]spanner.GenericColumnValue{values}, nil
+	})
+	duplicate := RowTransformerFunc(func(table *Table, values []spanner.GenericColumnValue) ([][]spanner.GenericColumnValue, error) {
+		copied := append([]spanner.GenericColumnValue(nil), values...)
+		copied[1].Value = structpb.NewStringValue(values[1].Value.GetStringValue() + "0")
+		return [][]spanner.GenericColumnValue{values, copied}, nil
+	})
+	for _, tt := range []struct {
+		desc         string
+		transformers []RowTransformer
+		values       []string
+		want         []string
+	}{
+		{desc: "No transformers", values: []string{`"prod"`, "2"}, want: []string{`"prod", 2`}},
+		{desc: "Modify", transformers: []RowTransformer{rehome}, values: []string{`"prod"`, "2"}, want: []string{`"dev", 2`}},
+		{desc: "Drop", transformers: []RowTransformer{dropOdd}, values: []string{`"prod"`, "1"}},
+		{desc: "Fan out", transformers: []RowTransformer{duplicate, rehome}, values: []string{`"prod"`, "2"}, want: []string{`"dev", 2`, `"dev", 20`}},
+		{desc: "In order", transformers: []RowTransformer{duplicate, dropOdd}, values: []string{`"prod"`, "1"}, want: []string{`"prod", 10`}},
+	} {
+		t.Run(tt.desc, func(t *testing.T) {
+			rows, err := transformRow(tt.transformers, table, literalValues(t, table, tt.values...))
+			if err != nil {
+				t.Fatalf("transformRow() failed: %v", err)
+			}
+			var got []string
+			for _, row := range rows {
+				decoded, err := decodeValues(row)
+				if err != nil {
+					t.Fatalf("decodeValues() failed: %v", err)
+				}
+				got = append(got, strings.Join(decoded, ", "))
+			}
+			if !equalColumns(got, tt.want) {
+				t.Errorf("transformRow(): got = %v, want = %v", got, tt.want)
+			}
+		})
+	}
+
+	truncate := RowTransformerFunc(func(table *Table, values []spanner.GenericColumnValue) ([][]spanner.GenericColumnValue, error) {
+		return [][]spanner.GenericColumnValue{values[:1]}, nil
+	})
+	if _, err := transformRow([]RowTransformer{truncate}, table, literalValues(t, table, `"prod"`, "1")); err == nil {
+		t.Errorf("transformRow(): want an error for a record with missing values")
+	}
+}
+
+func TestTableWriter_transform(t *testing.T) {
+	table := &Table{Name: "t", Columns: []string{"A"}, ColumnTypes: []string{"INT64"}}
+	out := &bytes.Buffer{}
+	var progress []string
+	d := &Dumper{bulkSize: 2}
+	// Records are dropped if 1, and fanned out to 3 records otherwise.
+	d.AddRowTransformer(RowTransformerFunc(func(table *Table, values []spanner.GenericColumnValue) ([][]spanner.GenericColumnValue, error) {
+		if values[0].Value.GetStringValue() == "1" {
+			return nil, nil
+		}
+		return [][]spanner.GenericColumnValue{values, values, values}, nil
+	}))
+	w, err := d.newTableWriter(d.outputEncoder(), table, out, nil, func(lastRow []string) error {
+		progress = append(progress, lastRow[0])
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("newTableWriter() failed: %v", err)
+	}
+	for _, v := range []string{"1", "2", "3"} {
+		if err := w.write(literalValues(t, table, v)); err != nil {
+			t.Fatalf("write() failed: %v", err)
+		}
+	}
+	if err := w.close(); err != nil {
+		t.Fatalf("close() failed: %v", err)
+	}
+	want := "INSERT INTO `t` (`A`) VALUES (2), (2);\n" +
+		"INSERT INTO `t` (`A`) VALUES (2), (3);\n" +
+		"INSERT INTO `t` (`A`) VALUES (3), (3);\n"
+	if got := out.String(); got != want {
+		t.Errorf("output: got = %q, want = %q", got, want)
+	}
+	// Progress is recorded with the last record whose transformed records are all written,
+	// i.e. 1 when a record of 2 is left in the buffer, and 3 when the buffer is empty.
+	if got, want := strings.Join(progress, ","), "1,3"; got != want {
+		t.Errorf("progress: got = %q, want = %q", got, want)
+	}
+	if got := w.stats.rows.Load(); got != 3 {
+		t.Errorf("rows: got = %d, want = %d", got, 3)
+	}
+}
+
+func TestTableWriter_transformKey(t *testing.T) {
+	table := &Table{Name: "t", Columns: []string{"Tenant", "Id"}, ColumnTypes: []string{"STRING(MAX)", "INT64"}, PrimaryKey: []KeyColumn{{Name: "Tenant"}, {Name: "Id"}}}
+	out := &bytes.Buffer{}
+	var progress []string
+	d := &Dumper{bulkSize: 1}
+	// The transformer rewrites the key column in place.
+	d.AddRowTransformer(RowTransformerFunc(func(table *Table, values []spanner.GenericColumnValue) ([][]spanner.GenericColumnValue, error) {
+		values[0].Value.Kind = &structpb.Value_StringValue{StringValue: "dev"}
+		return [][]spanner.GenericColumnValue{values}, nil
+	}))
+	w, err := d.newTableWriter(d.outputEncoder(), table, out, nil, func(lastRow []string) error {
+		progress = append(progress, strings.Join(lastRow, ","))
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("newTableWriter() failed: %v", err)
+	}
+	values := literalValues(t, table, `"prod"`, "1")
+	if err := w.write(values); err != nil {
+		t.Fatalf("write() failed: %v", err)
+	}
+	if err := w.close(); err != nil {
+		t.Fatalf("close() failed: %v", err)
+	}
+	if want := "INSERT INTO `t` (`Tenant`, `Id`) VALUES (\"dev\", 1);\n"; out.String() != want {
+		t.Errorf("output: got = %q, want = %q", out.String(), want)
+	}
+	// Progress and keys of pages are taken from the record read from the database.
+	if got, want := strings.Join(progress, "|"), `"prod",1`; got != want {
+		t.Errorf("progress: got = %q, want = %q", got, want)
+	}
+	if got := values[0].Value.GetStringValue(); got != "prod" {
+		t.Errorf("record after write(): got = %q, want = %q", got, "prod")
+	}
+}
@@ -22,36 +22,84 @@ import (
 	"strings"
 )
 
+// Spanner allows 80,000 mutations in a commit, but the number of mutations is only estimated,
+// so 20,000 mutations/statement would be safe in most cases.
+// https://cloud.google.com/spanner/quotas#limits_for_creating_reading_updating_and_deleting_data
+const defaultMaxMutations = 20000
+
+// Spanner allows 1,000,000 characters in a statement, which is no more than the number of bytes.
+const defaultMaxStatementBytes = 1000000
+
 // BufferedWriter is a writer to write table records in bulk.
 //
 // NOTE: BufferedWriter is not goroutine-safe.
 type BufferedWriter struct {
-	out      io.Writer
-	table    *Table
-	buffer   []string
-	bulkSize uint
-	upsert   bool
+	out               io.Writer
+	table             *Table
+	buffer            []string
+	bulkSize          uint
+	maxMutations      uint
+	maxStatementBytes uint
+	mutationsPerRow   uint
+	headerBytes       uint
+	bytes             uint
+	upsert            bool
 }
 
-// NewBufferedWriter creates BufferedWriter with specified configs.
+// NewBufferedWriter creates BufferedWriter with specified configs and the default limits of mutations and bytes of a statement
+// (see NewBufferedWriterWithLimits).
 func NewBufferedWriter(table *Table, out io.Writer, bulkSize uint, upsert bool) *BufferedWriter {
+	return NewBufferedWriterWithLimits(table, out, bulkSize, 0, 0, upsert)
+}
+
+// NewBufferedWriterWithLimits creates BufferedWriter with specified configs.
+// A statement includes at most bulkSize records if bulkSize is greater than 0,
+// and records are flushed before the estimated mutations or the byte size of the statement exceed maxMutations or maxStatementBytes.
+// A record exceeding the limits by itself is written in a single statement.
+// If maxMutations or maxStatementBytes is 0, the default limit is used.
+func NewBufferedWriterWithLimits(table *Table, out io.Writer, bulkSize, maxMutations, maxStatementBytes uint, upsert bool) *BufferedWriter {
+	if maxMutations == 0 {
+		maxMutations = defaultMaxMutations
+	}
+	if maxStatementBytes == 0 {
+		maxStatementBytes = defaultMaxStatementBytes
+	}
 	return &BufferedWriter{
-		out:      out,
-		table:    table,
-		buffer:   make([]string, 0, bulkSize),
-		bulkSize: bulkSize,
-		upsert:   upsert,
+		out:               out,
+		table:             table,
+		bulkSize:          bulkSize,
+		maxMutations:      maxMutations,
+		maxStatementBytes: maxStatementBytes,
+		mutationsPerRow:   table.mutationsPerRow(),
+		headerBytes:       uint(len("INSERT OR UPDATE INTO `` () VALUES ;\n") + len(table.Name) + len(table.quotedColumnList())),
+		upsert:            upsert,
 	}
 }
 
-// Write writes a single record into the buffer. If buffer becomes full, it is flushed.
+// Write writes a single record into the buffer.
+// If the record would exceed the limits, the buffer is flushed before the record is buffered.
+// If the buffer becomes full, it is flushed.
 func (w *BufferedWriter) Write(values []string) {
-	w.buffer = append(w.buffer, fmt.Sprintf("(%s)", strings.Join(values, ", ")))
-	if len(w.buffer) >= int(w.bulkSize) {
+	record := fmt.Sprintf("(%s)", strings.Join(values, ", "))
+	recordBytes := uint(len(record) + 2) // 2 is for value separator (", ")
+	if len(w.buffer) > 0 {
+		mutations := uint(len(w.buffer)+1) * w.mutationsPerRow
+		if mutations > w.maxMutations || w.headerBytes+w.bytes+recordBytes > w.maxStatementBytes {
+			w.Flush()
+		}
+	}
+	w.buffer = append(w.buffer, record)
+	w.bytes += recordBytes
+	if w.bulkSize > 0 && len(w.buffer) >= int(w.bulkSize) {
 		w.Flush()
 	}
 }
 
+// Len returns the number of buffered records.
+func (w *BufferedWriter) Len() int {
+	return len(w.buffer)
+}
+
 // Flush flushes the buffered records.
 func (w *BufferedWriter) Flush() {
 	if len(w.buffer) == 0 {
@@ -90,4 +138,5 @@ func (w *BufferedWriter) Flush() {
 
 	fmt.Fprint(w.out, sb.String())
 	w.buffer = w.buffer[:0]
+	w.bytes = 0
 }
@@ -0,0 +1,69 @@
+package spanner_dump
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestBufferedWriter(t *testing.T) {
+	table := &Table{Name: "t", Columns: []string{"A", "B"}, Indexes: []Index{{Name: "idx", Columns: []string{"B"}}}}
+	for _, tt := range []struct {
+		desc              string
+		bulkSize          uint
+		maxMutations      uint
+		maxStatementBytes uint
+		want              []string
+	}{
+		{
+			desc: "Default limits",
+			want: []string{"INSERT INTO `t` (`A`, `B`) VALUES (1, \"a\"), (2, \"bb\"), (3, \"ccc\");"},
+		},
+		{
+			desc:     "Bulk size",
+			bulkSize: 2,
+			want: []string{
+				"INSERT INTO `t` (`A`, `B`) VALUES (1, \"a\"), (2, \"bb\");",
+				"INSERT INTO `t` (`A`, `B`) VALUES (3, \"ccc\");",
+			},
+		},
+		{
+			desc:         "Mutations",
+			maxMutations: 7,
+			want: []string{
+				"INSERT INTO `t` (`A`, `B`) VALUES (1, \"a\"), (2, \"bb\");",
+				"INSERT INTO `t` (`A`, `B`) VALUES (3, \"ccc\");",
+			},
+		},
+		{
+			desc:              "Statement bytes",
+			maxStatementBytes: uint(len("INSERT OR UPDATE INTO `t` (`A`, `B`) VALUES ;\n") + len(`(1, "a"), (2, "bb"), `)),
+			want: []string{
+				"INSERT INTO `t` (`A`, `B`) VALUES (1, \"a\"), (2, \"bb\");",
+				"INSERT INTO `t` (`A`, `B`) VALUES (3, \"ccc\");",
+			},
+		},
+		{
+			desc:              "Records exceeding limits by themselves",
+			maxMutations:      1,
+			maxStatementBytes: 1,
+			want: []string{
+				"INSERT INTO `t` (`A`, `B`) VALUES (1, \"a\");",
+				"INSERT INTO `t` (`A`, `B`) VALUES (2, \"bb\");",
+				"INSERT INTO `t` (`A`, `B`) VALUES (3, \"ccc\");",
+			},
+		},
+	} {
+		t.Run(tt.desc, func(t *testing.T) {
+			out := &bytes.Buffer{}
+			w := NewBufferedWriterWithLimits(table, out, tt.bulkSize, tt.maxMutations, tt.maxStatementBytes, false)
+			w.Write([]string{"1", `"a"`})
+			w.Write([]string{"2", `"bb"`})
+			w.Write([]string{"3", `"ccc"`})
+			w.Flush()
+			if got, want := out.String(), strings.Join(tt.want, "\n")+"\n"; got != want {
+				t.Errorf("BufferedWriter: got = %q, want = %q", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,335 @@
+package spanner_dump
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"cloud.google.com/go/civil"
+	"cloud.google.com/go/spanner"
+	pb "google.golang.org/genproto/googleapis/spanner/v1"
+	structpb "google.golang.org/protobuf/types/known/structpb"
+	"gopkg.in/yaml.v3"
+)
+
+// FormatYAML outputs a YAML file listing records as maps of columns to values for each table.
+const FormatYAML Format = "yaml"
+
+// YAMLEncoder is an Encoder writing a file named <table>.yml for each table in a directory,
+// which lists records as maps of columns to values like fixtures of testfixtures:
+//
+//   - Id: "1"
+//     Name: foo
+//     Data: !!binary YWI=
+//     Tags: [a, null]
+//
+// Values are encoded so that their types are preserved: INT64, NUMERIC, DATE, and TIMESTAMP as quoted strings,
+// BYTES as !!binary in base64, JSON as strings of JSON texts, FLOAT64 including .nan and .inf, BOOL, and STRING as they are,
+// ARRAY as flow sequences, and NULL as null.
+//
+// Files are created when their tables begin, and appended to if their tables begin again or the dump is resumed.
+type YAMLEncoder struct {
+	dir string
+
+	mu      sync.Mutex
+	resumed bool
+	// started are tables whose files have been created in the dump.
+	started map[string]bool
+}
+
+// NewYAMLEncoder creates YAMLEncoder writing files in dir.
+func NewYAMLEncoder(dir string) *YAMLEncoder {
+	return &YAMLEncoder{dir: dir, started: map[string]bool{}}
+}
+
+func (e *YAMLEncoder) BeginDump(out io.Writer, dump *DumpInfo) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.resumed = dump.Resumed
+	return nil
+}
+
+func (e *YAMLEncoder) BeginTable(out io.Writer, table *Table) (TableEncoder, error) {
+	e.mu.Lock()
+	continued := e.started[table.Name]
+	e.started[table.Name] = true
+	resumed := e.resumed
+	e.mu.Unlock()
+
+	flag := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if continued || resumed {
+		flag = os.O_WRONLY | os.O_CREATE | os.O_APPEND
+	}
+	f, err := os.OpenFile(filepath.Join(e.dir, table.Name+".yml"), flag, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	return &yamlTableEncoder{file: f, table: table}, nil
+}
+
+func (e *YAMLEncoder) EndDump(out io.Writer) error {
+	return nil
+}
+
+type yamlTableEncoder struct {
+	file  *os.File
+	table *Table
+}
+
+func (e *yamlTableEncoder) WriteRow(values []spanner.GenericColumnValue) error {
+	row := &yaml.Node{Kind: yaml.MappingNode}
+	for i, v := range values {
+		node, err := yamlNode(v.Type, v.Value)
+		if err != nil {
+			return fmt.Errorf("failed to encode column %s: %v", e.table.Columns[i], err)
+		}
+		row.Content = append(row.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: e.table.Columns[i]}, node)
+	}
+	// A sequence of a single record is appended to the sequence of the preceding records.
+	b, err := yaml.Marshal(&yaml.Node{Kind: yaml.SequenceNode, Content: []*yaml.Node{row}})
+	if err != nil {
+		return err
+	}
+	_, err = e.file.Write(b)
+	return err
+}
+
+func (e *yamlTableEncoder) EndTable() error {
+	return e.file.Close()
+}
+
+// yamlNode returns a YAML node of the value preserving its type.
+func yamlNode(typ *pb.Type, v *structpb.Value) (*yaml.Node, error) {
+	if _, ok := v.GetKind().(*structpb.Value_NullValue); ok {
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null", Value: "null"}, nil
+	}
+	switch typ.GetCode() {
+	case pb.TypeCode_ARRAY:
+		list, ok := v.GetKind().(*structpb.Value_ListValue)
+		if !ok {
+			return nil, fmt.Errorf("unexpected value for ARRAY: %v", v)
+		}
+		node := &yaml.Node{Kind: yaml.SequenceNode, Style: yaml.FlowStyle}
+		for _, e := range list.ListValue.GetValues() {
+			n, err := yamlNode(typ.GetArrayElementType(), e)
+			if err != nil {
+				return nil, err
+			}
+			node.Content = append(node.Content, n)
+		}
+		return node, nil
+	case pb.TypeCode_BOOL:
+		b, ok := v.GetKind().(*structpb.Value_BoolValue)
+		if !ok {
+			return nil, fmt.Errorf("unexpected value for BOOL: %v", v)
+		}
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: strconv.FormatBool(b.BoolValue)}, nil
+	case pb.TypeCode_FLOAT64:
+		var f float64
+		switch k := v.GetKind().(type) {
+		case *structpb.Value_NumberValue:
+			f = k.NumberValue
+		case *structpb.Value_StringValue:
+			// NaN and infinities are encoded in strings.
+			var err error
+			if f, err = strconv.ParseFloat(k.StringValue, 64); err != nil {
+				return nil, fmt.Errorf("unexpected value for FLOAT64: %v", v)
+			}
+		default:
+			return nil, fmt.Errorf("unexpected value for FLOAT64: %v", v)
+		}
+		s := strconv.FormatFloat(f, 'g', -1, 64)
+		switch {
+		case math.IsNaN(f):
+			s = ".nan"
+		case math.IsInf(f, 1):
+			s = ".inf"
+		case math.IsInf(f, -1):
+			s = "-.inf"
+		}
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!float", Value: s}, nil
+	}
+
+	s, ok := v.GetKind().(*structpb.Value_StringValue)
+	if !ok {
+		return nil, fmt.Errorf("unexpected value for %s: %v", typeName(typ), v)
+	}
+	switch typ.GetCode() {
+	case pb.TypeCode_INT64, pb.TypeCode_NUMERIC, pb.TypeCode_DATE, pb.TypeCode_TIMESTAMP:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Style: yaml.DoubleQuotedStyle, Value: s.StringValue}, nil
+	case pb.TypeCode_BYTES:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!binary", Value: s.StringValue}, nil
+	case pb.TypeCode_STRING, pb.TypeCode_JSON:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: s.StringValue}, nil
+	default:
+		return nil, fmt.Errorf("unsupported type: %s", typeName(typ))
+	}
+}
+
+// fixtureRow is a record read from a YAML file, which has values of a subset of the columns of the table.
+type fixtureRow struct {
+	columns []string
+	values  []spanner.GenericColumnValue
+}
+
+// readFixtures reads records of the table from a YAML file in the format written by YAMLEncoder.
+// Values are converted to the types of the columns, so plain YAML values such as integers are also accepted for INT64,
+// and mappings and sequences for JSON. Columns omitted in a record are not inserted.
+func readFixtures(table *Table, r io.Reader) ([]fixtureRow, error) {
+	var doc yaml.Node
+	if err := yaml.NewDecoder(r).Decode(&doc); err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	root := &doc
+	if root.Kind == yaml.DocumentNode {
+		root = root.Content[0]
+	}
+	if root.Kind == yaml.ScalarNode && root.Tag == "!!null" {
+		return nil, nil
+	}
+	if root.Kind != yaml.SequenceNode {
+		return nil, fmt.Errorf("line %d: records must be a sequence", root.Line)
+	}
+
+	types := map[string]*pb.Type{}
+	for i, column := range table.Columns {
+		typ, err := parseSpannerType(table.ColumnTypes[i])
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse type of column %s: %v", column, err)
+		}
+		types[column] = typ
+	}
+	rows := make([]fixtureRow, len(root.Content))
+	for i, node := range root.Content {
+		if node.Kind != yaml.MappingNode {
+			return nil, fmt.Errorf("line %d: a record must be a mapping", node.Line)
+		}
+		for j := 0; j+1 < len(node.Content); j += 2 {
+			column := node.Content[j].Value
+			typ, ok := types[column]
+			if !ok {
+				return nil, fmt.Errorf("line %d: unknown column %s", node.Content[j].Line, column)
+			}
+			value, err := yamlValue(typ, node.Content[j+1])
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid value of column %s: %v", node.Content[j+1].Line, column, err)
+			}
+			rows[i].columns = append(rows[i].columns, column)
+			rows[i].values = append(rows[i].values, spanner.GenericColumnValue{Type: typ, Value: value})
+		}
+	}
+	return rows, nil
+}
+
+// yamlValue converts a YAML node into a value of the type in the encoding of Cloud Spanner API.
+func yamlValue(typ *pb.Type, node *yaml.Node) (*structpb.Value, error) {
+	if node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	if node.Kind == yaml.ScalarNode && node.Tag == "!!null" {
+		return structpb.NewNullValue(), nil
+	}
+	if typ.GetCode() == pb.TypeCode_ARRAY {
+		if node.Kind != yaml.SequenceNode {
+			return nil, fmt.Errorf("sequence is required for %s", typeName(typ))
+		}
+		list := &structpb.ListValue{}
+		for _, e := range node.Content {
+			v, err := yamlValue(typ.GetArrayElementType(), e)
+			if err != nil {
+				return nil, err
+			}
+			list.Values = append(list.Values, v)
+		}
+		return structpb.NewListValue(list), nil
+	}
+	if typ.GetCode() == pb.TypeCode_JSON && node.Kind != yaml.ScalarNode {
+		var v any
+		if err := node.Decode(&v); err != nil {
+			return nil, err
+		}
+		b, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		return structpb.NewStringValue(string(b)), nil
+	}
+	if node.Kind != yaml.ScalarNode {
+		return nil, fmt.Errorf("scalar is required for %s", typeName(typ))
+	}
+
+	s := node.Value
+	switch typ.GetCode() {
+	case pb.TypeCode_BOOL:
+		var b bool
+		if err := node.Decode(&b); err != nil {
+			return nil, err
+		}
+		return structpb.NewBoolValue(b), nil
+	case pb.TypeCode_FLOAT64:
+		var f float64
+		if err := node.Decode(&f); err != nil {
+			return nil, err
+		}
+		switch {
+		case math.IsNaN(f):
+			return structpb.NewStringValue("NaN"), nil
+		case math.IsInf(f, 1):
+			return structpb.NewStringValue("Infinity"), nil
+		case math.IsInf(f, -1):
+			return structpb.NewStringValue("-Infinity"), nil
+		}
+		return structpb.NewNumberValue(f), nil
+	case pb.TypeCode_INT64:
+		if _, err := strconv.ParseInt(s, 10, 64); err != nil {
+			return nil, fmt.Errorf("invalid INT64 %q", s)
+		}
+	case pb.TypeCode_NUMERIC:
+		r, ok := new(big.Rat).SetString(s)
+		if !ok {
+			return nil, fmt.Errorf("invalid NUMERIC %q", s)
+		}
+		if strings.ContainsAny(s, "eE/") {
+			// Spanner accepts only decimal notations.
+			s = spanner.NumericString(r)
+		}
+	case pb.TypeCode_DATE:
+		if _, err := civil.ParseDate(s); err != nil {
+			return nil, fmt.Errorf("invalid DATE %q", s)
+		}
+	case pb.TypeCode_TIMESTAMP:
+		t, err := time.Parse(time.RFC3339Nano, s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid TIMESTAMP %q", s)
+		}
+		s = t.UTC().Format(time.RFC3339Nano)
+	case pb.TypeCode_BYTES:
+		if node.Tag == "!!binary" {
+			b, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(s), ""))
+			if err != nil {
+				return nil, fmt.Errorf("invalid !!binary: %v", err)
+			}
+			s = base64.StdEncoding.EncodeToString(b)
+		} else {
+			// Plain strings are regarded as the bytes of the texts.
+			s = base64.StdEncoding.EncodeToString([]byte(s))
+		}
+	case pb.TypeCode_STRING, pb.TypeCode_JSON:
+	default:
+		return nil, fmt.Errorf("unsupported type: %s", typeName(typ))
+	}
+	return structpb.NewStringValue(s), nil
+}
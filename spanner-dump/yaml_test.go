@@ -0,0 +1,134 @@
+package spanner_dump
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+)
+
+func TestYAMLEncoder(t *testing.T) {
+	table := &Table{
+		Name:        "t",
+		Columns:     []string{"Id", "Name", "Score", "Price", "Birthday", "UpdatedAt", "Data", "Attrs", "Tags", "Flag"},
+		ColumnTypes: []string{"INT64", "STRING(MAX)", "FLOAT64", "NUMERIC", "DATE", "TIMESTAMP", "BYTES(MAX)", "JSON", "ARRAY<STRING(MAX)>", "BOOL"},
+	}
+	rows := [][]string{
+		{"1", `"true"`, "1.5", `NUMERIC "1.25"`, `DATE "2020-01-23"`, `TIMESTAMP "2020-01-23T03:00:00.5Z"`, `b"ab"`, `JSON "{\"a\":1}"`, `["a", NULL]`, "true"},
+		{"2", "NULL", `CAST("nan" AS FLOAT64)`, "NULL", "NULL", "NULL", "NULL", "NULL", "[]", "NULL"},
+	}
+
+	dir := t.TempDir()
+	e := NewYAMLEncoder(dir)
+	if err := e.BeginDump(nil, &DumpInfo{}); err != nil {
+		t.Fatalf("BeginDump() failed: %v", err)
+	}
+	// The table begins again to append the second record.
+	for _, row := range rows {
+		w, err := e.BeginTable(nil, table)
+		if err != nil {
+			t.Fatalf("BeginTable() failed: %v", err)
+		}
+		if err := w.WriteRow(literalValues(t, table, row...)); err != nil {
+			t.Fatalf("WriteRow() failed: %v", err)
+		}
+		if err := w.EndTable(); err != nil {
+			t.Fatalf("EndTable() failed: %v", err)
+		}
+	}
+
+	got, err := os.ReadFile(filepath.Join(dir, "t.yml"))
+	if err != nil {
+		t.Fatalf("failed to read output: %v", err)
+	}
+	want := `- Id: "1"
+  Name: "true"
+  Score: 1.5
+  Price: "1.25"
+  Birthday: "2020-01-23"
+  UpdatedAt: "2020-01-23T03:00:00.5Z"
+  Data: !!binary YWI=
+  Attrs: '{"a":1}'
+  Tags: [a, null]
+  Flag: true
+- Id: "2"
+  Name: null
+  Score: .nan
+  Price: null
+  Birthday: null
+  UpdatedAt: null
+  Data: null
+  Attrs: null
+  Tags: []
+  Flag: null
+`
+	if string(got) != want {
+		t.Errorf("output: got = %q, want = %q", got, want)
+	}
+
+	// Records are read with the same values.
+	fixtures, err := readFixtures(table, strings.NewReader(string(got)))
+	if err != nil {
+		t.Fatalf("readFixtures() failed: %v", err)
+	}
+	if len(fixtures) != len(rows) {
+		t.Fatalf("readFixtures(): got %d records, want %d", len(fixtures), len(rows))
+	}
+	for i, row := range rows {
+		want := literalValues(t, table, row...)
+		for j, v := range fixtures[i].values {
+			if fixtures[i].columns[j] != table.Columns[j] || !proto.Equal(v.Value, want[j].Value) {
+				t.Errorf("readFixtures(): record %d column %s: got = %v, want = %v", i, fixtures[i].columns[j], v.Value, want[j].Value)
+			}
+		}
+	}
+}
+
+func TestReadFixtures(t *testing.T) {
+	table := &Table{
+		Name:        "t",
+		Columns:     []string{"Id", "Price", "Data", "Attrs", "UpdatedAt"},
+		ColumnTypes: []string{"INT64", "NUMERIC", "BYTES(MAX)", "JSON", "TIMESTAMP"},
+	}
+	for _, tt := range []struct {
+		name    string
+		yaml    string
+		want    []string
+		wantErr bool
+	}{
+		{name: "empty", yaml: ""},
+		{
+			name: "plain values",
+			yaml: "- Id: 1\n  Price: 0.5\n  Data: ab\n  Attrs: {b: [1, true]}\n  UpdatedAt: 2020-01-23T12:00:00+09:00\n",
+			want: []string{"1", "0.5", "YWI=", `{"b":[1,true]}`, "2020-01-23T03:00:00Z"},
+		},
+		{name: "omitted columns", yaml: "- Id: 1\n", want: []string{"1"}},
+		{name: "unknown column", yaml: "- Unknown: 1\n", wantErr: true},
+		{name: "invalid INT64", yaml: "- Id: 1.5\n", wantErr: true},
+		{name: "not a sequence", yaml: "Id: 1\n", wantErr: true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			rows, err := readFixtures(table, strings.NewReader(tt.yaml))
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("readFixtures() succeeded, want error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("readFixtures() failed: %v", err)
+			}
+			var got []string
+			for _, row := range rows {
+				for _, v := range row.values {
+					got = append(got, v.Value.GetStringValue())
+				}
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("readFixtures(): got = %v, want = %v", got, tt.want)
+			}
+		})
+	}
+}